@@ -0,0 +1,191 @@
+package main
+
+import (
+	"sort"
+	"strings"
+	"unicode"
+
+	"github.com/gdamore/tcell/v2"
+)
+
+// Action is a command that can be run with a key or from the command palette
+type Action struct {
+	ID          string   // Stable name, e.g. "dashboard.install"
+	Title       string   // Shown in the command palette
+	Description string   // One line explaining what the action does
+	Screen      string   // Screen the action belongs to, or "" for global actions
+	Keys        []string // Keys that run the action, e.g. "s", "Enter", "Ctrl-P"
+	Hint        string   // Short label for the key hints in the status bar, if any
+	Run         func()
+}
+
+// KeyLabel returns the first key of the action for display
+func (a *Action) KeyLabel() string {
+	if len(a.Keys) == 0 {
+		return ""
+	}
+	return a.Keys[0]
+}
+
+// ActionProvider is implemented by screens that register their own actions
+type ActionProvider interface {
+	RegisterActions(reg *ActionRegistry)
+}
+
+// ActionRegistry holds every action of the application
+type ActionRegistry struct {
+	actions []*Action
+}
+
+// NewActionRegistry creates an empty registry
+func NewActionRegistry() *ActionRegistry {
+	return &ActionRegistry{}
+}
+
+// Register adds an action to the registry
+func (r *ActionRegistry) Register(action Action) {
+	r.actions = append(r.actions, &action)
+}
+
+// Bind replaces the keys of the action with the given ID; it returns false if
+// there is no such action
+func (r *ActionRegistry) Bind(id string, keys []string) bool {
+	for _, action := range r.actions {
+		if action.ID == id {
+			action.Keys = keys
+			return true
+		}
+	}
+	return false
+}
+
+// Action returns the action with the given ID, or nil
+func (r *ActionRegistry) Action(id string) *Action {
+	for _, action := range r.actions {
+		if action.ID == id {
+			return action
+		}
+	}
+	return nil
+}
+
+// Actions returns all registered actions, those of screen first
+func (r *ActionRegistry) Actions(screen string) []*Action {
+	actions := make([]*Action, len(r.actions))
+	copy(actions, r.actions)
+	sort.SliceStable(actions, func(i, j int) bool {
+		return actions[i].Screen == screen && actions[j].Screen != screen
+	})
+	return actions
+}
+
+// Lookup returns the action bound to key on screen. Actions of the screen
+// take precedence over global ones.
+func (r *ActionRegistry) Lookup(screen, key string) *Action {
+	var global *Action
+	for _, action := range r.actions {
+		for _, k := range action.Keys {
+			if k != key {
+				continue
+			}
+			if action.Screen == screen {
+				return action
+			}
+			if action.Screen == "" && global == nil {
+				global = action
+			}
+		}
+	}
+	return global
+}
+
+// eventKeyName returns the name of a key event in the form used by Action.Keys
+func eventKeyName(event *tcell.EventKey) string {
+	if event.Key() == tcell.KeyRune {
+		if event.Modifiers()&tcell.ModAlt != 0 {
+			return "Alt-" + string(event.Rune())
+		}
+		if event.Rune() == ' ' {
+			return "Space"
+		}
+		return string(event.Rune())
+	}
+	if name, ok := tcell.KeyNames[event.Key()]; ok {
+		return name
+	}
+	return event.Name()
+}
+
+// fuzzyScore reports whether all characters of query appear in text in order,
+// and scores the match. Consecutive characters and characters at the start of
+// words score higher.
+func fuzzyScore(query, text string) (int, bool) {
+	query = strings.ToLower(strings.TrimSpace(query))
+	if query == "" {
+		return 0, true
+	}
+
+	textRunes := []rune(strings.ToLower(text))
+	score, last := 0, -2
+	ti := 0
+	for _, q := range query {
+		if unicode.IsSpace(q) {
+			continue
+		}
+		found := false
+		for ; ti < len(textRunes); ti++ {
+			if textRunes[ti] != q {
+				continue
+			}
+			score++
+			if ti == last+1 {
+				score += 3
+			}
+			if ti == 0 || !unicode.IsLetter(textRunes[ti-1]) && !unicode.IsDigit(textRunes[ti-1]) {
+				score += 2
+			}
+			last = ti
+			ti++
+			found = true
+			break
+		}
+		if !found {
+			return 0, false
+		}
+	}
+
+	// Prefer shorter texts among equal matches
+	return score*100 - len(textRunes), true
+}
+
+// filterActions returns the actions matching query, best match first
+func filterActions(actions []*Action, query string) []*Action {
+	type scored struct {
+		action *Action
+		score  int
+	}
+
+	var matches []scored
+	for _, action := range actions {
+		best, ok := fuzzyScore(query, action.Title)
+		if !ok {
+			// Fall back to the screen name and description
+			if s, ok := fuzzyScore(query, action.Screen+" "+action.Title+" "+action.Description); ok {
+				best = s - 10000
+			} else {
+				continue
+			}
+		}
+		matches = append(matches, scored{action, best})
+	}
+
+	sort.SliceStable(matches, func(i, j int) bool {
+		return matches[i].score > matches[j].score
+	})
+
+	result := make([]*Action, len(matches))
+	for i, m := range matches {
+		result[i] = m.action
+	}
+	return result
+}
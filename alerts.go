@@ -0,0 +1,480 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"os/exec"
+	"runtime"
+	"strings"
+	"time"
+)
+
+const (
+	alertCheckInterval    = 10 * time.Second
+	defaultAlertCooldown  = 30 * time.Minute
+	alertNotifierTimeout  = 10 * time.Second
+	defaultQuotaThreshold = 85
+)
+
+// ringBell rings the terminal bell; main points it at the screen
+var ringBell func()
+
+// AlertKind is a condition an alert rule watches for
+type AlertKind string
+
+const (
+	AlertQuotaUsage    AlertKind = "quota_usage"    // An account uses more than Threshold percent of its quota
+	AlertAccountFailed AlertKind = "account_failed" // An account is expired or failing
+	AlertProxyDown     AlertKind = "proxy_down"     // The proxy exited without being stopped
+)
+
+// AlertRule raises an alert when its condition has held for ForSeconds
+type AlertRule struct {
+	Kind       AlertKind  `json:"kind"`
+	Threshold  float64    `json:"threshold,omitempty"`   // Usage percent for quota_usage, defaults to 85
+	ForSeconds int        `json:"for_seconds,omitempty"` // How long the condition must hold first
+	Provider   AIProvider `json:"provider,omitempty"`    // Only watch accounts of this provider
+}
+
+// DefaultAlertRules returns the rules used when the config has none
+func DefaultAlertRules() []AlertRule {
+	return []AlertRule{
+		{Kind: AlertQuotaUsage, Threshold: defaultQuotaThreshold},
+		{Kind: AlertAccountFailed},
+		{Kind: AlertProxyDown, ForSeconds: 60},
+	}
+}
+
+// AlertsConfig configures alert rules and the notifiers alerts are sent to
+type AlertsConfig struct {
+	Enabled         bool        `json:"enabled"`
+	Rules           []AlertRule `json:"rules,omitempty"`            // nil uses DefaultAlertRules
+	Bell            bool        `json:"bell"`                       // Ring the terminal bell
+	Desktop         bool        `json:"desktop"`                    // notify-send or D-Bus, osascript on macOS
+	WebhookURL      string      `json:"webhook_url,omitempty"`      // Receives each alert as a JSON POST
+	Command         string      `json:"command,omitempty"`          // Run by the shell with LAZYL2M_ALERT_* variables
+	CooldownMinutes int         `json:"cooldown_minutes,omitempty"` // An alert is sent at most this often, 0 uses 30
+	QuietHours      string      `json:"quiet_hours,omitempty"`      // e.g. "22:00-07:00"; alerts are only logged meanwhile
+}
+
+// AlertRules returns the configured rules or the defaults
+func (c AlertsConfig) AlertRules() []AlertRule {
+	if c.Rules == nil {
+		return DefaultAlertRules()
+	}
+	return c.Rules
+}
+
+// Cooldown returns how long the same alert is not sent again
+func (c AlertsConfig) Cooldown() time.Duration {
+	if c.CooldownMinutes <= 0 {
+		return defaultAlertCooldown
+	}
+	return time.Duration(c.CooldownMinutes) * time.Minute
+}
+
+// ValidateAlerts checks the rules, webhook URL and quiet hours
+func ValidateAlerts(c AlertsConfig) error {
+	for _, rule := range c.Rules {
+		switch rule.Kind {
+		case AlertQuotaUsage, AlertAccountFailed, AlertProxyDown:
+		default:
+			return fmt.Errorf("unknown alert rule %q", rule.Kind)
+		}
+		if rule.Threshold < 0 || rule.Threshold > 100 {
+			return fmt.Errorf("alert threshold %g is not a percentage", rule.Threshold)
+		}
+		if rule.ForSeconds < 0 {
+			return fmt.Errorf("alert rule %q waits a negative time", rule.Kind)
+		}
+	}
+	if c.WebhookURL != "" {
+		u, err := url.Parse(c.WebhookURL)
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			return fmt.Errorf("alert webhook %q is not an http(s) URL", c.WebhookURL)
+		}
+	}
+	if _, _, err := parseQuietHours(c.QuietHours); err != nil {
+		return err
+	}
+	return nil
+}
+
+// parseQuietHours parses "HH:MM-HH:MM" into minutes after midnight. An
+// empty string gives start == end, which never matches.
+func parseQuietHours(s string) (start, end int, err error) {
+	s = strings.TrimSpace(s)
+	if s == "" {
+		return 0, 0, nil
+	}
+	from, to, ok := strings.Cut(s, "-")
+	if !ok {
+		return 0, 0, fmt.Errorf("quiet hours %q must look like 22:00-07:00", s)
+	}
+	clock := func(s string) (int, error) {
+		t, err := time.Parse("15:04", strings.TrimSpace(s))
+		if err != nil {
+			return 0, fmt.Errorf("quiet hours %q must look like 22:00-07:00", s)
+		}
+		return t.Hour()*60 + t.Minute(), nil
+	}
+	if start, err = clock(from); err != nil {
+		return 0, 0, err
+	}
+	if end, err = clock(to); err != nil {
+		return 0, 0, err
+	}
+	return start, end, nil
+}
+
+// inQuietHours reports whether t falls in the quiet hours, which may span
+// midnight
+func inQuietHours(quietHours string, t time.Time) bool {
+	start, end, err := parseQuietHours(quietHours)
+	if err != nil || start == end {
+		return false
+	}
+	minute := t.Hour()*60 + t.Minute()
+	if start < end {
+		return minute >= start && minute < end
+	}
+	return minute >= start || minute < end
+}
+
+// Alert is a rule whose condition holds for one account or the proxy
+type Alert struct {
+	Key     string    `json:"key"` // Rule and subject; alerts with the same key are deduplicated
+	Kind    AlertKind `json:"kind"`
+	Profile string    `json:"profile"`
+	Title   string    `json:"title"`
+	Message string    `json:"message"`
+	Time    time.Time `json:"time"`
+	Since   time.Time `json:"since"` // When the condition started to hold
+	wait    time.Duration
+}
+
+// EvaluateAlerts returns an alert for every rule whose condition holds now
+func EvaluateAlerts(pm *ProxyManager, rules []AlertRule, now time.Time) []Alert {
+	profile := pm.GetProfile()
+	var alerts []Alert
+	add := func(rule AlertRule, subject, title, message string, since time.Time) {
+		alerts = append(alerts, Alert{
+			Key:     fmt.Sprintf("%s/%g/%s/%s", rule.Kind, rule.Threshold, rule.Provider, subject),
+			Kind:    rule.Kind,
+			Profile: profile,
+			Title:   title,
+			Message: message,
+			Time:    now,
+			Since:   since,
+			wait:    time.Duration(rule.ForSeconds) * time.Second,
+		})
+	}
+
+	for _, rule := range rules {
+		switch rule.Kind {
+		case AlertQuotaUsage:
+			threshold := rule.Threshold
+			if threshold == 0 {
+				threshold = defaultQuotaThreshold
+			}
+			// Only quotas reported by the proxy, not estimated ones
+			for _, quota := range pm.GetReportedQuotas() {
+				if (rule.Provider != "" && quota.Provider != rule.Provider) || quota.UsagePercent <= threshold {
+					continue
+				}
+				add(rule, quota.AccountID,
+					fmt.Sprintf("Quota at %.0f%%", quota.UsagePercent),
+					fmt.Sprintf("%s account %s has used %d of %d", GetProviderInfo(quota.Provider).Name, quota.AccountName, quota.Used, quota.Limit),
+					time.Time{})
+			}
+		case AlertAccountFailed:
+			for _, auth := range pm.GetAuthFiles() {
+				if (rule.Provider != "" && auth.Provider != rule.Provider) || (auth.Status != "expired" && auth.Status != "error") {
+					continue
+				}
+				add(rule, auth.ID,
+					fmt.Sprintf("Account %s", auth.Status),
+					fmt.Sprintf("%s account %s is %s", GetProviderInfo(auth.Provider).Name, auth.Name, auth.Status),
+					time.Time{})
+			}
+		case AlertProxyDown:
+			status := pm.GetStatus()
+			if status.Running || status.Starting || status.ExitedAt.IsZero() {
+				continue
+			}
+			add(rule, "proxy",
+				"Proxy down",
+				fmt.Sprintf("The proxy on port %d exited at %s and has not been restarted", status.Port, status.ExitedAt.Format("15:04:05")),
+				status.ExitedAt)
+		}
+	}
+	return alerts
+}
+
+// Alerter checks the alert rules of a profile and sends alerts whose
+// condition has held long enough. An alert is sent once while its condition
+// holds, and not again within the cooldown.
+type Alerter struct {
+	pm       *ProxyManager
+	since    map[string]time.Time // When each holding condition was first seen
+	handled  map[string]bool      // Holding conditions that were sent
+	muted    map[string]bool      // Holding conditions logged during quiet hours
+	lastSent map[string]time.Time
+}
+
+// NewAlerter creates an alerter for the proxy of pm, configured by its
+// profile's config
+func NewAlerter(pm *ProxyManager) *Alerter {
+	return &Alerter{
+		pm:       pm,
+		since:    make(map[string]time.Time),
+		handled:  make(map[string]bool),
+		muted:    make(map[string]bool),
+		lastSent: make(map[string]time.Time),
+	}
+}
+
+// Run checks the rules whenever the proxy manager changes and on a timer,
+// for rules that wait, until the profile is unloaded
+func (a *Alerter) Run() {
+	events, unsubscribe := a.pm.Subscribe()
+	defer unsubscribe()
+	ticker := time.NewTicker(alertCheckInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-a.pm.Done():
+			return
+		case event := <-events:
+			if event == EventLogAppended || event == EventDownloadProgress {
+				continue
+			}
+		case <-ticker.C:
+		}
+		a.Check(time.Now())
+	}
+}
+
+// Check evaluates the rules once and sends the alerts that are due
+func (a *Alerter) Check(now time.Time) {
+	cfg := a.pm.Config().Alerts
+	var alerts []Alert
+	if cfg.Enabled {
+		alerts = EvaluateAlerts(a.pm, cfg.AlertRules(), now)
+	}
+
+	holding := make(map[string]bool)
+	for _, alert := range alerts {
+		holding[alert.Key] = true
+
+		since, seen := a.since[alert.Key]
+		if !seen {
+			since = alert.Since
+			if since.IsZero() {
+				since = now
+			}
+			a.since[alert.Key] = since
+		}
+		alert.Since = since
+
+		if a.handled[alert.Key] || now.Sub(since) < alert.wait {
+			continue
+		}
+		// Checked again on every tick, so it is sent once the cooldown ends
+		if last, ok := a.lastSent[alert.Key]; ok && now.Sub(last) < cfg.Cooldown() {
+			continue
+		}
+		// Alerts still holding when the quiet hours end are sent then
+		if inQuietHours(cfg.QuietHours, now) {
+			if !a.muted[alert.Key] {
+				a.muted[alert.Key] = true
+				a.pm.AddLogExternal(LogLevelWarn, fmt.Sprintf("Alert (quiet hours): %s - %s", alert.Title, alert.Message))
+			}
+			continue
+		}
+
+		a.handled[alert.Key] = true
+		a.lastSent[alert.Key] = now
+		a.pm.AddLogExternal(LogLevelWarn, fmt.Sprintf("Alert: %s - %s", alert.Title, alert.Message))
+		for _, err := range SendAlert(cfg, alert) {
+			a.pm.AddLogExternal(LogLevelError, fmt.Sprintf("Failed to send alert by %v", err))
+		}
+	}
+
+	for key := range a.since {
+		if !holding[key] {
+			delete(a.since, key)
+			delete(a.handled, key)
+			delete(a.muted, key)
+		}
+	}
+}
+
+// SendAlert sends alert to every configured notifier and returns the errors
+// of those that failed
+func SendAlert(cfg AlertsConfig, alert Alert) []error {
+	var errs []error
+	for _, notifier := range Notifiers(cfg) {
+		if err := notifier.Notify(alert); err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", notifier.Name(), err))
+		}
+	}
+	return errs
+}
+
+// Notifier delivers alerts to the user
+type Notifier interface {
+	Name() string
+	Notify(alert Alert) error
+}
+
+// Notifiers returns the notifiers enabled in cfg
+func Notifiers(cfg AlertsConfig) []Notifier {
+	var notifiers []Notifier
+	if cfg.Bell {
+		notifiers = append(notifiers, bellNotifier{})
+	}
+	if cfg.Desktop {
+		notifiers = append(notifiers, desktopNotifier{})
+	}
+	if cfg.WebhookURL != "" {
+		notifiers = append(notifiers, webhookNotifier{url: cfg.WebhookURL})
+	}
+	if strings.TrimSpace(cfg.Command) != "" {
+		notifiers = append(notifiers, commandNotifier{command: cfg.Command})
+	}
+	return notifiers
+}
+
+// bellNotifier rings the terminal bell
+type bellNotifier struct{}
+
+func (bellNotifier) Name() string { return "bell" }
+
+func (bellNotifier) Notify(alert Alert) error {
+	if ringBell == nil {
+		return errors.New("no terminal")
+	}
+	ringBell()
+	return nil
+}
+
+// desktopNotifier shows a desktop notification with notify-send, falling
+// back to calling the notification service over D-Bus with gdbus. macOS uses
+// osascript.
+type desktopNotifier struct{}
+
+func (desktopNotifier) Name() string { return "desktop notification" }
+
+func (desktopNotifier) Notify(alert Alert) error {
+	ctx, cancel := context.WithTimeout(context.Background(), alertNotifierTimeout)
+	defer cancel()
+
+	title := "LazyL2M: " + alert.Title
+	var cmd *exec.Cmd
+	switch {
+	case runtime.GOOS == "darwin":
+		script := fmt.Sprintf("display notification %q with title %q", alert.Message, title)
+		cmd = exec.CommandContext(ctx, "osascript", "-e", script)
+	case runtime.GOOS == "windows":
+		return errors.New("not supported on Windows")
+	case commandExists("notify-send"):
+		cmd = exec.CommandContext(ctx, "notify-send", "--app-name=LazyL2M", title, alert.Message)
+	case commandExists("gdbus"):
+		cmd = exec.CommandContext(ctx, "gdbus", "call", "--session",
+			"--dest", "org.freedesktop.Notifications",
+			"--object-path", "/org/freedesktop/Notifications",
+			"--method", "org.freedesktop.Notifications.Notify",
+			"LazyL2M", "0", "", title, alert.Message, "[]", "{}", "10000")
+	default:
+		return errors.New("neither notify-send nor gdbus found")
+	}
+
+	if output, err := cmd.CombinedOutput(); err != nil {
+		return commandError(err, output)
+	}
+	return nil
+}
+
+// commandError adds the first line of a failed command's output to err
+func commandError(err error, output []byte) error {
+	line, _, _ := strings.Cut(strings.TrimSpace(string(output)), "\n")
+	if line == "" {
+		return err
+	}
+	return fmt.Errorf("%v: %s", err, line)
+}
+
+// commandExists reports whether name is found in PATH
+func commandExists(name string) bool {
+	_, err := exec.LookPath(name)
+	return err == nil
+}
+
+// webhookNotifier posts alerts as JSON. The text field makes the payload
+// readable by chat webhooks such as Slack's.
+type webhookNotifier struct {
+	url string
+}
+
+func (webhookNotifier) Name() string { return "webhook" }
+
+func (n webhookNotifier) Notify(alert Alert) error {
+	payload := struct {
+		Alert
+		Text string `json:"text"`
+	}{alert, fmt.Sprintf("LazyL2M: %s - %s", alert.Title, alert.Message)}
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return err
+	}
+
+	client := &http.Client{Timeout: alertNotifierTimeout}
+	resp, err := client.Post(n.url, "application/json", bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("webhook returned %s", resp.Status)
+	}
+	return nil
+}
+
+// commandNotifier runs a shell command with the alert in its environment
+type commandNotifier struct {
+	command string
+}
+
+func (commandNotifier) Name() string { return "command" }
+
+func (n commandNotifier) Notify(alert Alert) error {
+	ctx, cancel := context.WithTimeout(context.Background(), alertNotifierTimeout)
+	defer cancel()
+
+	cmd := exec.CommandContext(ctx, "sh", "-c", n.command)
+	if runtime.GOOS == "windows" {
+		cmd = exec.CommandContext(ctx, "cmd", "/C", n.command)
+	}
+	cmd.Env = append(os.Environ(),
+		"LAZYL2M_ALERT_KEY="+alert.Key,
+		"LAZYL2M_ALERT_KIND="+string(alert.Kind),
+		"LAZYL2M_ALERT_PROFILE="+alert.Profile,
+		"LAZYL2M_ALERT_TITLE="+alert.Title,
+		"LAZYL2M_ALERT_MESSAGE="+alert.Message,
+		"LAZYL2M_ALERT_TIME="+alert.Time.Format(time.RFC3339),
+	)
+
+	if output, err := cmd.CombinedOutput(); err != nil {
+		return commandError(err, output)
+	}
+	return nil
+}
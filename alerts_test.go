@@ -0,0 +1,208 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// webhookPayload is what the webhook notifier posts
+type webhookPayload struct {
+	Key     string    `json:"key"`
+	Kind    AlertKind `json:"kind"`
+	Profile string    `json:"profile"`
+	Title   string    `json:"title"`
+	Message string    `json:"message"`
+	Time    time.Time `json:"time"`
+	Since   time.Time `json:"since"`
+	Text    string    `json:"text"`
+}
+
+// webhookReceiver records the alerts posted to a local webhook
+type webhookReceiver struct {
+	mutex    sync.Mutex
+	payloads []webhookPayload
+}
+
+// serve starts the webhook and returns its URL
+func (r *webhookReceiver) serve(t *testing.T) string {
+	t.Helper()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		if req.Method != http.MethodPost || req.Header.Get("Content-Type") != "application/json" {
+			t.Errorf("got %s with content type %q, want a JSON POST", req.Method, req.Header.Get("Content-Type"))
+		}
+		var payload webhookPayload
+		if err := json.NewDecoder(req.Body).Decode(&payload); err != nil {
+			t.Errorf("invalid payload: %v", err)
+		}
+		r.mutex.Lock()
+		r.payloads = append(r.payloads, payload)
+		r.mutex.Unlock()
+	}))
+	t.Cleanup(server.Close)
+	return server.URL
+}
+
+// received returns the alerts posted so far
+func (r *webhookReceiver) received() []webhookPayload {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	return append([]webhookPayload(nil), r.payloads...)
+}
+
+// newAlertTest returns an alerter whose alerts go to a webhook, its proxy
+// manager and the webhook's receiver
+func newAlertTest(t *testing.T, alerts AlertsConfig) (*Alerter, *ProxyManager, *webhookReceiver) {
+	t.Helper()
+	t.Setenv("HOME", t.TempDir())
+	receiver := &webhookReceiver{}
+	alerts.Enabled = true
+	alerts.WebhookURL = receiver.serve(t)
+	alerts.Rules = []AlertRule{{Kind: AlertQuotaUsage, Threshold: 80}}
+	pm := NewProxyManager(&Config{Port: 8317, Profile: "work", Alerts: alerts})
+	return NewAlerter(pm), pm, receiver
+}
+
+// setQuotaUsage makes the proxy report one Claude account at percent usage,
+// or none for a negative percent
+func setQuotaUsage(pm *ProxyManager, percent int) {
+	pm.mutex.Lock()
+	defer pm.mutex.Unlock()
+	pm.quotaInfos = nil
+	if percent >= 0 {
+		pm.quotaInfos = []QuotaInfo{{
+			AccountID:    "claude-user@example.com.json",
+			AccountName:  "user@example.com",
+			Provider:     ProviderClaude,
+			Used:         percent,
+			Limit:        100,
+			UsagePercent: float64(percent),
+		}}
+	}
+}
+
+func TestAlerterWebhookPayload(t *testing.T) {
+	alerter, pm, receiver := newAlertTest(t, AlertsConfig{})
+	setQuotaUsage(pm, 90)
+
+	now := time.Date(2026, 3, 2, 12, 0, 0, 0, time.UTC)
+	alerter.Check(now)
+
+	got := receiver.received()
+	if len(got) != 1 {
+		t.Fatalf("received %d alerts, want 1", len(got))
+	}
+	want := webhookPayload{
+		Key:     "quota_usage/80//claude-user@example.com.json",
+		Kind:    AlertQuotaUsage,
+		Profile: "work",
+		Title:   "Quota at 90%",
+		Message: "Claude account user@example.com has used 90 of 100",
+		Time:    now,
+		Since:   now,
+		Text:    "LazyL2M: Quota at 90% - Claude account user@example.com has used 90 of 100",
+	}
+	if !got[0].Time.Equal(want.Time) || !got[0].Since.Equal(want.Since) {
+		t.Fatalf("got times %v and %v, want %v", got[0].Time, got[0].Since, now)
+	}
+	got[0].Time, got[0].Since = want.Time, want.Since
+	if got[0] != want {
+		t.Fatalf("got payload\n%+v\nwant\n%+v", got[0], want)
+	}
+}
+
+func TestAlerterDedupeAndCooldown(t *testing.T) {
+	alerter, pm, receiver := newAlertTest(t, AlertsConfig{CooldownMinutes: 10})
+	start := time.Date(2026, 3, 2, 12, 0, 0, 0, time.UTC)
+
+	steps := []struct {
+		name    string
+		minute  int
+		percent int // Negative when the proxy reports no quota
+		sent    int // Alerts received so far
+	}{
+		{"condition starts", 0, 90, 1},
+		{"condition still holds", 1, 95, 1},
+		{"condition clears", 2, 50, 1},
+		{"condition returns within the cooldown", 3, 90, 1},
+		{"condition still holds within the cooldown", 9, 90, 1},
+		{"condition still holds after the cooldown", 10, 90, 2},
+		{"condition still holds after being resent", 11, 90, 2},
+		{"quota no longer reported", 12, -1, 2},
+		{"condition returns within the cooldown again", 13, 90, 2},
+		{"condition returns after the cooldown", 21, 90, 3},
+	}
+	for _, step := range steps {
+		setQuotaUsage(pm, step.percent)
+		alerter.Check(start.Add(time.Duration(step.minute) * time.Minute))
+		if got := len(receiver.received()); got != step.sent {
+			t.Fatalf("%s: received %d alerts, want %d", step.name, got, step.sent)
+		}
+	}
+
+	resent := receiver.received()[1]
+	if want := start.Add(3 * time.Minute); !resent.Since.Equal(want) {
+		t.Fatalf("resent alert holds since %v, want %v", resent.Since, want)
+	}
+}
+
+func TestAlerterQuietHours(t *testing.T) {
+	alerter, pm, receiver := newAlertTest(t, AlertsConfig{QuietHours: "22:00-07:00"})
+	setQuotaUsage(pm, 90)
+
+	night := time.Date(2026, 3, 2, 23, 0, 0, 0, time.UTC)
+	alerter.Check(night)
+	alerter.Check(night.Add(30 * time.Minute))
+	if got := receiver.received(); len(got) != 0 {
+		t.Fatalf("received %d alerts during quiet hours", len(got))
+	}
+
+	logged := 0
+	for _, entry := range pm.GetLogs() {
+		if strings.HasPrefix(entry.Message, "Alert (quiet hours): Quota at 90%") {
+			logged++
+		}
+	}
+	if logged != 1 {
+		t.Fatalf("alert logged %d times during quiet hours, want once", logged)
+	}
+
+	// Still holding when the quiet hours end, so it is sent then
+	morning := time.Date(2026, 3, 3, 7, 0, 0, 0, time.UTC)
+	alerter.Check(morning)
+	got := receiver.received()
+	if len(got) != 1 {
+		t.Fatalf("received %d alerts after quiet hours, want 1", len(got))
+	}
+	if !got[0].Since.Equal(night) || !got[0].Time.Equal(morning) {
+		t.Fatalf("alert holds since %v at %v, want since %v at %v", got[0].Since, got[0].Time, night, morning)
+	}
+}
+
+func TestAlerterStopsWhenProfileUnloads(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	pm := NewProxyManager(&Config{Port: 8317})
+
+	stopped := make(chan struct{})
+	go func() {
+		NewAlerter(pm).Run()
+		close(stopped)
+	}()
+
+	pm.Close()
+	select {
+	case <-stopped:
+	case <-time.After(time.Second):
+		t.Fatal("alerter still running after the profile was unloaded")
+	}
+
+	pm.events.mutex.Lock()
+	defer pm.events.mutex.Unlock()
+	if len(pm.events.subscribers) != 0 {
+		t.Fatal("alerter still subscribed to events")
+	}
+}
@@ -0,0 +1,200 @@
+package main
+
+import (
+	"fmt"
+	"path"
+	"runtime"
+	"sort"
+	"strings"
+)
+
+// osSynonyms are the names release assets use for each GOOS
+var osSynonyms = map[string][]string{
+	"linux":   {"linux"},
+	"darwin":  {"darwin", "macos", "osx", "mac", "apple"},
+	"windows": {"windows", "win", "win32", "win64"},
+	"freebsd": {"freebsd"},
+	"openbsd": {"openbsd"},
+	"netbsd":  {"netbsd"},
+}
+
+// archSynonyms are the names release assets use for each GOARCH. x86_64 and
+// x86-64 are rewritten to amd64 before names are split into tokens.
+var archSynonyms = map[string][]string{
+	"amd64":   {"amd64", "x64", "x86lp64"},
+	"arm64":   {"arm64", "aarch64", "armv8", "armv8a"},
+	"386":     {"386", "i386", "i686", "x86", "ia32"},
+	"arm":     {"arm", "armv7", "armv7l", "armv6", "armv6l", "armhf", "armel"},
+	"riscv64": {"riscv64"},
+	"ppc64le": {"ppc64le"},
+	"s390x":   {"s390x"},
+}
+
+// nonBinarySuffixes mark assets that are metadata rather than something to install
+var nonBinarySuffixes = []string{
+	".sha256", ".sha512", ".sha1", ".md5", ".sig", ".asc", ".pem", ".crt", ".sbom",
+	".spdx", ".cdx", ".intoto.jsonl", ".json", ".txt", ".yaml", ".yml", ".md",
+	".deb", ".rpm", ".apk", ".msi", ".pkg", ".dmg",
+}
+
+// assetCandidate is a release asset with its match score, or why it was rejected
+type assetCandidate struct {
+	asset  assetInfo
+	score  int
+	reason string
+}
+
+// findCompatibleAsset finds the release asset for the current platform
+func (pm *ProxyManager) findCompatibleAsset(release *releaseInfo) (*assetInfo, error) {
+	asset, err := matchAsset(release.Assets, runtime.GOOS, runtime.GOARCH)
+	if err != nil {
+		return nil, fmt.Errorf("%s %s: %w", pm.releaseRepo(), release.TagName, err)
+	}
+	return asset, nil
+}
+
+// matchAsset scores every asset against goos and goarch and returns the best
+// one. Assets must name the OS and architecture; archives are preferred over
+// bare binaries. The error lists each asset and why it was rejected.
+func matchAsset(assets []assetInfo, goos, goarch string) (*assetInfo, error) {
+	candidates := make([]assetCandidate, 0, len(assets))
+	for _, asset := range assets {
+		score, reason := scoreAsset(asset.Name, goos, goarch)
+		candidates = append(candidates, assetCandidate{asset: asset, score: score, reason: reason})
+	}
+
+	// Highest score first; the release order breaks ties
+	sort.SliceStable(candidates, func(i, j int) bool {
+		return candidates[i].score > candidates[j].score
+	})
+
+	if len(candidates) > 0 && candidates[0].score > 0 {
+		asset := candidates[0].asset
+		return &asset, nil
+	}
+
+	if len(candidates) == 0 {
+		return nil, fmt.Errorf("release has no assets")
+	}
+	considered := make([]string, 0, len(candidates))
+	for _, c := range candidates {
+		considered = append(considered, fmt.Sprintf("%s (%s)", c.asset.Name, c.reason))
+	}
+	return nil, fmt.Errorf("no asset for %s/%s; considered %s", goos, goarch, strings.Join(considered, ", "))
+}
+
+// scoreAsset rates how well an asset name fits goos and goarch. A score of 0
+// means the asset can't be used, and reason says why.
+func scoreAsset(name, goos, goarch string) (int, string) {
+	lower := strings.ToLower(name)
+
+	if strings.Contains(lower, "checksum") || strings.Contains(lower, "sbom") {
+		return 0, "checksum or SBOM file"
+	}
+	for _, suffix := range nonBinarySuffixes {
+		if strings.HasSuffix(lower, suffix) {
+			return 0, fmt.Sprintf("%s file", strings.TrimPrefix(suffix, "."))
+		}
+	}
+
+	tokens := assetTokens(lower)
+
+	osScore, reason := matchPlatform(tokens, goos, osSynonyms, "OS")
+	if osScore == 0 {
+		return 0, reason
+	}
+	archScore, reason := matchPlatform(tokens, goarch, archSynonyms, "architecture")
+	if archScore == 0 {
+		// macOS universal binaries run on every architecture
+		if goos != "darwin" || !(tokens["universal"] || tokens["all"]) {
+			return 0, reason
+		}
+		archScore = 1
+	}
+
+	score := osScore + archScore
+	switch {
+	case strings.HasSuffix(lower, ".tar.gz") || strings.HasSuffix(lower, ".tgz"):
+		score += 3
+		if goos == "windows" {
+			score--
+		}
+	case strings.HasSuffix(lower, ".zip"):
+		score += 2
+		if goos == "windows" {
+			score += 2
+		}
+	case strings.HasSuffix(lower, ".exe"):
+		if goos != "windows" {
+			return 0, "Windows executable"
+		}
+		score++
+	case isBareBinaryName(lower):
+		score++
+	default:
+		return 0, fmt.Sprintf("unsupported format %s", path.Ext(lower))
+	}
+	return score, ""
+}
+
+// assetTokens splits an asset name into words, keeping x86_64 in one piece
+func assetTokens(name string) map[string]bool {
+	name = strings.NewReplacer("x86_64", "amd64", "x86-64", "amd64").Replace(name)
+	tokens := make(map[string]bool)
+	for _, token := range strings.FieldsFunc(name, func(r rune) bool {
+		return r == '_' || r == '-' || r == '.' || r == ' '
+	}) {
+		tokens[token] = true
+	}
+	return tokens
+}
+
+// matchPlatform scores tokens against one OS or architecture. It returns 2 for
+// a match and 0 with a reason when the name targets another platform or none.
+func matchPlatform(tokens map[string]bool, want string, synonyms map[string][]string, kind string) (int, string) {
+	for _, synonym := range platformSynonyms(want, synonyms) {
+		if tokens[synonym] {
+			return 2, ""
+		}
+	}
+
+	names := make([]string, 0, len(synonyms))
+	for name := range synonyms {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, other := range names {
+		if other == want {
+			continue
+		}
+		for _, synonym := range synonyms[other] {
+			if tokens[synonym] {
+				return 0, fmt.Sprintf("built for %s", other)
+			}
+		}
+	}
+	return 0, fmt.Sprintf("no %s in name", kind)
+}
+
+// platformSynonyms returns the names of a GOOS or GOARCH, including itself
+func platformSynonyms(want string, synonyms map[string][]string) []string {
+	if names, ok := synonyms[want]; ok {
+		return names
+	}
+	return []string{want}
+}
+
+// isBareBinaryName reports whether a name has no file extension. Dots inside
+// a version, as in "cli-proxy-api-6.1.0-linux-amd64", don't count.
+func isBareBinaryName(name string) bool {
+	ext := strings.TrimPrefix(path.Ext(name), ".")
+	if ext == "" || strings.ContainsAny(ext, "-_") {
+		return true
+	}
+	for _, r := range ext {
+		if r < '0' || r > '9' {
+			return false
+		}
+	}
+	return true
+}
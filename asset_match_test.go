@@ -0,0 +1,205 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+// assets returns release assets with the given names
+func assets(names ...string) []assetInfo {
+	list := make([]assetInfo, 0, len(names))
+	for _, name := range names {
+		list = append(list, assetInfo{Name: name, DownloadURL: "https://example.com/" + name})
+	}
+	return list
+}
+
+// CLIProxyAPI release assets, as published by goreleaser
+var cliProxyAPIAssets = assets(
+	"CLIProxyAPI_6.1.0_darwin_amd64.tar.gz",
+	"CLIProxyAPI_6.1.0_darwin_arm64.tar.gz",
+	"CLIProxyAPI_6.1.0_linux_amd64.tar.gz",
+	"CLIProxyAPI_6.1.0_linux_arm64.tar.gz",
+	"CLIProxyAPI_6.1.0_windows_amd64.zip",
+	"CLIProxyAPI_6.1.0_windows_arm64.zip",
+	"checksums.txt",
+)
+
+// goreleaser's title-cased default archive names, with signing and SBOM output
+var goreleaserAssets = assets(
+	"checksums.txt",
+	"checksums.txt.sig",
+	"checksums.txt.pem",
+	"CLIProxyAPI_6.1.0_Linux_x86_64.tar.gz.sbom.json",
+	"CLIProxyAPI_6.1.0_Linux_x86_64.tar.gz.sig",
+	"CLIProxyAPI_6.1.0_Linux_x86_64.tar.gz",
+	"CLIProxyAPI_6.1.0_Linux_arm64.tar.gz",
+	"CLIProxyAPI_6.1.0_Linux_i386.tar.gz",
+	"CLIProxyAPI_6.1.0_Linux_armv7.tar.gz",
+	"CLIProxyAPI_6.1.0_Darwin_all.tar.gz",
+	"CLIProxyAPI_6.1.0_Windows_x86_64.zip",
+	"CLIProxyAPI_6.1.0_Windows_i386.zip",
+	"cliproxyapi_6.1.0_linux_amd64.deb",
+	"cliproxyapi_6.1.0_linux_amd64.rpm",
+)
+
+func TestMatchAsset(t *testing.T) {
+	tests := []struct {
+		name   string
+		assets []assetInfo
+		goos   string
+		goarch string
+		want   string
+	}{
+		{"release linux amd64", cliProxyAPIAssets, "linux", "amd64", "CLIProxyAPI_6.1.0_linux_amd64.tar.gz"},
+		{"release linux arm64", cliProxyAPIAssets, "linux", "arm64", "CLIProxyAPI_6.1.0_linux_arm64.tar.gz"},
+		{"release darwin amd64", cliProxyAPIAssets, "darwin", "amd64", "CLIProxyAPI_6.1.0_darwin_amd64.tar.gz"},
+		{"release darwin arm64", cliProxyAPIAssets, "darwin", "arm64", "CLIProxyAPI_6.1.0_darwin_arm64.tar.gz"},
+		{"release windows amd64", cliProxyAPIAssets, "windows", "amd64", "CLIProxyAPI_6.1.0_windows_amd64.zip"},
+		{"release windows arm64", cliProxyAPIAssets, "windows", "arm64", "CLIProxyAPI_6.1.0_windows_arm64.zip"},
+
+		{"goreleaser Linux_x86_64", goreleaserAssets, "linux", "amd64", "CLIProxyAPI_6.1.0_Linux_x86_64.tar.gz"},
+		{"goreleaser arm64", goreleaserAssets, "linux", "arm64", "CLIProxyAPI_6.1.0_Linux_arm64.tar.gz"},
+		{"goreleaser i386", goreleaserAssets, "linux", "386", "CLIProxyAPI_6.1.0_Linux_i386.tar.gz"},
+		{"goreleaser armv7", goreleaserAssets, "linux", "arm", "CLIProxyAPI_6.1.0_Linux_armv7.tar.gz"},
+		{"goreleaser darwin universal on arm64", goreleaserAssets, "darwin", "arm64", "CLIProxyAPI_6.1.0_Darwin_all.tar.gz"},
+		{"goreleaser darwin universal on amd64", goreleaserAssets, "darwin", "amd64", "CLIProxyAPI_6.1.0_Darwin_all.tar.gz"},
+		{"goreleaser windows zip", goreleaserAssets, "windows", "amd64", "CLIProxyAPI_6.1.0_Windows_x86_64.zip"},
+		{"goreleaser windows 386", goreleaserAssets, "windows", "386", "CLIProxyAPI_6.1.0_Windows_i386.zip"},
+
+		{
+			"aarch64 spelling",
+			assets("cli-proxy-api-linux-x86_64.tar.gz", "cli-proxy-api-linux-aarch64.tar.gz"),
+			"linux", "arm64", "cli-proxy-api-linux-aarch64.tar.gz",
+		},
+		{
+			"macOS spelling",
+			assets("cli-proxy-api-linux-x64.tar.gz", "cli-proxy-api-macos-arm64.tar.gz", "cli-proxy-api-macos-x64.tar.gz"),
+			"darwin", "amd64", "cli-proxy-api-macos-x64.tar.gz",
+		},
+		{
+			"macOS universal",
+			assets("CLIProxyAPI-windows-x64.zip", "CLIProxyAPI-macOS-universal.zip"),
+			"darwin", "arm64", "CLIProxyAPI-macOS-universal.zip",
+		},
+		{
+			"native build over universal",
+			assets("CLIProxyAPI_Darwin_all.tar.gz", "CLIProxyAPI_Darwin_arm64.tar.gz"),
+			"darwin", "arm64", "CLIProxyAPI_Darwin_arm64.tar.gz",
+		},
+		{
+			"windows zip over tar.gz and exe",
+			assets("cli-proxy-api-windows-amd64.exe", "cli-proxy-api-windows-amd64.tar.gz", "cli-proxy-api-windows-amd64.zip"),
+			"windows", "amd64", "cli-proxy-api-windows-amd64.zip",
+		},
+		{
+			"archive over bare binary",
+			assets("cli-proxy-api-6.1.0-linux-amd64", "cli-proxy-api-6.1.0-linux-amd64.tar.gz"),
+			"linux", "amd64", "cli-proxy-api-6.1.0-linux-amd64.tar.gz",
+		},
+		{
+			"bare binary with version dots",
+			assets("cli-proxy-api-6.1.0-linux-amd64.sig", "cli-proxy-api-6.1.0-linux-amd64"),
+			"linux", "amd64", "cli-proxy-api-6.1.0-linux-amd64",
+		},
+		{
+			"sbom and signature decoys listed first",
+			assets("CLIProxyAPI_linux_amd64.tar.gz.sbom", "CLIProxyAPI_linux_amd64.tar.gz.sig", "CLIProxyAPI_linux_amd64.tar.gz.sha256", "CLIProxyAPI_linux_amd64.tar.gz"),
+			"linux", "amd64", "CLIProxyAPI_linux_amd64.tar.gz",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			asset, err := matchAsset(tt.assets, tt.goos, tt.goarch)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if asset.Name != tt.want {
+				t.Fatalf("picked %s, want %s", asset.Name, tt.want)
+			}
+		})
+	}
+}
+
+func TestMatchAssetNoMatch(t *testing.T) {
+	tests := []struct {
+		name   string
+		assets []assetInfo
+		goos   string
+		goarch string
+		want   []string
+	}{
+		{
+			name:   "other OS",
+			assets: cliProxyAPIAssets,
+			goos:   "freebsd",
+			goarch: "amd64",
+			want: []string{
+				"no asset for freebsd/amd64",
+				"CLIProxyAPI_6.1.0_linux_amd64.tar.gz (built for linux)",
+				"CLIProxyAPI_6.1.0_darwin_arm64.tar.gz (built for darwin)",
+				"CLIProxyAPI_6.1.0_windows_amd64.zip (built for windows)",
+				"checksums.txt (checksum or SBOM file)",
+			},
+		},
+		{
+			name:   "other architecture",
+			assets: goreleaserAssets,
+			goos:   "linux",
+			goarch: "riscv64",
+			want: []string{
+				"no asset for linux/riscv64",
+				"CLIProxyAPI_6.1.0_Linux_x86_64.tar.gz (built for amd64)",
+				"CLIProxyAPI_6.1.0_Linux_arm64.tar.gz (built for arm64)",
+				"CLIProxyAPI_6.1.0_Windows_x86_64.zip (built for windows)",
+				"checksums.txt.sig (checksum or SBOM file)",
+				"CLIProxyAPI_6.1.0_Linux_x86_64.tar.gz.sig (sig file)",
+				"cliproxyapi_6.1.0_linux_amd64.deb (deb file)",
+			},
+		},
+		{
+			name:   "no platform in names",
+			assets: assets("cli-proxy-api.tar.gz", "cli-proxy-api-linux.tar.gz", "cli-proxy-api-linux-amd64.7z"),
+			goos:   "linux",
+			goarch: "amd64",
+			want: []string{
+				"cli-proxy-api.tar.gz (no OS in name)",
+				"cli-proxy-api-linux.tar.gz (no architecture in name)",
+				"cli-proxy-api-linux-amd64.7z (unsupported format .7z)",
+			},
+		},
+		{
+			name:   "windows executable elsewhere",
+			assets: assets("cli-proxy-api-linux-amd64.exe"),
+			goos:   "linux",
+			goarch: "amd64",
+			want:   []string{"cli-proxy-api-linux-amd64.exe (Windows executable)"},
+		},
+		{
+			name:   "empty release",
+			goos:   "linux",
+			goarch: "amd64",
+			want:   []string{"release has no assets"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			asset, err := matchAsset(tt.assets, tt.goos, tt.goarch)
+			if err == nil {
+				t.Fatalf("picked %s, want an error", asset.Name)
+			}
+			for _, want := range tt.want {
+				if !strings.Contains(err.Error(), want) {
+					t.Errorf("error does not mention %q:\n%v", want, err)
+				}
+			}
+			for _, asset := range tt.assets {
+				if !strings.Contains(err.Error(), asset.Name) {
+					t.Errorf("error does not list candidate %s", asset.Name)
+				}
+			}
+		})
+	}
+}
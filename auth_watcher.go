@@ -0,0 +1,179 @@
+package main
+
+import (
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	authPollInterval    = 2 * time.Second
+	authWatcherDebounce = 250 * time.Millisecond
+)
+
+// AuthDirOp describes what happened to a file in the auth directory
+type AuthDirOp string
+
+const (
+	AuthDirAdded   AuthDirOp = "added"
+	AuthDirRemoved AuthDirOp = "removed"
+	AuthDirChanged AuthDirOp = "changed"
+)
+
+// AuthDirEvent represents a single change to an auth file
+type AuthDirEvent struct {
+	Name string
+	Op   AuthDirOp
+}
+
+// AuthWatcher watches the auth directory for added, removed or changed files.
+// It uses inotify where available and falls back to polling file mtimes.
+type AuthWatcher struct {
+	dir      string
+	onChange func([]AuthDirEvent)
+	stop     chan struct{}
+	stopOnce sync.Once
+
+	// Pending events collected during the debounce window
+	pending   map[string]AuthDirOp
+	pendingMu sync.Mutex
+	timer     *time.Timer
+}
+
+// NewAuthWatcher creates a watcher for dir that calls onChange with batched events
+func NewAuthWatcher(dir string, onChange func([]AuthDirEvent)) *AuthWatcher {
+	return &AuthWatcher{
+		dir:      dir,
+		onChange: onChange,
+		stop:     make(chan struct{}),
+		pending:  map[string]AuthDirOp{},
+	}
+}
+
+// Start begins watching in the background. It returns the mode in use
+// ("inotify" or "polling") so callers can log it.
+func (w *AuthWatcher) Start() string {
+	if err := w.startNative(); err == nil {
+		return "inotify"
+	}
+	go w.poll()
+	return "polling"
+}
+
+// Stop stops watching the directory
+func (w *AuthWatcher) Stop() {
+	w.stopOnce.Do(func() {
+		close(w.stop)
+	})
+}
+
+// isAuthFileName reports whether name looks like an auth file
+func isAuthFileName(name string) bool {
+	return strings.HasSuffix(name, ".json") && !strings.HasPrefix(name, ".")
+}
+
+// queue records an event and schedules a debounced flush
+func (w *AuthWatcher) queue(name string, op AuthDirOp) {
+	if !isAuthFileName(name) {
+		return
+	}
+
+	w.pendingMu.Lock()
+	defer w.pendingMu.Unlock()
+
+	// Collapse sequences such as create+write into a single event
+	if prev, ok := w.pending[name]; ok {
+		switch {
+		case prev == AuthDirAdded && op == AuthDirChanged:
+			op = AuthDirAdded
+		case prev == AuthDirAdded && op == AuthDirRemoved:
+			delete(w.pending, name)
+			return
+		case prev == AuthDirRemoved && op == AuthDirAdded:
+			op = AuthDirChanged
+		}
+	}
+	w.pending[name] = op
+
+	if w.timer == nil {
+		w.timer = time.AfterFunc(authWatcherDebounce, w.flush)
+	} else {
+		w.timer.Reset(authWatcherDebounce)
+	}
+}
+
+// flush delivers all pending events to the change handler
+func (w *AuthWatcher) flush() {
+	w.pendingMu.Lock()
+	events := make([]AuthDirEvent, 0, len(w.pending))
+	for name, op := range w.pending {
+		events = append(events, AuthDirEvent{Name: name, Op: op})
+	}
+	w.pending = map[string]AuthDirOp{}
+	w.pendingMu.Unlock()
+
+	if len(events) > 0 && w.onChange != nil {
+		w.onChange(events)
+	}
+}
+
+// authFileStamp is the state compared between polls
+type authFileStamp struct {
+	modTime time.Time
+	size    int64
+}
+
+// snapshot reads mtimes and sizes of the auth files in the directory
+func (w *AuthWatcher) snapshot() map[string]authFileStamp {
+	stamps := map[string]authFileStamp{}
+
+	entries, err := os.ReadDir(w.dir)
+	if err != nil {
+		return stamps
+	}
+
+	for _, entry := range entries {
+		if entry.IsDir() || !isAuthFileName(entry.Name()) {
+			continue
+		}
+		info, err := entry.Info()
+		if err != nil {
+			continue
+		}
+		stamps[entry.Name()] = authFileStamp{modTime: info.ModTime(), size: info.Size()}
+	}
+
+	return stamps
+}
+
+// poll compares directory snapshots on an interval
+func (w *AuthWatcher) poll() {
+	ticker := time.NewTicker(authPollInterval)
+	defer ticker.Stop()
+
+	prev := w.snapshot()
+	for {
+		select {
+		case <-w.stop:
+			return
+		case <-ticker.C:
+		}
+
+		cur := w.snapshot()
+		for name, stamp := range cur {
+			old, ok := prev[name]
+			if !ok {
+				w.queue(name, AuthDirAdded)
+			} else if !old.modTime.Equal(stamp.modTime) || old.size != stamp.size {
+				w.queue(name, AuthDirChanged)
+			}
+		}
+		for name := range prev {
+			if _, ok := cur[name]; !ok {
+				w.queue(name, AuthDirRemoved)
+			}
+		}
+		prev = cur
+	}
+}
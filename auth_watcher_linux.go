@@ -0,0 +1,83 @@
+//go:build linux
+
+package main
+
+import (
+	"bytes"
+	"os"
+	"syscall"
+	"unsafe"
+)
+
+const inotifyMask = syscall.IN_CREATE | syscall.IN_CLOSE_WRITE | syscall.IN_MODIFY |
+	syscall.IN_DELETE | syscall.IN_MOVED_FROM | syscall.IN_MOVED_TO
+
+// startNative watches the directory with inotify
+func (w *AuthWatcher) startNative() error {
+	fd, err := syscall.InotifyInit1(syscall.IN_CLOEXEC | syscall.IN_NONBLOCK)
+	if err != nil {
+		return err
+	}
+
+	if _, err := syscall.InotifyAddWatch(fd, w.dir, inotifyMask); err != nil {
+		syscall.Close(fd)
+		return err
+	}
+
+	// A non-blocking fd wrapped in os.File uses the runtime poller,
+	// so closing the file unblocks the pending Read
+	file := os.NewFile(uintptr(fd), "inotify")
+
+	go func() {
+		<-w.stop
+		file.Close()
+	}()
+
+	known := make(map[string]bool)
+	for name := range w.snapshot() {
+		known[name] = true
+	}
+	go w.readInotify(file, known)
+	return nil
+}
+
+// readInotify decodes inotify events and queues them. known holds the files
+// in the directory, so a file renamed over an existing one, as editors and
+// LazyL2M replace files, is reported as changed rather than added.
+func (w *AuthWatcher) readInotify(file *os.File, known map[string]bool) {
+	buf := make([]byte, 64*(syscall.SizeofInotifyEvent+syscall.NAME_MAX+1))
+
+	for {
+		n, err := file.Read(buf)
+		if err != nil {
+			return
+		}
+
+		offset := 0
+		for offset+syscall.SizeofInotifyEvent <= n {
+			raw := (*syscall.InotifyEvent)(unsafe.Pointer(&buf[offset]))
+			nameStart := offset + syscall.SizeofInotifyEvent
+			nameEnd := nameStart + int(raw.Len)
+			if nameEnd > n {
+				break
+			}
+			name := string(bytes.TrimRight(buf[nameStart:nameEnd], "\x00"))
+			offset = nameEnd
+
+			switch {
+			case raw.Mask&(syscall.IN_CREATE|syscall.IN_MOVED_TO) != 0:
+				if known[name] {
+					w.queue(name, AuthDirChanged)
+				} else {
+					w.queue(name, AuthDirAdded)
+				}
+				known[name] = true
+			case raw.Mask&(syscall.IN_DELETE|syscall.IN_MOVED_FROM) != 0:
+				delete(known, name)
+				w.queue(name, AuthDirRemoved)
+			case raw.Mask&(syscall.IN_MODIFY|syscall.IN_CLOSE_WRITE) != 0:
+				w.queue(name, AuthDirChanged)
+			}
+		}
+	}
+}
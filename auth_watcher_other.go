@@ -0,0 +1,10 @@
+//go:build !linux
+
+package main
+
+import "errors"
+
+// startNative is not available on this platform; the watcher polls instead
+func (w *AuthWatcher) startNative() error {
+	return errors.New("native file watching not supported")
+}
@@ -0,0 +1,86 @@
+package main
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// nextBatch waits for the next batch of watcher events
+func nextBatch(t *testing.T, batches <-chan []AuthDirEvent) []AuthDirEvent {
+	t.Helper()
+	select {
+	case events := <-batches:
+		return events
+	case <-time.After(2*authPollInterval + time.Second):
+		t.Fatal("no auth directory events")
+		return nil
+	}
+}
+
+func TestAuthWatcherReportsReplacedFileAsChanged(t *testing.T) {
+	dir := t.TempDir()
+	existing := filepath.Join(dir, "claude-user@example.com.json")
+	if err := os.WriteFile(existing, []byte(`{"type":"claude"}`), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	batches := make(chan []AuthDirEvent, 10)
+	watcher := NewAuthWatcher(dir, func(events []AuthDirEvent) { batches <- events })
+	watcher.Start()
+	defer watcher.Stop()
+
+	// Replaced by writing a temporary file and renaming it over the original
+	fields := map[string]json.RawMessage{"type": json.RawMessage(`"claude"`), "disabled": json.RawMessage("true")}
+	if err := writeAuthFields(existing, fields); err != nil {
+		t.Fatal(err)
+	}
+	events := nextBatch(t, batches)
+	if len(events) != 1 || events[0] != (AuthDirEvent{Name: filepath.Base(existing), Op: AuthDirChanged}) {
+		t.Fatalf("got %v, want one change of %s", events, filepath.Base(existing))
+	}
+
+	added := filepath.Join(dir, "codex-user@example.com.json")
+	if err := os.WriteFile(added, []byte(`{"type":"codex"}`), 0600); err != nil {
+		t.Fatal(err)
+	}
+	events = nextBatch(t, batches)
+	if len(events) != 1 || events[0] != (AuthDirEvent{Name: filepath.Base(added), Op: AuthDirAdded}) {
+		t.Fatalf("got %v, want %s added", events, filepath.Base(added))
+	}
+}
+
+func TestApplyAuthDirEventsIgnoresOwnWrites(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	pm := NewProxyManager(&Config{Port: 8317})
+	path := filepath.Join(pm.GetAuthDir(), "claude-user@example.com.json")
+	if err := os.WriteFile(path, []byte(`{"type":"claude"}`), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	fields, err := readAuthFields(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	fields[pausedUntilKey] = json.RawMessage(`""`)
+	updateDisabled(fields)
+	if err := pm.writeAuthFile(path, fields); err != nil {
+		t.Fatal(err)
+	}
+
+	own := []AuthDirEvent{{Name: filepath.Base(path), Op: AuthDirChanged}}
+	if kept := pm.dropOwnWrites(own); len(kept) != 0 {
+		t.Fatalf("own write reported as %v", kept)
+	}
+
+	// A later edit by someone else is reported again
+	if err := os.WriteFile(path, []byte(`{"type":"claude","email":"user@example.com"}`), 0600); err != nil {
+		t.Fatal(err)
+	}
+	edited := []AuthDirEvent{{Name: filepath.Base(path), Op: AuthDirChanged}}
+	if kept := pm.dropOwnWrites(edited); len(kept) != 1 {
+		t.Fatalf("edit after own write dropped: %v", kept)
+	}
+}
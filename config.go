@@ -2,54 +2,167 @@ package main
 
 import (
 	"encoding/json"
+	"fmt"
 	"os"
 	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
 )
 
 const (
-	configDir  = ".config/lazyl2m-tui"
-	configFile = "config.json"
+	configDir      = ".config/lazyl2m-tui"
+	configFile     = "config.json"
+	profilesDir    = "profiles"
+	defaultProfile = "default"
 )
 
+// profileNamePattern restricts profile names to safe file names
+var profileNamePattern = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9_-]*$`)
+
 // GetConfigPath returns the full path to the config file
 func GetConfigPath() (string, error) {
+	return GetProfileConfigPath(defaultProfile)
+}
+
+// GetProfileConfigPath returns the config file path for a profile.
+// The default profile keeps using the original config.json.
+func GetProfileConfigPath(profile string) (string, error) {
 	homeDir, err := os.UserHomeDir()
 	if err != nil {
 		return "", err
 	}
-	return filepath.Join(homeDir, configDir, configFile), nil
+	if profile == "" || profile == defaultProfile {
+		return filepath.Join(homeDir, configDir, configFile), nil
+	}
+	return filepath.Join(homeDir, configDir, profilesDir, profile+".json"), nil
+}
+
+// ValidateProfileName checks that a profile name is usable as a file name
+func ValidateProfileName(name string) error {
+	if !profileNamePattern.MatchString(name) {
+		return fmt.Errorf("invalid profile name %q: use letters, digits, '-' and '_'", name)
+	}
+	return nil
+}
+
+// ProfileExists reports whether a profile has been created
+func ProfileExists(profile string) bool {
+	if profile == defaultProfile {
+		return true
+	}
+	configPath, err := GetProfileConfigPath(profile)
+	if err != nil {
+		return false
+	}
+	_, err = os.Stat(configPath)
+	return err == nil
+}
+
+// ListProfiles returns the names of all profiles, default first
+func ListProfiles() []string {
+	profiles := []string{defaultProfile}
+
+	homeDir, err := os.UserHomeDir()
+	if err != nil {
+		return profiles
+	}
+
+	entries, err := os.ReadDir(filepath.Join(homeDir, configDir, profilesDir))
+	if err != nil {
+		return profiles
+	}
+
+	var names []string
+	for _, entry := range entries {
+		name := strings.TrimSuffix(entry.Name(), ".json")
+		if entry.IsDir() || name == entry.Name() || name == defaultProfile {
+			continue
+		}
+		if ValidateProfileName(name) == nil {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+
+	return append(profiles, names...)
 }
 
 // LoadConfig loads configuration from file or returns default
 func LoadConfig() (*Config, error) {
-	configPath, err := GetConfigPath()
+	return LoadProfileConfig(defaultProfile)
+}
+
+// LoadProfileConfig loads the configuration of a profile or returns default
+func LoadProfileConfig(profile string) (*Config, error) {
+	defaultConfig := NewDefaultConfig()
+	defaultConfig.Profile = profile
+
+	configPath, err := GetProfileConfigPath(profile)
 	if err != nil {
-		return NewDefaultConfig(), err
+		return defaultConfig, err
 	}
 
 	// If config file doesn't exist, return default config
 	if _, err := os.Stat(configPath); os.IsNotExist(err) {
-		return NewDefaultConfig(), nil
+		return defaultConfig, nil
 	}
 
 	// Read config file
 	data, err := os.ReadFile(configPath)
 	if err != nil {
-		return NewDefaultConfig(), err
+		return defaultConfig, err
 	}
 
 	// Parse JSON
 	var config Config
 	if err := json.Unmarshal(data, &config); err != nil {
-		return NewDefaultConfig(), err
+		return defaultConfig, err
 	}
+	config.Profile = profile
 
 	return &config, nil
 }
 
+// CreateProfile creates a new profile with its own auth directory and a port
+// that doesn't clash with existing profiles
+func CreateProfile(name string) (*Config, error) {
+	if err := ValidateProfileName(name); err != nil {
+		return nil, err
+	}
+	if ProfileExists(name) {
+		return nil, fmt.Errorf("profile %q already exists", name)
+	}
+
+	homeDir, err := os.UserHomeDir()
+	if err != nil {
+		return nil, err
+	}
+
+	config := NewDefaultConfig()
+	config.Profile = name
+	config.AuthDir = filepath.Join(homeDir, ".cli-proxy-api-"+name)
+
+	// Pick the next port after the highest one in use
+	for _, existing := range ListProfiles() {
+		other, err := LoadProfileConfig(existing)
+		if err != nil {
+			continue
+		}
+		if other.Port >= config.Port {
+			config.Port = other.Port + 1
+		}
+	}
+
+	if err := SaveConfig(config); err != nil {
+		return nil, err
+	}
+	return config, nil
+}
+
 // SaveConfig saves configuration to file
 func SaveConfig(config *Config) error {
-	configPath, err := GetConfigPath()
+	configPath, err := GetProfileConfigPath(config.Profile)
 	if err != nil {
 		return err
 	}
@@ -0,0 +1,222 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	downloadsDir        = "downloads"
+	maxDownloadAttempts = 5
+	downloadRetryDelay  = 2 * time.Second
+)
+
+// errDownloadCanceled is returned when the user cancels an install
+var errDownloadCanceled = errors.New("download canceled")
+
+// DownloadStats describes the progress of the running download
+type DownloadStats struct {
+	Downloaded int64
+	Total      int64   // -1 if the server didn't send a length
+	Speed      float64 // Bytes per second
+}
+
+// progressWriter counts bytes written to the download file
+type progressWriter struct {
+	pm *ProxyManager
+}
+
+func (w progressWriter) Write(p []byte) (int, error) {
+	w.pm.mutex.Lock()
+	defer w.pm.mutex.Unlock()
+
+	w.pm.downloadedBytes += int64(len(p))
+
+	// The download covers progress from 0.1 to 0.6
+	if w.pm.downloadTotal > 0 {
+		w.pm.downloadProgress = 0.1 + 0.5*float64(w.pm.downloadedBytes)/float64(w.pm.downloadTotal)
+	}
+
+	// Chunks arrive far more often than the screen needs redrawing
+	if time.Since(w.pm.lastProgress) >= downloadEventInterval {
+		w.pm.lastProgress = time.Now()
+		w.pm.events.Publish(EventDownloadProgress)
+	}
+	return len(p), nil
+}
+
+// GetDownloadStats returns bytes downloaded so far and the current speed
+func (pm *ProxyManager) GetDownloadStats() DownloadStats {
+	pm.mutex.RLock()
+	defer pm.mutex.RUnlock()
+
+	stats := DownloadStats{Downloaded: pm.downloadedBytes, Total: pm.downloadTotal}
+	if elapsed := time.Since(pm.downloadStarted).Seconds(); elapsed > 0 && !pm.downloadStarted.IsZero() {
+		stats.Speed = float64(pm.downloadedBytes-pm.downloadOffset) / elapsed
+	}
+	return stats
+}
+
+// CancelDownload cancels the running install; it returns false if there is none
+func (pm *ProxyManager) CancelDownload() bool {
+	pm.mutex.Lock()
+	cancel := pm.cancelDownload
+	pm.mutex.Unlock()
+
+	if cancel == nil {
+		return false
+	}
+	cancel()
+	return true
+}
+
+// partialDownloadPath returns where an asset is downloaded to. Interrupted
+// downloads stay there so a later install can resume them.
+func (pm *ProxyManager) partialDownloadPath(assetName string) string {
+	return filepath.Join(filepath.Dir(pm.managedBinaryPath), downloadsDir, filepath.Base(assetName)+".part")
+}
+
+// downloadToFile streams url into path, resuming with HTTP range requests
+// after a dropped connection. size is the expected size, 0 if unknown.
+func (pm *ProxyManager) downloadToFile(ctx context.Context, url, path string, size int64) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+
+	for attempt := 1; ; attempt++ {
+		err := pm.downloadRange(ctx, url, path, size)
+		if err == nil {
+			return nil
+		}
+		if ctx.Err() != nil {
+			return errDownloadCanceled
+		}
+		if attempt >= maxDownloadAttempts {
+			return err
+		}
+
+		pm.AddLogExternal(LogLevelWarn, fmt.Sprintf("Download interrupted (%v), resuming (attempt %d/%d)", err, attempt+1, maxDownloadAttempts))
+		select {
+		case <-ctx.Done():
+			return errDownloadCanceled
+		case <-time.After(downloadRetryDelay * time.Duration(attempt)):
+		}
+	}
+}
+
+// downloadRange downloads the part of url that is missing from path
+func (pm *ProxyManager) downloadRange(ctx context.Context, url, path string, size int64) error {
+	var offset int64
+	if info, err := os.Stat(path); err == nil {
+		offset = info.Size()
+	}
+
+	req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("User-Agent", "LazyL2M/1.0")
+	if offset > 0 {
+		req.Header.Set("Range", fmt.Sprintf("bytes=%d-", offset))
+	}
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	flags := os.O_CREATE | os.O_WRONLY
+	switch resp.StatusCode {
+	case http.StatusPartialContent:
+		flags |= os.O_APPEND
+		pm.AddLogExternal(LogLevelInfo, fmt.Sprintf("Resuming download at %s", formatBytes(offset)))
+	case http.StatusOK:
+		// The server ignored the range, so start over
+		flags |= os.O_TRUNC
+		offset = 0
+	case http.StatusRequestedRangeNotSatisfiable:
+		// Nothing is left to download when the partial file is complete;
+		// otherwise it doesn't belong to this asset
+		if size <= 0 {
+			size = rangeTotal(resp.Header.Get("Content-Range"))
+		}
+		if size > 0 && offset == size {
+			pm.mutex.Lock()
+			pm.downloadedBytes = offset
+			pm.downloadOffset = offset
+			pm.downloadTotal = size
+			pm.events.Publish(EventDownloadProgress)
+			pm.mutex.Unlock()
+			return nil
+		}
+		os.Remove(path)
+		return fmt.Errorf("partial download is invalid, restarting")
+	default:
+		return fmt.Errorf("download failed with status %d", resp.StatusCode)
+	}
+
+	file, err := os.OpenFile(path, flags, 0644)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	total := int64(-1)
+	if resp.ContentLength >= 0 {
+		total = offset + resp.ContentLength
+	}
+
+	pm.mutex.Lock()
+	pm.downloadedBytes = offset
+	pm.downloadOffset = offset
+	pm.downloadTotal = total
+	pm.downloadStarted = time.Now()
+	pm.events.Publish(EventDownloadProgress)
+	pm.mutex.Unlock()
+
+	written, err := io.Copy(io.MultiWriter(file, progressWriter{pm}), resp.Body)
+	if err != nil {
+		return err
+	}
+	if resp.ContentLength >= 0 && written < resp.ContentLength {
+		return io.ErrUnexpectedEOF
+	}
+	return nil
+}
+
+// rangeTotal returns the total size from a "bytes */<size>" Content-Range
+// header, or 0 if it is missing
+func rangeTotal(contentRange string) int64 {
+	_, total, ok := strings.Cut(contentRange, "/")
+	if !ok {
+		return 0
+	}
+	size, err := strconv.ParseInt(strings.TrimSpace(total), 10, 64)
+	if err != nil {
+		return 0
+	}
+	return size
+}
+
+// formatBytes formats a byte count with a binary unit
+func formatBytes(n int64) string {
+	const unit = 1024
+	if n < unit {
+		return fmt.Sprintf("%d B", n)
+	}
+	div, exp := int64(unit), 0
+	for m := n / unit; m >= unit; m /= unit {
+		div *= unit
+		exp++
+	}
+	return fmt.Sprintf("%.1f %ciB", float64(n)/float64(div), "KMGTPE"[exp])
+}
@@ -0,0 +1,57 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestDownloadRangeResumes(t *testing.T) {
+	content := []byte(strings.Repeat("CLIProxyAPI", 1000))
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.ServeContent(w, r, "asset.tar.gz", time.Time{}, bytes.NewReader(content))
+	}))
+	defer server.Close()
+
+	tests := []struct {
+		name    string
+		partial []byte
+		size    int64
+	}{
+		{name: "fresh download"},
+		{name: "partial file", partial: content[:4000]},
+		{name: "complete file with known size", partial: content, size: int64(len(content))},
+		{name: "complete file with size from Content-Range", partial: content},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv("HOME", t.TempDir())
+			pm := NewProxyManager(&Config{Port: 8317})
+			path := filepath.Join(t.TempDir(), "asset.tar.gz.part")
+			if tt.partial != nil {
+				if err := os.WriteFile(path, tt.partial, 0644); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			// A single attempt, so a restarted download doesn't pass as a resumed one
+			if err := pm.downloadRange(context.Background(), server.URL, path, tt.size); err != nil {
+				t.Fatalf("download failed: %v", err)
+			}
+			data, err := os.ReadFile(path)
+			if err != nil || !bytes.Equal(data, content) {
+				t.Fatalf("downloaded %d bytes, want %d (%v)", len(data), len(content), err)
+			}
+			if stats := pm.GetDownloadStats(); stats.Downloaded != int64(len(content)) {
+				t.Fatalf("progress reports %d bytes, want %d", stats.Downloaded, len(content))
+			}
+		})
+	}
+}
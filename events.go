@@ -0,0 +1,104 @@
+package main
+
+import (
+	"sync"
+	"time"
+)
+
+const (
+	eventBufferSize       = 64
+	downloadEventInterval = 100 * time.Millisecond
+)
+
+// Event is a kind of change in a ProxyManager. Subscribers read the new
+// state with the ProxyManager's getters.
+type Event int
+
+const (
+	EventStatusChanged    Event = iota // The proxy started, stopped, or an update was found
+	EventLogAppended                   // Log entries were added or cleared
+	EventAccountsChanged               // Accounts or their quotas changed
+	EventStatsUpdated                  // Usage statistics were fetched
+	EventDownloadProgress              // An install advanced, finished or failed
+)
+
+// EventBus delivers events to subscribers. Publishing never blocks: a
+// subscriber that falls too far behind misses events until it catches up.
+type EventBus struct {
+	mutex       sync.Mutex
+	subscribers map[int]chan Event
+	nextID      int
+}
+
+// NewEventBus creates an event bus without subscribers
+func NewEventBus() *EventBus {
+	return &EventBus{subscribers: make(map[int]chan Event)}
+}
+
+// Subscribe returns a channel receiving every event published from now on,
+// and a function that unsubscribes and closes the channel
+func (b *EventBus) Subscribe() (<-chan Event, func()) {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	id := b.nextID
+	b.nextID++
+	events := make(chan Event, eventBufferSize)
+	b.subscribers[id] = events
+
+	var once sync.Once
+	return events, func() {
+		once.Do(func() {
+			b.mutex.Lock()
+			defer b.mutex.Unlock()
+			delete(b.subscribers, id)
+			close(events)
+		})
+	}
+}
+
+// Publish sends an event to all subscribers. It may be called with the
+// ProxyManager's lock held.
+func (b *EventBus) Publish(event Event) {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	for _, events := range b.subscribers {
+		select {
+		case events <- event:
+		default:
+		}
+	}
+}
+
+// nextEvents waits for an event and returns it together with the events that
+// queued up meanwhile, each kind once. It returns nil once events is closed.
+func nextEvents(events <-chan Event) []Event {
+	event, ok := <-events
+	if !ok {
+		return nil
+	}
+
+	batch := []Event{event}
+	seen := map[Event]bool{event: true}
+	for {
+		select {
+		case event, ok := <-events:
+			if !ok {
+				return batch
+			}
+			if !seen[event] {
+				seen[event] = true
+				batch = append(batch, event)
+			}
+		default:
+			return batch
+		}
+	}
+}
+
+// Subscribe returns a channel receiving the manager's events, and a function
+// that unsubscribes
+func (pm *ProxyManager) Subscribe() (<-chan Event, func()) {
+	return pm.events.Subscribe()
+}
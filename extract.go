@@ -0,0 +1,318 @@
+package main
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"compress/gzip"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+const binaryPrefixSearch = "cliproxyapi"
+
+// Extraction limits, variables so tests can use small ones
+var (
+	maxExtractedSize  int64 = 512 << 20 // Total bytes an archive may expand to
+	maxArchiveEntries       = 10000
+)
+
+// binaryNames are the names the CLIProxyAPI binary is published under, in
+// order of preference
+var binaryNames = []string{"CLIProxyAPI", "CLIProxyAPIPlus", "cli-proxy-api", "cli-proxy-api-plus", "proxy"}
+
+// extractedFile is a regular file written by an extractor
+type extractedFile struct {
+	Name string // Path inside the archive
+	Path string // Path on disk
+	Mode os.FileMode
+}
+
+// extractor writes archive entries below a directory, refusing entries that
+// would escape it and enforcing the size and entry limits
+type extractor struct {
+	destDir   string
+	remaining int64
+	entries   int
+	files     []extractedFile
+}
+
+func newExtractor(destDir string) *extractor {
+	return &extractor{destDir: destDir, remaining: maxExtractedSize}
+}
+
+// target returns the path an archive entry extracts to. Absolute paths,
+// drive letters and ".." components are rejected.
+func (e *extractor) target(name string) (string, error) {
+	e.entries++
+	if e.entries > maxArchiveEntries {
+		return "", fmt.Errorf("archive has more than %d entries", maxArchiveEntries)
+	}
+
+	clean := filepath.Clean(filepath.FromSlash(strings.ReplaceAll(name, `\`, "/")))
+	if filepath.IsAbs(clean) || filepath.VolumeName(clean) != "" || strings.HasPrefix(clean, string(filepath.Separator)) {
+		return "", fmt.Errorf("archive entry %q has an absolute path", name)
+	}
+	if clean == ".." || strings.HasPrefix(clean, ".."+string(filepath.Separator)) {
+		return "", fmt.Errorf("archive entry %q escapes the extraction directory", name)
+	}
+	return filepath.Join(e.destDir, clean), nil
+}
+
+// checkParents makes sure no directory between destDir and target is a
+// symlink, so files can't be written through a link
+func (e *extractor) checkParents(target string) error {
+	rel, err := filepath.Rel(e.destDir, filepath.Dir(target))
+	if err != nil || rel == "." {
+		return err
+	}
+
+	path := e.destDir
+	for _, part := range strings.Split(rel, string(filepath.Separator)) {
+		path = filepath.Join(path, part)
+		info, err := os.Lstat(path)
+		if os.IsNotExist(err) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		if info.Mode()&os.ModeSymlink != 0 {
+			return fmt.Errorf("archive entry %q is inside a symlinked directory", target)
+		}
+	}
+	return nil
+}
+
+// mkdir creates a directory entry
+func (e *extractor) mkdir(name string) error {
+	target, err := e.target(name)
+	if err != nil {
+		return err
+	}
+	if err := e.checkParents(target); err != nil {
+		return err
+	}
+	return os.MkdirAll(target, 0755)
+}
+
+// writeFile creates a regular file entry from r, counting it against the size cap
+func (e *extractor) writeFile(name string, mode os.FileMode, r io.Reader) error {
+	target, err := e.target(name)
+	if err != nil {
+		return err
+	}
+	if err := e.checkParents(target); err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(target), 0755); err != nil {
+		return err
+	}
+
+	// Never follow a link an earlier entry left at this path
+	if info, err := os.Lstat(target); err == nil {
+		if info.Mode()&os.ModeSymlink != 0 || info.IsDir() {
+			return fmt.Errorf("archive entry %q replaces a link or directory", name)
+		}
+		os.Remove(target)
+	}
+
+	out, err := os.OpenFile(target, os.O_CREATE|os.O_EXCL|os.O_WRONLY, mode.Perm()|0600)
+	if err != nil {
+		return err
+	}
+
+	written, err := io.Copy(out, io.LimitReader(r, e.remaining+1))
+	if closeErr := out.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return fmt.Errorf("failed to extract %s: %w", name, err)
+	}
+	if written > e.remaining {
+		return fmt.Errorf("archive expands to more than %s", formatBytes(maxExtractedSize))
+	}
+	e.remaining -= written
+
+	e.files = append(e.files, extractedFile{Name: name, Path: target, Mode: mode})
+	return nil
+}
+
+// symlink creates a symlink entry whose target must stay inside destDir
+func (e *extractor) symlink(name, linkTarget string) error {
+	target, err := e.target(name)
+	if err != nil {
+		return err
+	}
+	if err := e.checkParents(target); err != nil {
+		return err
+	}
+
+	if filepath.IsAbs(linkTarget) || filepath.VolumeName(linkTarget) != "" {
+		return fmt.Errorf("symlink %q points to absolute path %q", name, linkTarget)
+	}
+	resolved := filepath.Join(filepath.Dir(target), filepath.FromSlash(linkTarget))
+	if rel, err := filepath.Rel(e.destDir, resolved); err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return fmt.Errorf("symlink %q points outside the archive (%q)", name, linkTarget)
+	}
+
+	if err := os.MkdirAll(filepath.Dir(target), 0755); err != nil {
+		return err
+	}
+	return os.Symlink(linkTarget, target)
+}
+
+// extractTarGz extracts a tar.gz archive and returns the regular files in it
+func extractTarGz(archivePath, destDir string) ([]extractedFile, error) {
+	file, err := os.Open(archivePath)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	gzr, err := gzip.NewReader(file)
+	if err != nil {
+		return nil, err
+	}
+	defer gzr.Close()
+
+	tr := tar.NewReader(gzr)
+	e := newExtractor(destDir)
+
+	for {
+		header, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		switch header.Typeflag {
+		case tar.TypeDir:
+			err = e.mkdir(header.Name)
+		case tar.TypeReg:
+			err = e.writeFile(header.Name, header.FileInfo().Mode(), tr)
+		case tar.TypeSymlink:
+			err = e.symlink(header.Name, header.Linkname)
+		case tar.TypeLink:
+			err = fmt.Errorf("archive entry %q is a hard link, which is not supported", header.Name)
+		default:
+			// Devices, FIFOs and metadata entries are skipped
+			_, err = e.target(header.Name)
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return e.files, nil
+}
+
+// extractZip extracts a zip archive and returns the regular files in it
+func extractZip(archivePath, destDir string) ([]extractedFile, error) {
+	r, err := zip.OpenReader(archivePath)
+	if err != nil {
+		return nil, err
+	}
+	defer r.Close()
+
+	e := newExtractor(destDir)
+
+	for _, f := range r.File {
+		mode := f.Mode()
+
+		switch {
+		case mode.IsDir():
+			err = e.mkdir(f.Name)
+		case mode&os.ModeSymlink != 0:
+			err = extractZipSymlink(e, f)
+		case mode.IsRegular():
+			err = extractZipFile(e, f)
+		default:
+			_, err = e.target(f.Name)
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return e.files, nil
+}
+
+// extractZipFile extracts a regular zip entry
+func extractZipFile(e *extractor, f *zip.File) error {
+	rc, err := f.Open()
+	if err != nil {
+		return err
+	}
+	defer rc.Close()
+	return e.writeFile(f.Name, f.Mode(), rc)
+}
+
+// extractZipSymlink extracts a zip symlink, whose content is its target
+func extractZipSymlink(e *extractor, f *zip.File) error {
+	rc, err := f.Open()
+	if err != nil {
+		return err
+	}
+	defer rc.Close()
+
+	linkTarget, err := io.ReadAll(io.LimitReader(rc, 4096))
+	if err != nil {
+		return err
+	}
+	return e.symlink(f.Name, string(linkTarget))
+}
+
+// selectBinary picks the CLIProxyAPI binary among extracted files: an exact
+// known name first, then a name starting with "cliproxyapi", then the only
+// executable file. It returns why the file was chosen.
+func selectBinary(files []extractedFile) (extractedFile, string, error) {
+	baseName := func(f extractedFile) string {
+		return strings.TrimSuffix(filepath.Base(f.Path), ".exe")
+	}
+
+	for _, name := range binaryNames {
+		for _, f := range files {
+			if strings.EqualFold(baseName(f), name) {
+				return f, fmt.Sprintf("named %s", name), nil
+			}
+		}
+	}
+
+	for _, f := range files {
+		if strings.HasPrefix(strings.ToLower(baseName(f)), binaryPrefixSearch) && !isArchiveSidecar(f.Path) {
+			return f, fmt.Sprintf("name starts with %s", binaryPrefixSearch), nil
+		}
+	}
+
+	var executables []extractedFile
+	for _, f := range files {
+		if f.Mode&0111 != 0 && !isArchiveSidecar(f.Path) {
+			executables = append(executables, f)
+		}
+	}
+	if len(executables) == 1 {
+		return executables[0], "only executable in archive", nil
+	}
+
+	names := make([]string, 0, len(files))
+	for _, f := range files {
+		names = append(names, f.Name)
+	}
+	return extractedFile{}, "", fmt.Errorf("could not find binary in archive (expected one of %s; archive contains %s)",
+		strings.Join(binaryNames, ", "), strings.Join(names, ", "))
+}
+
+// isArchiveSidecar reports whether a file is documentation or a script
+// rather than a binary
+func isArchiveSidecar(path string) bool {
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".sh", ".md", ".txt", ".yaml", ".yml", ".json", ".example", ".bat", ".ps1":
+		return true
+	}
+	return false
+}
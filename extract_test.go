@@ -0,0 +1,472 @@
+package main
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bytes"
+	"compress/gzip"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// tarEntry is a file of a test archive
+type tarEntry struct {
+	header tar.Header
+	body   string
+}
+
+// buildTarGz builds a .tar.gz archive from entries
+func buildTarGz(t *testing.T, entries []tarEntry) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	gz := gzip.NewWriter(&buf)
+	tw := tar.NewWriter(gz)
+	for _, e := range entries {
+		header := e.header
+		if header.Typeflag == 0 {
+			header.Typeflag = tar.TypeReg
+		}
+		if header.Mode == 0 {
+			header.Mode = 0755
+		}
+		if header.Typeflag == tar.TypeReg {
+			header.Size = int64(len(e.body))
+		}
+		if err := tw.WriteHeader(&header); err != nil {
+			t.Fatal(err)
+		}
+		if header.Typeflag == tar.TypeReg {
+			if _, err := tw.Write([]byte(e.body)); err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+	if err := tw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if err := gz.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+// zipEntry is a file of a test zip archive
+type zipEntry struct {
+	name string
+	mode os.FileMode
+	body string
+}
+
+// buildZip builds a zip archive from entries; symlinks carry their target
+// as content
+func buildZip(t *testing.T, entries []zipEntry) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+	for _, e := range entries {
+		header := &zip.FileHeader{Name: e.name, Method: zip.Deflate}
+		mode := e.mode
+		if mode == 0 {
+			mode = 0755
+		}
+		header.SetMode(mode)
+		w, err := zw.CreateHeader(header)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := w.Write([]byte(e.body)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := zw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+// extractionRoot returns a directory holding the archive and the extraction
+// target, and a sentinel file outside the target that must stay untouched
+func extractionRoot(t *testing.T, archiveName string, archive []byte) (root, archivePath, dest string) {
+	t.Helper()
+	root = t.TempDir()
+	archivePath = filepath.Join(root, archiveName)
+	dest = filepath.Join(root, "dest")
+	if err := os.WriteFile(archivePath, archive, 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(root, "sentinel"), []byte("original"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Mkdir(dest, 0755); err != nil {
+		t.Fatal(err)
+	}
+	return root, archivePath, dest
+}
+
+// assertNothingOutside fails if anything besides the archive, the sentinel
+// and the target was created in root, or the sentinel was changed
+func assertNothingOutside(t *testing.T, root, archivePath, dest string) {
+	t.Helper()
+	if data, err := os.ReadFile(filepath.Join(root, "sentinel")); err != nil || string(data) != "original" {
+		t.Errorf("file outside the target was changed: %q %v", data, err)
+	}
+	filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		switch path {
+		case root, archivePath, filepath.Join(root, "sentinel"):
+			return nil
+		case dest:
+			return filepath.SkipDir
+		}
+		t.Errorf("extraction wrote %s outside the target", path)
+		return nil
+	})
+}
+
+func TestExtractTarGzRejectsMaliciousArchives(t *testing.T) {
+	absolute := filepath.Join(t.TempDir(), "absolute")
+
+	tests := []struct {
+		name    string
+		entries []tarEntry
+		wantErr string
+	}{
+		{
+			name:    "parent directory entry",
+			entries: []tarEntry{{header: tar.Header{Name: "../sentinel"}, body: "evil"}},
+			wantErr: "escapes the extraction directory",
+		},
+		{
+			name:    "nested parent directory entry",
+			entries: []tarEntry{{header: tar.Header{Name: "bin/../../sentinel"}, body: "evil"}},
+			wantErr: "escapes the extraction directory",
+		},
+		{
+			name:    "backslash parent directory entry",
+			entries: []tarEntry{{header: tar.Header{Name: `..\sentinel`}, body: "evil"}},
+			wantErr: "escapes the extraction directory",
+		},
+		{
+			name:    "absolute path",
+			entries: []tarEntry{{header: tar.Header{Name: absolute}, body: "evil"}},
+			wantErr: "absolute path",
+		},
+		{
+			name:    "symlink escaping the target",
+			entries: []tarEntry{{header: tar.Header{Name: "link", Typeflag: tar.TypeSymlink, Linkname: "../sentinel"}}},
+			wantErr: "points outside the archive",
+		},
+		{
+			name:    "nested symlink escaping the target",
+			entries: []tarEntry{{header: tar.Header{Name: "bin/link", Typeflag: tar.TypeSymlink, Linkname: "../../"}}},
+			wantErr: "points outside the archive",
+		},
+		{
+			name:    "absolute symlink",
+			entries: []tarEntry{{header: tar.Header{Name: "link", Typeflag: tar.TypeSymlink, Linkname: absolute}}},
+			wantErr: "points to absolute path",
+		},
+		{
+			name: "write through a symlinked parent",
+			entries: []tarEntry{
+				{header: tar.Header{Name: "sub", Typeflag: tar.TypeDir}},
+				{header: tar.Header{Name: "dir", Typeflag: tar.TypeSymlink, Linkname: "sub"}},
+				{header: tar.Header{Name: "dir/cli-proxy-api"}, body: "evil"},
+			},
+			wantErr: "inside a symlinked directory",
+		},
+		{
+			name: "file replacing a symlink",
+			entries: []tarEntry{
+				{header: tar.Header{Name: "README.md", Mode: 0644}, body: "readme"},
+				{header: tar.Header{Name: "cli-proxy-api", Typeflag: tar.TypeSymlink, Linkname: "README.md"}},
+				{header: tar.Header{Name: "cli-proxy-api"}, body: "evil"},
+			},
+			wantErr: "replaces a link or directory",
+		},
+		{
+			name: "hard link",
+			entries: []tarEntry{
+				{header: tar.Header{Name: "cli-proxy-api", Typeflag: tar.TypeLink, Linkname: "../sentinel"}},
+			},
+			wantErr: "hard link",
+		},
+		{
+			name: "hard link inside the archive",
+			entries: []tarEntry{
+				{header: tar.Header{Name: "README.md", Mode: 0644}, body: "readme"},
+				{header: tar.Header{Name: "cli-proxy-api", Typeflag: tar.TypeLink, Linkname: "README.md"}},
+			},
+			wantErr: "hard link",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			root, archivePath, dest := extractionRoot(t, "cli.tar.gz", buildTarGz(t, tt.entries))
+			_, err := extractTarGz(archivePath, dest)
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Fatalf("got error %v, want %q", err, tt.wantErr)
+			}
+			assertNothingOutside(t, root, archivePath, dest)
+			if _, err := os.Lstat(absolute); err == nil {
+				t.Fatal("absolute path entry was written")
+			}
+		})
+	}
+}
+
+func TestExtractZipRejectsMaliciousArchives(t *testing.T) {
+	absolute := filepath.Join(t.TempDir(), "absolute")
+
+	tests := []struct {
+		name    string
+		entries []zipEntry
+		wantErr string
+	}{
+		{
+			name:    "parent directory entry",
+			entries: []zipEntry{{name: "../sentinel", body: "evil"}},
+			wantErr: "escapes the extraction directory",
+		},
+		{
+			name:    "backslash parent directory entry",
+			entries: []zipEntry{{name: `bin\..\..\sentinel`, body: "evil"}},
+			wantErr: "escapes the extraction directory",
+		},
+		{
+			name:    "absolute path",
+			entries: []zipEntry{{name: absolute, body: "evil"}},
+			wantErr: "absolute path",
+		},
+		{
+			name:    "symlink escaping the target",
+			entries: []zipEntry{{name: "link", mode: os.ModeSymlink | 0777, body: "../sentinel"}},
+			wantErr: "points outside the archive",
+		},
+		{
+			name: "write through a symlinked parent",
+			entries: []zipEntry{
+				{name: "sub/", mode: os.ModeDir | 0755},
+				{name: "dir", mode: os.ModeSymlink | 0777, body: "sub"},
+				{name: "dir/cli-proxy-api", body: "evil"},
+			},
+			wantErr: "inside a symlinked directory",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			root, archivePath, dest := extractionRoot(t, "cli.zip", buildZip(t, tt.entries))
+			_, err := extractZip(archivePath, dest)
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Fatalf("got error %v, want %q", err, tt.wantErr)
+			}
+			assertNothingOutside(t, root, archivePath, dest)
+			if _, err := os.Lstat(absolute); err == nil {
+				t.Fatal("absolute path entry was written")
+			}
+		})
+	}
+}
+
+func TestExtractLimits(t *testing.T) {
+	defer func(size int64, entries int) {
+		maxExtractedSize, maxArchiveEntries = size, entries
+	}(maxExtractedSize, maxArchiveEntries)
+	maxExtractedSize = 1 << 20
+	maxArchiveEntries = 100
+
+	// Zeros compress about a thousandfold
+	bomb := strings.Repeat("\x00", 2<<20)
+	many := make([]tarEntry, maxArchiveEntries+1)
+	manyZip := make([]zipEntry, maxArchiveEntries+1)
+	for i := range many {
+		many[i] = tarEntry{header: tar.Header{Name: fmt.Sprintf("file%d.txt", i), Mode: 0644}}
+		manyZip[i] = zipEntry{name: fmt.Sprintf("file%d.txt", i), mode: 0644}
+	}
+
+	tests := []struct {
+		name    string
+		archive func(t *testing.T) (string, []byte)
+		wantErr string
+	}{
+		{
+			name: "tar.gz decompression bomb",
+			archive: func(t *testing.T) (string, []byte) {
+				return "cli.tar.gz", buildTarGz(t, []tarEntry{{header: tar.Header{Name: "cli-proxy-api"}, body: bomb}})
+			},
+			wantErr: "archive expands to more than 1.0 MiB",
+		},
+		{
+			name: "tar.gz bomb split over files",
+			archive: func(t *testing.T) (string, []byte) {
+				half := bomb[:len(bomb)/2]
+				return "cli.tar.gz", buildTarGz(t, []tarEntry{
+					{header: tar.Header{Name: "a"}, body: half},
+					{header: tar.Header{Name: "b"}, body: half},
+				})
+			},
+			wantErr: "archive expands to more than",
+		},
+		{
+			name: "zip decompression bomb",
+			archive: func(t *testing.T) (string, []byte) {
+				return "cli.zip", buildZip(t, []zipEntry{{name: "cli-proxy-api", body: bomb}})
+			},
+			wantErr: "archive expands to more than",
+		},
+		{
+			name: "tar.gz entry count",
+			archive: func(t *testing.T) (string, []byte) {
+				return "cli.tar.gz", buildTarGz(t, many)
+			},
+			wantErr: "more than 100 entries",
+		},
+		{
+			name: "zip entry count",
+			archive: func(t *testing.T) (string, []byte) {
+				return "cli.zip", buildZip(t, manyZip)
+			},
+			wantErr: "more than 100 entries",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			name, data := tt.archive(t)
+			if len(data) >= int(maxExtractedSize) {
+				t.Fatalf("archive is %d bytes, not a bomb", len(data))
+			}
+			root, archivePath, dest := extractionRoot(t, name, data)
+
+			var err error
+			if strings.HasSuffix(name, ".zip") {
+				_, err = extractZip(archivePath, dest)
+			} else {
+				_, err = extractTarGz(archivePath, dest)
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Fatalf("got error %v, want %q", err, tt.wantErr)
+			}
+			assertNothingOutside(t, root, archivePath, dest)
+		})
+	}
+}
+
+func TestExtractReleaseArchive(t *testing.T) {
+	entries := []tarEntry{
+		{header: tar.Header{Name: "CLIProxyAPI_6.1.0_linux_amd64", Typeflag: tar.TypeDir}},
+		{header: tar.Header{Name: "CLIProxyAPI_6.1.0_linux_amd64/LICENSE", Mode: 0644}, body: "MIT"},
+		{header: tar.Header{Name: "CLIProxyAPI_6.1.0_linux_amd64/README.md", Mode: 0644}, body: "readme"},
+		{header: tar.Header{Name: "CLIProxyAPI_6.1.0_linux_amd64/config.example.yaml", Mode: 0644}, body: "port: 8317"},
+		{header: tar.Header{Name: "CLIProxyAPI_6.1.0_linux_amd64/cli-proxy-api"}, body: "binary"},
+		{header: tar.Header{Name: "CLIProxyAPI_6.1.0_linux_amd64/current", Typeflag: tar.TypeSymlink, Linkname: "cli-proxy-api"}},
+	}
+	root, archivePath, dest := extractionRoot(t, "cli.tar.gz", buildTarGz(t, entries))
+
+	files, err := extractTarGz(archivePath, dest)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assertNothingOutside(t, root, archivePath, dest)
+	if len(files) != 4 {
+		t.Fatalf("extracted %d regular files, want 4", len(files))
+	}
+
+	binary, reason, err := selectBinary(files)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if binary.Name != "CLIProxyAPI_6.1.0_linux_amd64/cli-proxy-api" || reason != "named cli-proxy-api" {
+		t.Fatalf("selected %s (%s)", binary.Name, reason)
+	}
+	if data, err := os.ReadFile(binary.Path); err != nil || string(data) != "binary" {
+		t.Fatalf("binary content %q %v", data, err)
+	}
+}
+
+func TestSelectBinary(t *testing.T) {
+	file := func(name string, mode os.FileMode) extractedFile {
+		return extractedFile{Name: name, Path: filepath.Join("/tmp/x", name), Mode: mode}
+	}
+
+	tests := []struct {
+		name       string
+		files      []extractedFile
+		want       string
+		wantReason string
+		wantErr    string
+	}{
+		{
+			name:       "preferred name wins over later known names",
+			files:      []extractedFile{file("cli-proxy-api", 0755), file("CLIProxyAPI", 0755)},
+			want:       "CLIProxyAPI",
+			wantReason: "named CLIProxyAPI",
+		},
+		{
+			name:       "known name ignores case and .exe",
+			files:      []extractedFile{file("README.md", 0644), file("cliproxyapi.exe", 0755)},
+			want:       "cliproxyapi.exe",
+			wantReason: "named CLIProxyAPI",
+		},
+		{
+			name:       "plus build",
+			files:      []extractedFile{file("LICENSE", 0644), file("cli-proxy-api-plus", 0755)},
+			want:       "cli-proxy-api-plus",
+			wantReason: "named cli-proxy-api-plus",
+		},
+		{
+			name:       "name prefix",
+			files:      []extractedFile{file("CLIProxyAPI-linux-amd64", 0644)},
+			want:       "CLIProxyAPI-linux-amd64",
+			wantReason: "name starts with cliproxyapi",
+		},
+		{
+			name:       "prefix skips documentation",
+			files:      []extractedFile{file("cliproxyapi-notes.md", 0644), file("cliproxyapi-server", 0644)},
+			want:       "cliproxyapi-server",
+			wantReason: "name starts with cliproxyapi",
+		},
+		{
+			name:       "only executable",
+			files:      []extractedFile{file("LICENSE", 0644), file("install.sh", 0755), file("server", 0755)},
+			want:       "server",
+			wantReason: "only executable in archive",
+		},
+		{
+			name:    "several executables",
+			files:   []extractedFile{file("server", 0755), file("helper", 0755)},
+			wantErr: "archive contains server, helper",
+		},
+		{
+			name:    "no executable",
+			files:   []extractedFile{file("LICENSE", 0644), file("README.md", 0644)},
+			wantErr: "could not find binary in archive",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			binary, reason, err := selectBinary(tt.files)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("got error %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if binary.Name != tt.want || reason != tt.wantReason {
+				t.Fatalf("selected %s (%s), want %s (%s)", binary.Name, reason, tt.want, tt.wantReason)
+			}
+		})
+	}
+}
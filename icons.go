@@ -0,0 +1,115 @@
+package main
+
+import (
+	"os"
+	"runtime"
+	"strings"
+
+	"github.com/rivo/uniseg"
+)
+
+// IconMode selects how icons are drawn
+type IconMode string
+
+const (
+	IconModeAuto  IconMode = "auto"  // ASCII on terminals that can't show emoji
+	IconModeEmoji IconMode = "emoji" // Emoji icons
+	IconModeASCII IconMode = "ascii" // ASCII and short text tags instead of emoji
+)
+
+// IconModes returns the icon modes in display order
+func IconModes() []IconMode {
+	return []IconMode{IconModeAuto, IconModeEmoji, IconModeASCII}
+}
+
+// asciiIcons is set when icons are drawn in ASCII
+var asciiIcons bool
+
+// SetIconMode selects the icon mode; auto picks one for the terminal. It
+// returns the mode in effect.
+func SetIconMode(mode IconMode) IconMode {
+	if mode != IconModeEmoji && mode != IconModeASCII {
+		mode = detectIconMode()
+	}
+	asciiIcons = mode == IconModeASCII
+	return mode
+}
+
+// detectIconMode falls back to ASCII on the Linux console, serial and dumb
+// terminals, the legacy Windows console and locales other than UTF-8
+func detectIconMode() IconMode {
+	term := os.Getenv("TERM")
+	if term == "linux" || term == "dumb" || strings.HasPrefix(term, "vt") {
+		return IconModeASCII
+	}
+	if runtime.GOOS == "windows" && os.Getenv("WT_SESSION") == "" {
+		return IconModeASCII
+	}
+
+	// An unset locale is taken as UTF-8, like tcell does
+	for _, name := range []string{"LC_ALL", "LC_CTYPE", "LANG"} {
+		locale := os.Getenv(name)
+		if locale == "" {
+			continue
+		}
+		locale = strings.ToLower(locale)
+		if !strings.Contains(locale, "utf-8") && !strings.Contains(locale, "utf8") {
+			return IconModeASCII
+		}
+		break
+	}
+	return IconModeEmoji
+}
+
+// icon returns emoji, or ascii in ASCII mode
+func icon(emoji, ascii string) string {
+	if asciiIcons {
+		return ascii
+	}
+	return emoji
+}
+
+// iconText puts an icon in front of text. Icons are padded to the same width
+// so labels line up; in ASCII mode an empty ascii leaves the text alone.
+func iconText(emoji, ascii, text string) string {
+	if asciiIcons {
+		if ascii == "" {
+			return text
+		}
+		return padRight(ascii, 3) + " " + text
+	}
+	return padRight(emoji, 2) + " " + text
+}
+
+// padRight pads s with spaces to width terminal cells
+func padRight(s string, width int) string {
+	if w := uniseg.StringWidth(s); w < width {
+		return s + strings.Repeat(" ", width-w)
+	}
+	return s
+}
+
+// fitWidth shortens s to width terminal cells, ending it with "…", and pads
+// it when it is narrower
+func fitWidth(s string, width int) string {
+	if uniseg.StringWidth(s) <= width {
+		return padRight(s, width)
+	}
+
+	var b strings.Builder
+	used := 0
+	state := -1
+	rest := s
+	for rest != "" {
+		var cluster string
+		var boundaries int
+		cluster, rest, boundaries, state = uniseg.StepString(rest, state)
+		w := boundaries >> uniseg.ShiftWidth
+		if used+w > width-1 {
+			break
+		}
+		b.WriteString(cluster)
+		used += w
+	}
+	return padRight(b.String()+"…", width)
+}
@@ -0,0 +1,181 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/gdamore/tcell/v2"
+	"github.com/rivo/tview"
+)
+
+// KeymapPreset is the name of a built-in set of key bindings
+type KeymapPreset string
+
+const (
+	KeymapPresetDefault KeymapPreset = "default"
+	KeymapPresetVim     KeymapPreset = "vim"
+)
+
+// KeymapConfig customizes the keys that run actions. Contexts maps "global"
+// or a screen name to action names and their keys, e.g.
+// {"dashboard": {"toggle-server": ["Space"]}}. An empty key list unbinds an
+// action.
+type KeymapConfig struct {
+	Preset   KeymapPreset                   `json:"preset,omitempty"`
+	Contexts map[string]map[string][]string `json:"contexts,omitempty"`
+}
+
+// keymapPreset holds the bindings a preset changes and keys it translates
+// into other keys, such as j into Down
+type keymapPreset struct {
+	bindings map[string][]string // Action ID to keys
+	aliases  map[string]string
+}
+
+// keymapPresets are the built-in presets; the default one keeps the keys
+// actions are registered with
+var keymapPresets = map[KeymapPreset]keymapPreset{
+	KeymapPresetDefault: {},
+	KeymapPresetVim: {
+		bindings: map[string][]string{
+			"global.goto-dashboard": {"1"},
+			"global.goto-quota":     {"2"},
+			"global.goto-usage":     {"3"},
+			"global.goto-providers": {"4"},
+			"global.goto-agents":    {"5"},
+			"global.goto-apikeys":   {"6"},
+			"global.goto-logs":      {"7"},
+			"global.goto-settings":  {"8"},
+			"global.prev-screen":    {"h", "["},
+			"global.next-screen":    {"l", "]"},
+			"global.palette":        {":", "/", "Ctrl-P"},
+			"global.quit":           {"x", "Q"},
+			"apikeys.generate":      {"n", "N"},
+		},
+		aliases: map[string]string{
+			"j": "Down",
+			"k": "Up",
+			"g": "Home",
+			"G": "End",
+		},
+	},
+}
+
+// KeymapPresets returns the names of the built-in presets in display order
+func KeymapPresets() []KeymapPreset {
+	return []KeymapPreset{KeymapPresetDefault, KeymapPresetVim}
+}
+
+// keyNames maps lowercase key names to their canonical form
+var keyNames = func() map[string]string {
+	names := map[string]string{"space": "Space"}
+	for _, name := range tcell.KeyNames {
+		names[strings.ToLower(name)] = name
+	}
+	return names
+}()
+
+// keysByName maps canonical key names back to tcell keys
+var keysByName = func() map[string]tcell.Key {
+	keys := make(map[string]tcell.Key)
+	for key, name := range tcell.KeyNames {
+		keys[name] = key
+	}
+	return keys
+}()
+
+// normalizeKeyName turns a configured key such as "ctrl+p" into the form
+// eventKeyName produces ("Ctrl-P")
+func normalizeKeyName(name string) (string, error) {
+	if utf8.RuneCountInString(name) == 1 {
+		if name == " " {
+			return "Space", nil
+		}
+		return name, nil
+	}
+
+	if rest, ok := strings.CutPrefix(strings.ToLower(name), "alt"); ok && len(rest) > 1 && (rest[0] == '-' || rest[0] == '+') {
+		if utf8.RuneCountInString(name[4:]) == 1 {
+			return "Alt-" + name[4:], nil
+		}
+	}
+	if canonical, ok := keyNames[strings.ToLower(strings.ReplaceAll(name, "+", "-"))]; ok {
+		return canonical, nil
+	}
+	return "", fmt.Errorf("unknown key %q", name)
+}
+
+// applyKeymap rebinds the registered actions following a preset and the
+// user's bindings. It returns the keys to translate into other keys, and
+// warnings about entries that could not be applied.
+func applyKeymap(reg *ActionRegistry, keymap KeymapConfig) (map[string]tcell.Key, []string) {
+	var warnings []string
+
+	presetName := keymap.Preset
+	if presetName == "" {
+		presetName = KeymapPresetDefault
+	}
+	preset, ok := keymapPresets[presetName]
+	if !ok {
+		warnings = append(warnings, fmt.Sprintf("unknown keymap preset %q", keymap.Preset))
+	}
+
+	for id, keys := range preset.bindings {
+		reg.Bind(id, keys)
+	}
+
+	aliases := make(map[string]tcell.Key)
+	for from, to := range preset.aliases {
+		aliases[from] = keysByName[to]
+	}
+
+	// Apply contexts in a fixed order so warnings are stable
+	contexts := make([]string, 0, len(keymap.Contexts))
+	for context := range keymap.Contexts {
+		contexts = append(contexts, context)
+	}
+	sort.Strings(contexts)
+
+	for _, context := range contexts {
+		for name, keys := range keymap.Contexts[context] {
+			id := context + "." + name
+			var normalized []string
+			for _, key := range keys {
+				canonical, err := normalizeKeyName(key)
+				if err != nil {
+					warnings = append(warnings, fmt.Sprintf("%s: %v", id, err))
+					continue
+				}
+				normalized = append(normalized, canonical)
+			}
+			if !reg.Bind(id, normalized) {
+				warnings = append(warnings, fmt.Sprintf("unknown action %q", id))
+			}
+		}
+	}
+
+	return aliases, warnings
+}
+
+// isTextInput reports whether p takes typed text, so keys must reach it
+// instead of running actions
+func isTextInput(p tview.Primitive) bool {
+	switch p := p.(type) {
+	case *tview.InputField, *tview.TextArea:
+		return true
+	case *tview.DropDown:
+		return p.IsOpen()
+	}
+	return false
+}
+
+// isShortcutKey reports whether a key can't be typed into a text field, so
+// it may run an action even while one has focus
+func isShortcutKey(event *tcell.EventKey) bool {
+	if event.Key() >= tcell.KeyF1 && event.Key() <= tcell.KeyF64 {
+		return true
+	}
+	return event.Modifiers()&(tcell.ModCtrl|tcell.ModAlt) != 0
+}
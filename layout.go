@@ -0,0 +1,73 @@
+package main
+
+import "github.com/rivo/tview"
+
+// LayoutMode selects between the full and the compact layout
+type LayoutMode string
+
+const (
+	LayoutAuto    LayoutMode = "auto"    // Compact on small terminals
+	LayoutFull    LayoutMode = "full"    // Sidebar and banners
+	LayoutCompact LayoutMode = "compact" // Tab bar and no banners
+)
+
+const (
+	// The compact layout kicks in below this terminal size
+	compactMaxWidth  = 100
+	compactMaxHeight = 32
+
+	sidebarWidth  = 22
+	headingHeight = 4 // Height of screenHeading
+)
+
+// LayoutModes returns the layout modes in display order
+func LayoutModes() []LayoutMode {
+	return []LayoutMode{LayoutAuto, LayoutFull, LayoutCompact}
+}
+
+// useCompactLayout reports whether mode uses the compact layout on a terminal
+// of the given size
+func useCompactLayout(mode LayoutMode, width, height int) bool {
+	switch mode {
+	case LayoutFull:
+		return false
+	case LayoutCompact:
+		return true
+	}
+	return width < compactMaxWidth || height < compactMaxHeight
+}
+
+// Compactable is implemented by screens that hide parts of themselves in the
+// compact layout
+type Compactable interface {
+	SetCompact(compact bool)
+}
+
+// collapsible is embedded by screens to hide decorative items, such as
+// banners, in the compact layout
+type collapsible struct {
+	items []collapsibleItem
+}
+
+type collapsibleItem struct {
+	flex *tview.Flex
+	item tview.Primitive
+	size int // Fixed size in the full layout
+}
+
+// hideWhenCompact hides item of flex in the compact layout; size is its fixed
+// size otherwise
+func (c *collapsible) hideWhenCompact(flex *tview.Flex, item tview.Primitive, size int) {
+	c.items = append(c.items, collapsibleItem{flex: flex, item: item, size: size})
+}
+
+// SetCompact hides or shows the items
+func (c *collapsible) SetCompact(compact bool) {
+	for _, item := range c.items {
+		size := item.size
+		if compact {
+			size = 0
+		}
+		item.flex.ResizeItem(item.item, size, 0)
+	}
+}
@@ -1,7 +1,15 @@
 package main
 
 import (
+	"errors"
+	"flag"
 	"fmt"
+	"math"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
 	"time"
 
 	"github.com/gdamore/tcell/v2"
@@ -9,84 +17,175 @@ import (
 )
 
 func main() {
-	// Load configuration
-	config, err := LoadConfig()
+	profileFlag := flag.String("profile", defaultProfile, "profile to use (each profile has its own accounts, port and API keys)")
+	rollbackFlag := flag.Bool("rollback", false, "switch CLIProxyAPI back to the previously installed version and exit")
+	archiveFlag := flag.String("install-archive", "", "install CLIProxyAPI from a local .tar.gz or .zip `file` and exit")
+	flag.Parse()
+
+	// Load the selected profile
+	profiles := NewProfileSet()
+	pm, config, _, err := profiles.Activate(*profileFlag)
+	if pm == nil {
+		fmt.Printf("Error: %v\n", err)
+		os.Exit(1)
+	}
 	if err != nil {
 		fmt.Printf("Warning: Failed to load config: %v\n", err)
-		config = NewDefaultConfig()
 	}
 
-	// Create proxy manager
-	pm := NewProxyManager(config)
-	pm.AddLogExternal(LogLevelInfo, "LazyL2M TUI started")
+	if *rollbackFlag {
+		version, err := pm.Rollback()
+		if err != nil {
+			fmt.Printf("Error: %v\n", err)
+			os.Exit(1)
+		}
+		fmt.Printf("Rolled back to CLIProxyAPI %s\n", version)
+		return
+	}
 
-	// Auto-start if configured and binary is installed
-	if config.AutoStart && pm.IsBinaryInstalled() {
-		if err := pm.Start(); err != nil {
-			pm.AddLogExternal(LogLevelError, fmt.Sprintf("Failed to auto-start: %v", err))
+	if *archiveFlag != "" {
+		if err := pm.InstallFromArchive(*archiveFlag); err != nil {
+			fmt.Printf("Error: %v\n", err)
+			os.Exit(1)
 		}
-	} else if config.AutoStart && !pm.IsBinaryInstalled() {
-		pm.AddLogExternal(LogLevelWarn, "Auto-start enabled but CLIProxyAPI not installed. Press 'I' on dashboard to install.")
+		fmt.Printf("Installed CLIProxyAPI %s\n", pm.GetInstalledVersion())
+		return
 	}
+	pm.AddLogExternal(LogLevelInfo, fmt.Sprintf("LazyL2M TUI started (profile %q)", config.Profile))
+
+	// Apply the theme and icon mode of the profile before any primitive is
+	// created. It reports whether either changed.
+	applyAppearance := func() bool {
+		t, err := LoadTheme(config.Theme)
+		if err != nil {
+			pm.AddLogExternal(LogLevelWarn, fmt.Sprintf("Failed to load theme: %v", err))
+		}
+		wasASCII := asciiIcons
+		changed := t.Name != theme.Name
+		ApplyTheme(t)
+		SetIconMode(config.Icons)
+		return changed || asciiIcons != wasASCII
+	}
+	applyAppearance()
 
 	// Create tview application
 	app := tview.NewApplication()
 
+	// Slow operations run in the background and show up in the status bar
+	var statusBar *StatusBar
+	tasks := NewTaskRunner(app, func() {
+		statusBar.UpdateTasks()
+	})
+	statusBar = NewStatusBar(pm, tasks)
+
+	// Screens are rebuilt whenever the active profile changes
+	var (
+		dashboardScreen  *DashboardScreen
+		quotaScreen      *QuotaScreen
+		usageScreen      *UsageScreen
+		providersScreen  *ProvidersScreen
+		agentsScreen     *AgentsScreen
+		apiKeysScreen    *APIKeysScreen
+		logsScreen       *LogsScreen
+		settingsScreen   *SettingsScreen
+		screens          map[string]Screen
+		rootPages        *tview.Pages
+		mainFlex         *tview.Flex
+		actions          *ActionRegistry
+		keyAliases       map[string]tcell.Key
+		registerActions  func()
+		reloadAppearance func()
+	)
+
 	// Create screens
-	dashboardScreen := NewDashboardScreen(pm)
-	quotaScreen := NewQuotaScreen(pm)
-	providersScreen := NewProvidersScreen(pm)
-	agentsScreen := NewAgentsScreen()
-	apiKeysScreen := NewAPIKeysScreen(pm, config)
-	logsScreen := NewLogsScreen(pm)
-	settingsScreen := NewSettingsScreen(pm, config, app)
-
-	// Store screens
-	screens := map[string]Screen{
-		"dashboard": dashboardScreen,
-		"quota":     quotaScreen,
-		"providers": providersScreen,
-		"agents":    agentsScreen,
-		"apikeys":   apiKeysScreen,
-		"logs":      logsScreen,
-		"settings":  settingsScreen,
+	createScreens := func() {
+		dashboardScreen = NewDashboardScreen(pm, tasks)
+		quotaScreen = NewQuotaScreen(pm, app)
+		usageScreen = NewUsageScreen(pm, tasks)
+		providersScreen = NewProvidersScreen(pm, tasks)
+		agentsScreen = NewAgentsScreen()
+		apiKeysScreen = NewAPIKeysScreen(pm, config)
+		logsScreen = NewLogsScreen(pm)
+		settingsScreen = NewSettingsScreen(pm, app, tasks)
+		// The editors change the profile's config and the settings being
+		// edited alike, so saving the settings keeps their changes
+		editConfig := func(change func(cfg *Config)) {
+			pm.EditConfig(change)
+			settingsScreen.EditDraft(change)
+		}
+		settingsScreen.SetPricingHandler(func() {
+			showPricingEditor(app, pm, config, editConfig, rootPages, mainFlex)
+		})
+		settingsScreen.SetRoutingHandler(func() {
+			showRoutingEditor(app, pm, config, editConfig, tasks, rootPages, mainFlex)
+		})
+		settingsScreen.SetSavedHandler(func() {
+			registerActions()
+			reloadAppearance()
+		})
+
+		// Store screens
+		screens = map[string]Screen{
+			"dashboard": dashboardScreen,
+			"quota":     quotaScreen,
+			"usage":     usageScreen,
+			"providers": providersScreen,
+			"agents":    agentsScreen,
+			"apikeys":   apiKeysScreen,
+			"logs":      logsScreen,
+			"settings":  settingsScreen,
+		}
 	}
+	createScreens()
 
 	// Create sidebar navigation with enhanced styling
-	sidebar := tview.NewList().
+	sidebar := styleList(tview.NewList()).
 		ShowSecondaryText(false).
-		SetHighlightFullLine(true).
-		SetMainTextColor(tcell.ColorWhite).
-		SetSelectedTextColor(tcell.ColorBlack).
-		SetSelectedBackgroundColor(tcell.ColorDodgerBlue)
-
-	sidebar.
-		AddItem(" 📊 Dashboard", "", 'd', nil).
-		AddItem(" 📈 Quota", "", 'q', nil).
-		AddItem(" 🤖 Providers", "", 'p', nil).
-		AddItem(" ⚙️  Agents", "", 'a', nil).
-		AddItem(" 🔑 API Keys", "", 'k', nil).
-		AddItem(" 📋 Logs", "", 'l', nil).
-		AddItem(" 🔧 Settings", "", 0, nil).
-		AddItem("", "", 0, nil).
-		AddItem(" ❌ Quit", "", 'x', nil)
-
-	sidebar.SetBorder(true).SetTitle(" ☰ Navigation ").SetBorderColor(tcell.ColorDodgerBlue).SetTitleColor(tcell.ColorLightCyan)
+		SetHighlightFullLine(true)
+
+	// Fill the sidebar, showing the keys the keymap binds to each item
+	fillSidebar := func(actions *ActionRegistry) {
+		current := sidebar.GetCurrentItem()
+		sidebar.Clear()
+		for _, nav := range screenNav {
+			sidebar.AddItem(" "+iconText(nav.icon, "", nav.title), "", shortcutRune(actions.Action("global.goto-"+nav.name)), nil)
+		}
+		sidebar.AddItem("", "", 0, nil)
+		sidebar.AddItem(" "+iconText("❌", "", "Quit"), "", shortcutRune(actions.Action("global.quit")), nil)
+		sidebar.SetCurrentItem(current)
+	}
+
+	sidebar.SetBorder(true).SetTitle(sidebarTitle(config.Profile)).SetBorderColor(theme.Border).SetTitleColor(theme.Title)
 
 	// Content area
 	content := tview.NewPages()
 
-	// Add all screens to pages
-	content.AddPage("dashboard", dashboardScreen.GetView(), true, true)
-	content.AddPage("quota", quotaScreen.GetView(), true, false)
-	content.AddPage("providers", providersScreen.GetView(), true, false)
-	content.AddPage("agents", agentsScreen.GetView(), true, false)
-	content.AddPage("apikeys", apiKeysScreen.GetView(), true, false)
-	content.AddPage("logs", logsScreen.GetView(), true, false)
-	content.AddPage("settings", settingsScreen.GetView(), true, false)
-
 	// Current screen tracking
 	currentScreen := "dashboard"
+
+	// Tab bar taking the place of the sidebar in the compact layout
+	tabBar := tview.NewTextView().
+		SetDynamicColors(true).
+		SetRegions(true).
+		SetWrap(false)
+	var tabs strings.Builder
+	for _, nav := range screenNav {
+		fmt.Fprintf(&tabs, `["%s"] %s [""] `, nav.name, nav.title)
+	}
+	tabBar.SetText(tabs.String()).Highlight(currentScreen)
+
+	// Add all screens to pages, replacing those of a previous profile
+	addPages := func() {
+		content.AddPage("dashboard", dashboardScreen.GetView(), true, currentScreen == "dashboard")
+		content.AddPage("quota", quotaScreen.GetView(), true, currentScreen == "quota")
+		content.AddPage("usage", usageScreen.GetView(), true, currentScreen == "usage")
+		content.AddPage("providers", providersScreen.GetView(), true, currentScreen == "providers")
+		content.AddPage("agents", agentsScreen.GetView(), true, currentScreen == "agents")
+		content.AddPage("apikeys", apiKeysScreen.GetView(), true, currentScreen == "apikeys")
+		content.AddPage("logs", logsScreen.GetView(), true, currentScreen == "logs")
+		content.AddPage("settings", settingsScreen.GetView(), true, currentScreen == "settings")
+	}
+	addPages()
 	sidebar.SetCurrentItem(0)
 
 	// Function to switch screens
@@ -94,23 +193,127 @@ func main() {
 		currentScreen = screenName
 		content.SwitchToPage(screenName)
 		sidebar.SetCurrentItem(index)
+		tabBar.Highlight(screenName)
 
-		// Update the screen
+		// Update the screen and the keys it offers
 		if screen, ok := screens[screenName]; ok {
 			screen.Update()
 		}
+		statusBar.SetHints(actions, screenName)
 
 		app.SetFocus(content)
 	}
 
+	// Clicking a tab opens its screen
+	tabBar.SetHighlightedFunc(func(added, removed, remaining []string) {
+		if len(added) == 0 || added[0] == currentScreen {
+			return
+		}
+		for i, nav := range screenNav {
+			if nav.name == added[0] {
+				switchScreen(nav.name, i)
+			}
+		}
+	})
+
 	// Main layout with styled flex
-	mainFlex := tview.NewFlex().
-		AddItem(sidebar, 22, 0, true).
+	mainFlex = tview.NewFlex().
+		AddItem(sidebar, sidebarWidth, 0, true).
 		AddItem(content, 0, 1, false)
 
+	// The status bar runs along the bottom of every screen; the tab bar is
+	// shown in the compact layout only
+	layout := tview.NewFlex().
+		SetDirection(tview.FlexRow).
+		AddItem(tabBar, 0, 0, false).
+		AddItem(mainFlex, 0, 1, true).
+		AddItem(statusBar.GetView(), 2, 0, false)
+
+	// The compact layout replaces the sidebar with the tab bar and hides
+	// banners. layoutMode is the Layout setting until the toggle forces a mode.
+	layoutMode := config.Layout
+	compact := false
+	applyLayout := func() {
+		width, tabHeight := sidebarWidth, 0
+		if compact {
+			width, tabHeight = 0, 1
+		}
+		mainFlex.ResizeItem(sidebar, width, 0)
+		layout.ResizeItem(tabBar, tabHeight, 0)
+		for _, screen := range screens {
+			if screen, ok := screen.(Compactable); ok {
+				screen.SetCompact(compact)
+			}
+		}
+	}
+
+	// Alerts ring the bell on the next draw, which has the screen
+	bellPending := false
+	ringBell = func() {
+		app.QueueUpdateDraw(func() {
+			bellPending = true
+		})
+	}
+
+	// Pick the layout for the terminal size before every draw
+	app.SetBeforeDrawFunc(func(screen tcell.Screen) bool {
+		if bellPending {
+			bellPending = false
+			screen.Beep()
+		}
+		width, height := screen.Size()
+		if c := useCompactLayout(layoutMode, width, height); c != compact {
+			compact = c
+			applyLayout()
+			// The hidden sidebar can't keep the focus. Focus can't change
+			// while drawing, so it moves right after.
+			if compact && sidebar.HasFocus() {
+				go app.QueueUpdateDraw(func() {
+					app.SetFocus(content)
+				})
+			}
+		}
+		return false
+	})
+
 	// Root pages for modal overlay support
-	rootPages := tview.NewPages().
-		AddPage("main", mainFlex, true, true)
+	rootPages = tview.NewPages().
+		AddPage("main", layout, true, true)
+
+	// Redraw the current screen, e.g. after a background task finished
+	refreshScreen := func() {
+		if screen, ok := screens[currentScreen]; ok {
+			screen.Update()
+		}
+	}
+
+	// Restyle the primitives that outlive a theme change
+	restyleLayout := func() {
+		styleList(sidebar)
+		sidebar.SetBackgroundColor(theme.Background)
+		sidebar.SetBorderColor(theme.Border).SetTitleColor(theme.Title)
+		content.SetBackgroundColor(theme.Background)
+		mainFlex.SetBackgroundColor(theme.Background)
+		tabBar.SetTextColor(theme.Text).SetBackgroundColor(theme.Background)
+		statusBar.Restyle()
+		layout.SetBackgroundColor(theme.Background)
+		rootPages.SetBackgroundColor(theme.Background)
+	}
+
+	// Rebuild the screens when saved settings change the theme or icons
+	reloadAppearance = func() {
+		layoutMode = config.Layout
+		if !applyAppearance() {
+			return
+		}
+		createScreens()
+		registerActions()
+		addPages()
+		applyLayout()
+		restyleLayout()
+		screens[currentScreen].Update()
+		app.SetFocus(content)
+	}
 
 	// Handle sidebar selection
 	sidebar.SetSelectedFunc(func(index int, mainText string, secondaryText string, shortcut rune) {
@@ -120,198 +323,520 @@ func main() {
 		case 1:
 			switchScreen("quota", 1)
 		case 2:
-			switchScreen("providers", 2)
+			switchScreen("usage", 2)
 		case 3:
-			switchScreen("agents", 3)
+			switchScreen("providers", 3)
 		case 4:
-			switchScreen("apikeys", 4)
+			switchScreen("agents", 4)
 		case 5:
-			switchScreen("logs", 5)
+			switchScreen("apikeys", 5)
 		case 6:
-			switchScreen("settings", 6)
-		case 8: // Quit (index 8 because of empty separator at 7)
-			showQuitConfirmation(app, pm, rootPages, mainFlex)
+			switchScreen("logs", 6)
+		case 7:
+			switchScreen("settings", 7)
+		case 9: // Quit (index 9 because of empty separator at 8)
+			showQuitConfirmation(app, profiles, tasks, rootPages, mainFlex)
 		}
 	})
 
-	// Global key handler
-	app.SetInputCapture(func(event *tcell.EventKey) *tcell.EventKey {
-		// Handle Tab to toggle focus
-		if event.Key() == tcell.KeyTab {
-			if app.GetFocus() == sidebar {
-				app.SetFocus(content)
-			} else {
-				app.SetFocus(sidebar)
+	// Watch the auth directory of every loaded profile, so account changes
+	// show up immediately and background profiles act on current accounts
+	watchAuthDir := func(watched *ProxyManager) {
+		authWatcher := NewAuthWatcher(watched.GetAuthDir(), func(events []AuthDirEvent) {
+			watched.ApplyAuthDirEvents(events)
+		})
+		mode := authWatcher.Start()
+		watched.AddLogExternal(LogLevelDebug, fmt.Sprintf("Watching %s for account changes (%s)", watched.GetAuthDir(), mode))
+		go func() {
+			<-watched.Done()
+			authWatcher.Stop()
+		}()
+	}
+	watchAuthDir(pm)
+	defer func() {
+		for _, loaded := range profiles.Managers() {
+			loaded.Close()
+		}
+	}()
+
+	// Redraw the current screen as the active profile's proxy manager reports
+	// changes
+	var unsubscribe func()
+	watchEvents := func() {
+		if unsubscribe != nil {
+			unsubscribe()
+		}
+		var events <-chan Event
+		events, unsubscribe = pm.Subscribe()
+		go func() {
+			for batch := nextEvents(events); batch != nil; batch = nextEvents(events) {
+				app.QueueUpdateDraw(func() {
+					handler, ok := screens[currentScreen].(EventHandler)
+					for _, event := range batch {
+						statusBar.HandleEvent(event)
+						if ok {
+							handler.HandleEvent(event)
+						}
+					}
+				})
 			}
-			return nil
+		}()
+	}
+	watchEvents()
+	defer func() {
+		unsubscribe()
+	}()
+
+	// Function to switch to another profile; running proxies keep running,
+	// while the previous profile is unloaded if its proxy is stopped
+	switchProfile := func(name string) {
+		newPM, newConfig, loaded, err := profiles.Activate(name)
+		if newPM == nil {
+			pm.AddLogExternal(LogLevelError, fmt.Sprintf("Failed to switch profile: %v", err))
+			return
+		}
+		if err != nil {
+			newPM.AddLogExternal(LogLevelWarn, fmt.Sprintf("Failed to load config: %v", err))
+		}
+		profiles.Unload(pm.GetProfile())
+		pm, config = newPM, newConfig
+		if loaded {
+			autoStart(profiles, pm, config, tasks, refreshScreen)
+			go NewAlerter(pm).Run()
+			watchAuthDir(pm)
 		}
+		tasks.Run(taskKey(pm, taskRefresh), "Loading accounts", pm.FetchAuthFiles, func(err error) {
+			refreshScreen()
+		})
 
-		// Handle screen shortcuts
-		switch event.Rune() {
-		case 'd':
-			switchScreen("dashboard", 0)
-			return nil
-		case 'q':
-			switchScreen("quota", 1)
-			return nil
-		case 'p':
-			switchScreen("providers", 2)
-			return nil
-		case 'a':
-			switchScreen("agents", 3)
-			return nil
-		case 'k':
-			switchScreen("apikeys", 4)
-			return nil
-		case 'l':
-			switchScreen("logs", 5)
-			return nil
+		applyAppearance()
+		layoutMode = config.Layout
+		createScreens()
+		registerActions()
+		addPages()
+		applyLayout()
+		restyleLayout()
+		watchEvents()
+		statusBar.SetProxyManager(pm)
+		sidebar.SetTitle(sidebarTitle(config.Profile))
+		screens[currentScreen].Update()
+		pm.AddLogExternal(LogLevelInfo, fmt.Sprintf("Switched to profile %q", config.Profile))
+	}
+
+	// Run an install or upgrade in the background; its progress is redrawn
+	// as download events arrive. It swaps the binary a start, stop or
+	// rollback may be using, so it can't overlap them.
+	runInstall := func(installer *ProxyManager, label, started string, install func() error, failure string) {
+		tasks.Run(taskKey(installer, taskProxy), label, func() error {
+			if started != "" {
+				installer.AddLogExternal(LogLevelInfo, started)
+			}
+			return install()
+		}, func(err error) {
+			if err != nil && !errors.Is(err, errDownloadCanceled) {
+				tasks.Report(installer, LogLevelError, fmt.Sprintf("%s: %v", failure, err))
+			}
+			dashboardScreen.Update()
+		})
+		dashboardScreen.Update()
+	}
+
+	// Register the actions of every screen and of the app itself. Keys run
+	// them directly and the command palette lists them all.
+	registerActions = func() {
+		actions = NewActionRegistry()
+
+		for i, nav := range screenNav {
+			index, name := i, nav.name
+			actions.Register(Action{
+				ID:          "global.goto-" + name,
+				Title:       "Go to " + nav.title,
+				Description: nav.description,
+				Keys:        nav.keys,
+				Run:         func() { switchScreen(name, index) },
+			})
+		}
+		actions.Register(Action{
+			ID:          "global.palette",
+			Title:       "Command palette",
+			Description: "Search and run any action",
+			Keys:        []string{":", "Ctrl-P"},
+			Hint:        "Commands",
+			Run: func() {
+				showCommandPalette(app, actions.Actions(currentScreen), rootPages, mainFlex, func(action *Action) {
+					if action.Screen != "" && action.Screen != currentScreen {
+						for i, nav := range screenNav {
+							if nav.name == action.Screen {
+								switchScreen(nav.name, i)
+							}
+						}
+					}
+					action.Run()
+				})
+			},
+		})
+		cycleScreen := func(step int) {
+			for i, nav := range screenNav {
+				if nav.name == currentScreen {
+					next := (i + step + len(screenNav)) % len(screenNav)
+					switchScreen(screenNav[next].name, next)
+					return
+				}
+			}
 		}
+		actions.Register(Action{
+			ID:          "global.prev-screen",
+			Title:       "Previous screen",
+			Description: "Go to the screen above in the sidebar",
+			Keys:        []string{"["},
+			Run:         func() { cycleScreen(-1) },
+		})
+		actions.Register(Action{
+			ID:          "global.next-screen",
+			Title:       "Next screen",
+			Description: "Go to the screen below in the sidebar",
+			Keys:        []string{"]"},
+			Run:         func() { cycleScreen(1) },
+		})
+		actions.Register(Action{
+			ID:          "global.focus",
+			Title:       "Toggle focus",
+			Description: "Move focus between the sidebar and the screen",
+			Keys:        []string{"Tab"},
+			Hint:        "Focus",
+			Run: func() {
+				// The sidebar is hidden in the compact layout
+				if app.GetFocus() == sidebar || compact {
+					app.SetFocus(content)
+				} else {
+					app.SetFocus(sidebar)
+				}
+			},
+		})
+		actions.Register(Action{
+			ID:          "global.toggle-layout",
+			Title:       "Toggle compact layout",
+			Description: "Switch between the full and the compact layout until the settings are saved",
+			Keys:        []string{"z"},
+			Run: func() {
+				if compact {
+					layoutMode = LayoutFull
+				} else {
+					layoutMode = LayoutCompact
+				}
+			},
+		})
+		actions.Register(Action{
+			ID:          "global.switch-profile",
+			Title:       "Switch profile",
+			Description: "Switch to another profile or create a new one",
+			Keys:        []string{"w"},
+			Run: func() {
+				showProfileSwitcher(app, profiles, rootPages, mainFlex, switchProfile)
+			},
+		})
+		actions.Register(Action{
+			ID:          "global.quit",
+			Title:       "Quit",
+			Description: "Stop all proxies and exit LazyL2M",
+			Keys:        []string{"x"},
+			Hint:        "Quit",
+			Run: func() {
+				showQuitConfirmation(app, profiles, tasks, rootPages, mainFlex)
+			},
+		})
 
-		// Screen-specific keys
-		if currentScreen == "dashboard" {
-			switch event.Rune() {
-			case 's', 'S': // Toggle server
+		actions.Register(Action{
+			ID:          "dashboard.toggle-server",
+			Title:       "Start/stop proxy",
+			Description: "Start the proxy server, or stop it if it is running",
+			Screen:      "dashboard",
+			Keys:        []string{"s", "S"},
+			Hint:        "Server",
+			Run: func() {
 				if !pm.IsBinaryInstalled() {
 					pm.AddLogExternal(LogLevelWarn, "Binary not installed. Press 'I' to install first")
-					return nil
+					return
 				}
-				status := pm.GetStatus()
-				if status.Running {
-					if err := pm.Stop(); err != nil {
-						pm.AddLogExternal(LogLevelError, fmt.Sprintf("Failed to stop: %v", err))
-					}
+				// A second press while the proxy starts or stops is rejected
+				proxy, port := pm, config.Port
+				if proxy.GetStatus().Running {
+					tasks.Run(taskKey(proxy, taskProxy), "Stopping proxy", proxy.Stop, func(err error) {
+						if err != nil {
+							tasks.Report(proxy, LogLevelError, fmt.Sprintf("Failed to stop: %v", err))
+						} else {
+							tasks.Notify(LogLevelInfo, "Proxy stopped")
+						}
+						refreshScreen()
+					})
+				} else if err := profiles.CheckPortFree(config.Profile); err != nil {
+					tasks.Report(proxy, LogLevelError, fmt.Sprintf("Failed to start: %v", err))
 				} else {
-					if err := pm.Start(); err != nil {
-						pm.AddLogExternal(LogLevelError, fmt.Sprintf("Failed to start: %v", err))
-					}
+					tasks.Run(taskKey(proxy, taskProxy), "Starting proxy", proxy.Start, func(err error) {
+						if err != nil {
+							tasks.Report(proxy, LogLevelError, fmt.Sprintf("Failed to start: %v", err))
+						} else {
+							tasks.Notify(LogLevelInfo, fmt.Sprintf("Proxy started on port %d", port))
+						}
+						refreshScreen()
+					})
 				}
 				dashboardScreen.Update()
-				return nil
-			case 'r', 'R': // Refresh
-				pm.FetchAuthFiles()
-				pm.FetchUsageStats()
-				dashboardScreen.Update()
-				pm.AddLogExternal(LogLevelInfo, "Dashboard refreshed")
-				return nil
-			case 'i', 'I': // Install binary
+			},
+		})
+		actions.Register(Action{
+			ID:          "dashboard.install",
+			Title:       "Install CLIProxyAPI",
+			Description: "Download and install the release for this platform",
+			Screen:      "dashboard",
+			Keys:        []string{"i", "I"},
+			Hint:        "Install",
+			Run: func() {
 				if pm.IsBinaryInstalled() {
+					if latest, ok := pm.GetAvailableUpdate(); ok {
+						pm.AddLogExternal(LogLevelInfo, fmt.Sprintf("CLIProxyAPI is already installed. Press 'U' to upgrade to %s", latest))
+						return
+					}
 					pm.AddLogExternal(LogLevelInfo, "CLIProxyAPI is already installed")
-					return nil
+					return
 				}
 				if pm.IsDownloading() {
 					pm.AddLogExternal(LogLevelInfo, "Download already in progress...")
-					return nil
+					return
 				}
-				pm.AddLogExternal(LogLevelInfo, "Starting CLIProxyAPI installation...")
-				go func() {
-					if err := pm.DownloadAndInstallBinary(); err != nil {
-						pm.AddLogExternal(LogLevelError, fmt.Sprintf("Installation failed: %v", err))
+				runInstall(pm, "Installing", "Starting CLIProxyAPI installation...", pm.DownloadAndInstallBinary, "Installation failed")
+			},
+		})
+		actions.Register(Action{
+			ID:          "dashboard.install-archive",
+			Title:       "Install from archive",
+			Description: "Install CLIProxyAPI from a local .tar.gz or .zip file",
+			Screen:      "dashboard",
+			Keys:        []string{"o", "O"},
+			Hint:        "From File",
+			Run: func() {
+				if pm.IsDownloading() {
+					pm.AddLogExternal(LogLevelInfo, "Download already in progress...")
+					return
+				}
+				installer := pm
+				showArchivePrompt(app, rootPages, mainFlex, func(path string) {
+					runInstall(installer, "Installing", "", func() error {
+						return installer.InstallFromArchive(path)
+					}, "Installation failed")
+				})
+			},
+		})
+		actions.Register(Action{
+			ID:          "dashboard.cancel-download",
+			Title:       "Cancel download",
+			Description: "Stop the running download; installing again resumes it",
+			Screen:      "dashboard",
+			Keys:        []string{"c", "C"},
+			Hint:        "Cancel",
+			Run: func() {
+				if !pm.CancelDownload() {
+					pm.AddLogExternal(LogLevelInfo, "No download in progress")
+				}
+			},
+		})
+		actions.Register(Action{
+			ID:          "dashboard.rollback",
+			Title:       "Roll back CLIProxyAPI",
+			Description: "Switch back to the previously installed version",
+			Screen:      "dashboard",
+			Keys:        []string{"b", "B"},
+			Hint:        "Rollback",
+			Run: func() {
+				if pm.IsDownloading() {
+					pm.AddLogExternal(LogLevelInfo, "Download in progress, try again when it has finished")
+					return
+				}
+				if pm.IsCustomBinary() {
+					pm.AddLogExternal(LogLevelWarn, "A custom binary is configured; rollback only applies to installed releases")
+					return
+				}
+				showRollbackConfirmation(app, pm, tasks, dashboardScreen, rootPages, mainFlex)
+			},
+		})
+		actions.Register(Action{
+			ID:          "dashboard.upgrade",
+			Title:       "Upgrade CLIProxyAPI",
+			Description: "Install the latest release and restart the proxy",
+			Screen:      "dashboard",
+			Keys:        []string{"U"},
+			Hint:        "Upgrade",
+			Run: func() {
+				if !pm.IsBinaryInstalled() {
+					pm.AddLogExternal(LogLevelWarn, "Binary not installed. Press 'I' to install first")
+					return
+				}
+				if pm.IsDownloading() {
+					pm.AddLogExternal(LogLevelInfo, "Download already in progress...")
+					return
+				}
+				latest, ok := pm.GetAvailableUpdate()
+				if !ok {
+					if version, known := pm.CachedInstalledVersion(); known {
+						pm.AddLogExternal(LogLevelInfo, fmt.Sprintf("CLIProxyAPI %s is up to date", version))
+					} else {
+						pm.AddLogExternal(LogLevelInfo, "No CLIProxyAPI update available")
 					}
-					app.QueueUpdateDraw(func() {
-						dashboardScreen.Update()
-					})
-				}()
-				dashboardScreen.Update()
-				return nil
-			}
-		}
-
-		if currentScreen == "quota" {
-			switch event.Rune() {
-			case 'r', 'R': // Refresh
-				quotaScreen.Update()
-				pm.AddLogExternal(LogLevelInfo, "Quota data refreshed")
-				return nil
-			}
-		}
+					return
+				}
+				runInstall(pm, "Upgrading", fmt.Sprintf("Upgrading CLIProxyAPI to %s...", latest), pm.Upgrade, "Upgrade failed")
+			},
+		})
 
-		if currentScreen == "providers" {
-			if event.Key() == tcell.KeyEnter {
+		actions.Register(Action{
+			ID:          "quota.details",
+			Title:       "Show quota details",
+			Description: "Show the quota history and per-model limits of the selected account",
+			Screen:      "quota",
+			Keys:        []string{"Enter"},
+			Hint:        "Details",
+			Run: func() {
+				showQuotaDetails(app, pm, quotaScreen, rootPages, mainFlex)
+			},
+		})
+		actions.Register(Action{
+			ID:          "providers.details",
+			Title:       "Show provider details",
+			Description: "List the accounts connected to the selected provider",
+			Screen:      "providers",
+			Keys:        []string{"Enter"},
+			Hint:        "Details",
+			Run: func() {
 				showProviderDetails(app, pm, providersScreen, rootPages, mainFlex)
-				return nil
-			}
-			switch event.Rune() {
-			case 'r', 'R': // Refresh
-				pm.FetchAuthFiles()
-				providersScreen.Update()
-				pm.AddLogExternal(LogLevelInfo, "Providers refreshed")
-				return nil
-			}
-		}
-
-		if currentScreen == "agents" {
-			if event.Key() == tcell.KeyEnter {
+			},
+		})
+		actions.Register(Action{
+			ID:          "agents.details",
+			Title:       "Show agent details",
+			Description: "Show how the selected CLI agent is configured",
+			Screen:      "agents",
+			Keys:        []string{"Enter"},
+			Hint:        "Details",
+			Run: func() {
 				showAgentDetails(app, pm, agentsScreen, rootPages, mainFlex)
-				return nil
-			}
-			switch event.Rune() {
-			case 'r', 'R': // Refresh
+			},
+		})
+		actions.Register(Action{
+			ID:          "agents.refresh",
+			Title:       "Refresh agents",
+			Description: "Detect installed CLI agents again",
+			Screen:      "agents",
+			Keys:        []string{"r", "R"},
+			Hint:        "Refresh",
+			Run: func() {
 				agentsScreen.Update()
 				pm.AddLogExternal(LogLevelInfo, "Agents refreshed")
-				return nil
+			},
+		})
+		actions.Register(Action{
+			ID:          "apikeys.delete",
+			Title:       "Delete API key",
+			Description: "Delete the selected API key",
+			Screen:      "apikeys",
+			Keys:        []string{"d", "D"},
+			Hint:        "Delete",
+			Run: func() {
+				if len(config.APIKeys) > 0 {
+					showDeleteKeyConfirmation(app, pm, config, apiKeysScreen, rootPages, mainFlex)
+				}
+			},
+		})
+
+		for _, nav := range screenNav {
+			if provider, ok := screens[nav.name].(ActionProvider); ok {
+				provider.RegisterActions(actions)
 			}
 		}
 
-		if currentScreen == "logs" {
-			switch event.Rune() {
-			case 'c', 'C': // Clear logs
-				pm.ClearLogs()
-				logsScreen.Update()
-				pm.AddLogExternal(LogLevelInfo, "Logs cleared")
-				return nil
-			}
+		// Rebind keys following the profile's keymap
+		var warnings []string
+		keyAliases, warnings = applyKeymap(actions, config.Keymap)
+		for _, warning := range warnings {
+			pm.AddLogExternal(LogLevelWarn, fmt.Sprintf("Keymap: %s", warning))
 		}
+		fillSidebar(actions)
+		statusBar.SetHints(actions, currentScreen)
+	}
+	registerActions()
 
-		if currentScreen == "apikeys" {
-			switch event.Rune() {
-			case 'g', 'G': // Generate new key using secure generation
-				newKey, err := GenerateSecureKey()
-				if err != nil {
-					pm.AddLogExternal(LogLevelError, fmt.Sprintf("Failed to generate key: %v", err))
-					return nil
-				}
-				config.APIKeys = append(config.APIKeys, newKey)
-				apiKeysScreen.Update()
-				pm.AddLogExternal(LogLevelInfo, "New secure API key generated")
-				return nil
-			case 'd', 'D': // Delete selected key
-				if len(config.APIKeys) > 0 {
-					showDeleteKeyConfirmation(app, pm, config, apiKeysScreen, rootPages, mainFlex)
-				}
-				return nil
-			}
+	// Global key handler
+	app.SetInputCapture(func(event *tcell.EventKey) *tcell.EventKey {
+		// Let open dialogs handle their own keys
+		if name, _ := rootPages.GetFrontPage(); name != "main" {
+			return event
 		}
 
-		// Handle 'x' for quit with confirmation
-		if event.Rune() == 'x' {
-			showQuitConfirmation(app, pm, rootPages, mainFlex)
+		// Keys typed into a text field belong to the field
+		if isTextInput(app.GetFocus()) && !isShortcutKey(event) {
+			return event
+		}
+
+		name := eventKeyName(event)
+		if action := actions.Lookup(currentScreen, name); action != nil {
+			action.Run()
 			return nil
 		}
+		if key, ok := keyAliases[name]; ok {
+			return tcell.NewEventKey(key, 0, tcell.ModNone)
+		}
 
 		return event
 	})
 
+	// Start the proxy of the first profile if its config asks for it
+	autoStart(profiles, pm, config, tasks, refreshScreen)
+
+	// Alerts are checked for every loaded profile, also in the background
+	go NewAlerter(pm).Run()
+
 	// Background refresh ticker
 	go func() {
 		ticker := time.NewTicker(15 * time.Second) // Increased to 15 seconds per quotio patterns
 		defer ticker.Stop()
 
 		for range ticker.C {
-			// Refresh data if server is running
-			status := pm.GetStatus()
-			if status.Running {
-				pm.FetchAuthFiles()
-				pm.FetchUsageStats()
-				pm.FetchQuotaInfo()
-			} else {
-				// Still scan auth directory even when not running
-				pm.FetchAuthFiles()
-			}
-
-			// Update current screen
+			active, _ := profiles.Active()
+
+			// Refresh the active profile, and background profiles while
+			// their proxy runs, since their quotas drive alerts and routing
+			var wg sync.WaitGroup
+			for _, loaded := range profiles.Managers() {
+				running := loaded.GetStatus().Running
+				if loaded != active && !running {
+					continue
+				}
+				wg.Add(1)
+				go func() {
+					defer wg.Done()
+					if running {
+						loaded.FetchAuthFiles()
+						loaded.FetchUsageStats()
+						loaded.FetchQuotaInfo()
+					} else {
+						// Still scan auth directory even when not running
+						loaded.FetchAuthFiles()
+					}
+				}()
+			}
+			wg.Wait()
+
+			// Check for a newer CLIProxyAPI release on a schedule
+			if active.IsBinaryInstalled() && active.UpdateCheckDue() {
+				active.CheckForUpdate()
+			}
+
+			// Screens that don't follow events, such as Agents, are redrawn on
+			// every tick
 			app.QueueUpdateDraw(func() {
-				if screen, ok := screens[currentScreen]; ok {
+				// The request rate changes as time passes
+				statusBar.Update()
+				screen, ok := screens[currentScreen]
+				if _, handlesEvents := screen.(EventHandler); ok && !handlesEvents {
 					screen.Update()
 				}
 			})
@@ -324,32 +849,140 @@ func main() {
 	}
 
 	// Cleanup
-	if pm.GetStatus().Running {
-		pm.Stop()
-	}
+	profiles.StopAll()
 	pm.AddLogExternal(LogLevelInfo, "LazyL2M TUI stopped")
 }
 
+// screenNav lists the screens in sidebar order with the keys that open them
+var screenNav = []struct {
+	name, title, icon, description string
+	keys                           []string
+}{
+	{"dashboard", "Dashboard", "📊", "Server status, trends and costs", []string{"d"}},
+	{"quota", "Quota", "📈", "Quota usage per account", []string{"q"}},
+	{"usage", "Usage", "📑", "Usage broken down by provider, model, account or key", []string{"u"}},
+	{"providers", "Providers", "🤖", "Supported providers and their accounts", []string{"p"}},
+	{"agents", "Agents", "⚙️", "CLI agents and their configuration", []string{"a"}},
+	{"apikeys", "API Keys", "🔑", "Manage the proxy's API keys", []string{"k"}},
+	{"logs", "Logs", "📋", "Application logs", []string{"l"}},
+	{"settings", "Settings", "🔧", "Proxy, release, keymap and pricing settings", []string{"s", ","}},
+}
+
+// shortcutRune returns the first single-character key of an action, for
+// display in the sidebar
+func shortcutRune(action *Action) rune {
+	if action == nil {
+		return 0
+	}
+	for _, key := range action.Keys {
+		if runes := []rune(key); len(runes) == 1 {
+			return runes[0]
+		}
+	}
+	return 0
+}
+
+// sidebarTitle returns the sidebar title, naming the profile unless it is the default one
+func sidebarTitle(profile string) string {
+	if profile == defaultProfile {
+		return " ☰ Navigation "
+	}
+	return fmt.Sprintf(" ☰ Profile: %s ", profile)
+}
+
+// autoStart starts a profile's proxy in the background if its config asks for
+// it, and calls done on the event loop once it has started or failed
+func autoStart(profiles *ProfileSet, pm *ProxyManager, config *Config, tasks *TaskRunner, done func()) {
+	if !config.AutoStart {
+		return
+	}
+
+	// Auto-start if configured and binary is installed
+	if !pm.IsBinaryInstalled() {
+		pm.AddLogExternal(LogLevelWarn, "Auto-start enabled but CLIProxyAPI not installed. Press 'I' on dashboard to install.")
+		return
+	}
+	if err := profiles.CheckPortFree(config.Profile); err != nil {
+		pm.AddLogExternal(LogLevelError, fmt.Sprintf("Failed to auto-start: %v", err))
+		return
+	}
+	tasks.Run(taskKey(pm, taskProxy), "Starting proxy", pm.Start, func(err error) {
+		if err != nil {
+			tasks.Report(pm, LogLevelError, fmt.Sprintf("Failed to auto-start: %v", err))
+		}
+		done()
+	})
+}
+
 // showQuitConfirmation displays a confirmation modal before quitting
-func showQuitConfirmation(app *tview.Application, pm *ProxyManager, rootPages *tview.Pages, mainFlex *tview.Flex) {
+func showQuitConfirmation(app *tview.Application, profiles *ProfileSet, tasks *TaskRunner, rootPages *tview.Pages, mainFlex *tview.Flex) {
+	text := "Are you sure you want to quit LazyL2M?"
+	if running := profiles.Running(); len(running) > 1 {
+		text += fmt.Sprintf("\n\nProxies of %d profiles will be stopped.", len(running))
+	}
+
 	modal := tview.NewModal().
-		SetText("Are you sure you want to quit LazyL2M?").
+		SetText(text).
 		AddButtons([]string{"Cancel", "Quit"}).
 		SetDoneFunc(func(buttonIndex int, buttonLabel string) {
+			// Remove modal and return to main
+			rootPages.RemovePage("modal")
+			app.SetFocus(mainFlex)
 			if buttonLabel == "Quit" {
-				if pm.GetStatus().Running {
-					pm.Stop()
-				}
-				app.Stop()
-			} else {
-				// Remove modal and return to main
-				rootPages.RemovePage("modal")
-				app.SetFocus(mainFlex)
+				// Proxies take up to a few seconds to stop
+				tasks.Run("quit", "Stopping proxies", func() error {
+					profiles.StopAll()
+					return nil
+				}, func(err error) {
+					app.Stop()
+				})
+			}
+		})
+	styleModal(modal, false)
+
+	rootPages.AddPage("modal", modal, true, true)
+}
+
+// showRollbackConfirmation asks before switching back to the previous binary version
+func showRollbackConfirmation(app *tview.Application, pm *ProxyManager, tasks *TaskRunner, dashboardScreen *DashboardScreen, rootPages *tview.Pages, mainFlex *tview.Flex) {
+	previous, ok := pm.PreviousVersion()
+	if !ok {
+		pm.AddLogExternal(LogLevelWarn, "No previous CLIProxyAPI version to roll back to")
+		return
+	}
+
+	text := fmt.Sprintf("Roll back CLIProxyAPI to %s?", previous.Version)
+	if current, known := pm.CachedInstalledVersion(); known {
+		text = fmt.Sprintf("Roll back CLIProxyAPI from %s to %s?", current, previous.Version)
+	}
+	if pm.GetStatus().Running {
+		text += "\n\nThe proxy will be restarted."
+	}
+
+	modal := tview.NewModal().
+		SetText(text).
+		AddButtons([]string{"Cancel", "Roll Back"}).
+		SetDoneFunc(func(buttonIndex int, buttonLabel string) {
+			rootPages.RemovePage("modal")
+			app.SetFocus(mainFlex)
+			if buttonLabel == "Roll Back" {
+				// Rollback restarts a running proxy, so it can't overlap a start or stop
+				var version string
+				tasks.Run(taskKey(pm, taskProxy), "Rolling back", func() error {
+					var err error
+					version, err = pm.Rollback()
+					return err
+				}, func(err error) {
+					if err != nil {
+						tasks.Report(pm, LogLevelError, fmt.Sprintf("Rollback failed: %v", err))
+					} else {
+						tasks.Notify(LogLevelInfo, fmt.Sprintf("Rolled back to CLIProxyAPI %s", version))
+					}
+					dashboardScreen.Update()
+				})
 			}
 		})
-	modal.SetBackgroundColor(tcell.ColorDarkSlateGray)
-	modal.SetTextColor(tcell.ColorWhite)
-	modal.SetButtonBackgroundColor(tcell.ColorDodgerBlue)
+	styleModal(modal, false)
 
 	rootPages.AddPage("modal", modal, true, true)
 }
@@ -367,22 +1000,117 @@ func showDeleteKeyConfirmation(app *tview.Application, pm *ProxyManager, config
 		SetDoneFunc(func(buttonIndex int, buttonLabel string) {
 			if buttonLabel == "Delete" {
 				// Delete the key
-				config.APIKeys = append(config.APIKeys[:selectedIdx], config.APIKeys[selectedIdx+1:]...)
+				pm.EditConfig(func(cfg *Config) {
+					cfg.APIKeys = append(cfg.APIKeys[:selectedIdx], cfg.APIKeys[selectedIdx+1:]...)
+				})
 				apiKeysScreen.Update()
+				apiKeysScreen.SaveKeys()
 				pm.AddLogExternal(LogLevelInfo, fmt.Sprintf("API key #%d deleted", selectedIdx+1))
-				SaveConfig(config)
 			}
 			// Remove modal and return to main
 			rootPages.RemovePage("modal")
 			app.SetFocus(mainFlex)
 		})
-	modal.SetBackgroundColor(tcell.ColorDarkSlateGray)
-	modal.SetTextColor(tcell.ColorWhite)
-	modal.SetButtonBackgroundColor(tcell.ColorIndianRed)
+	styleModal(modal, true)
 
 	rootPages.AddPage("modal", modal, true, true)
 }
 
+// showQuotaDetails displays the quota history and per-model limits of the
+// selected account
+func showQuotaDetails(app *tview.Application, pm *ProxyManager, quotaScreen *QuotaScreen, rootPages *tview.Pages, mainFlex *tview.Flex) {
+	quota := quotaScreen.GetSelectedQuota()
+	if quota == nil {
+		return
+	}
+
+	closeModal := func() {
+		rootPages.RemovePage("modal")
+		app.SetFocus(mainFlex)
+	}
+
+	info := GetProviderInfo(quota.Provider)
+	resetTime := "unknown"
+	if quota.ResetTime != nil {
+		resetTime = quota.ResetTime.Format("Jan 02 15:04")
+	}
+	text := fmt.Sprintf(" [heading]%s %s · %s[-]\n\n", info.Symbol, info.Name, tview.Escape(quota.AccountName))
+	text += fmt.Sprintf(" [muted]Used[-] [text]%d of %d (%.1f%%)[-]   [muted]Status[-] [text]%s[-]   [muted]Resets[-] [text]%s[-]\n\n",
+		quota.Used, quota.Limit, quota.UsagePercent, quota.Status, resetTime)
+
+	// The sparkline shows the most recent samples, one per minute
+	history := pm.GetQuotaHistory(quota.AccountID)
+	if len(history) > quotaSparklineWidth {
+		history = history[len(history)-quotaSparklineWidth:]
+	}
+	if len(history) == 0 {
+		text += " [muted]History[-] [dim]Recorded while the proxy reports quotas[-]"
+	} else {
+		values := make([]int, len(history))
+		peak := 0.0
+		for i, sample := range history {
+			values[i] = int(math.Round(sample.UsagePercent))
+			peak = math.Max(peak, sample.UsagePercent)
+		}
+		text += fmt.Sprintf(" [muted]History since %s[-]  %s  [muted]peak[-] [text]%.0f%%[-]",
+			history[0].Timestamp.Format("15:04"), createSparkline(values, "accent"), peak)
+	}
+
+	summary := tview.NewTextView().
+		SetDynamicColors(true).
+		SetText(text)
+
+	table := styleTable(tview.NewTable()).
+		SetBorders(false).
+		SetSelectable(true, false).
+		SetFixed(1, 0)
+	if len(quota.Models) == 0 {
+		table.SetCell(0, 0, tview.NewTableCell(" [dim]No per-model limits reported by the proxy[-]").SetSelectable(false))
+	} else {
+		for col, header := range []string{"Model", "Used", "Limit", "Usage", "Reset Time"} {
+			table.SetCell(0, col, headerCell(header))
+		}
+	}
+	for row, model := range quota.Models {
+		resetTime := "[dim]N/A[-]"
+		if model.ResetTime != nil {
+			resetTime = model.ResetTime.Format("Jan 02 15:04")
+		}
+		cells := []string{
+			" " + model.Model,
+			fmt.Sprintf("%d", model.Used),
+			fmt.Sprintf("%d", model.Limit),
+			fmt.Sprintf("%s %.0f%%", createMiniProgressBar(model.UsagePercent, 10), model.UsagePercent),
+			resetTime,
+		}
+		for col, text := range cells {
+			align := tview.AlignRight
+			if col == 0 || col == 4 {
+				align = tview.AlignLeft
+			}
+			table.SetCell(row+1, col, tview.NewTableCell(text+" ").SetAlign(align))
+		}
+	}
+	table.SetInputCapture(func(event *tcell.EventKey) *tcell.EventKey {
+		if event.Key() == tcell.KeyEscape || event.Key() == tcell.KeyEnter {
+			closeModal()
+			return nil
+		}
+		return event
+	})
+
+	details := tview.NewFlex().
+		SetDirection(tview.FlexRow).
+		AddItem(summary, 6, 0, false).
+		AddItem(table, 0, 1, true)
+	details.SetBorder(true).
+		SetTitle(" Quota Details · Esc Close ").
+		SetBorderColor(theme.Border)
+
+	rootPages.AddPage("modal", centered(details, 84, 20), true, true)
+	app.SetFocus(table)
+}
+
 // showProviderDetails displays provider details and connected accounts
 func showProviderDetails(app *tview.Application, pm *ProxyManager, providersScreen *ProvidersScreen, rootPages *tview.Pages, mainFlex *tview.Flex) {
 	provider, info, count := providersScreen.GetSelectedProvider()
@@ -398,9 +1126,9 @@ func showProviderDetails(app *tview.Application, pm *ProxyManager, providersScre
 	} else {
 		accountsList = "\n\nConnected accounts:\n"
 		for _, acc := range accounts {
-			statusIcon := "✓"
-			if acc.Status != "active" {
-				statusIcon = "✗"
+			statusIcon := icon("✓", "+")
+			if acc.Status != "active" && acc.Status != "standby" {
+				statusIcon = icon("✗", "x")
 			}
 			accountsList += fmt.Sprintf("  %s %s (%s)\n", statusIcon, acc.Email, acc.Status)
 		}
@@ -416,9 +1144,7 @@ func showProviderDetails(app *tview.Application, pm *ProxyManager, providersScre
 			rootPages.RemovePage("modal")
 			app.SetFocus(mainFlex)
 		})
-	modal.SetBackgroundColor(tcell.ColorDarkSlateGray)
-	modal.SetTextColor(tcell.ColorWhite)
-	modal.SetButtonBackgroundColor(tcell.ColorDodgerBlue)
+	styleModal(modal, false)
 
 	rootPages.AddPage("modal", modal, true, true)
 }
@@ -431,14 +1157,14 @@ func showAgentDetails(app *tview.Application, pm *ProxyManager, agentsScreen *Ag
 	}
 
 	// Build status info
-	installedStatus := "❌ Not Installed"
+	installedStatus := iconText("❌", "", "Not Installed")
 	if agent.Installed {
-		installedStatus = "✅ Installed"
+		installedStatus = iconText("✅", "", "Installed")
 	}
 
-	configuredStatus := "❌ Not Configured"
+	configuredStatus := iconText("❌", "", "Not Configured")
 	if agent.Configured {
-		configuredStatus = "✅ Configured"
+		configuredStatus = iconText("✅", "", "Configured")
 	}
 
 	// Configuration instructions
@@ -461,9 +1187,593 @@ func showAgentDetails(app *tview.Application, pm *ProxyManager, agentsScreen *Ag
 			rootPages.RemovePage("modal")
 			app.SetFocus(mainFlex)
 		})
-	modal.SetBackgroundColor(tcell.ColorDarkSlateGray)
-	modal.SetTextColor(tcell.ColorWhite)
-	modal.SetButtonBackgroundColor(tcell.ColorDodgerBlue)
+	styleModal(modal, false)
 
 	rootPages.AddPage("modal", modal, true, true)
 }
+
+// showProfileSwitcher displays the list of profiles and lets the user switch
+// to one of them or create a new one
+func showProfileSwitcher(app *tview.Application, profiles *ProfileSet, rootPages *tview.Pages, mainFlex *tview.Flex, switchProfile func(string)) {
+	closeModal := func() {
+		rootPages.RemovePage("modal")
+		app.SetFocus(mainFlex)
+	}
+
+	list := styleList(tview.NewList()).
+		ShowSecondaryText(true).
+		SetHighlightFullLine(true)
+	list.SetBorder(true).SetTitle(" Switch Profile ").SetBorderColor(theme.Border)
+
+	active := profiles.ActiveName()
+	running := map[string]bool{}
+	for _, name := range profiles.Running() {
+		running[name] = true
+	}
+
+	for _, name := range ListProfiles() {
+		profileName := name
+		mainText := "  " + name
+		if name == active {
+			mainText = "● " + name
+		}
+
+		state := "[dim]stopped[-]"
+		if running[name] {
+			state = "[good]running[-]"
+		}
+		secondaryText := "    " + state
+		if config, err := LoadProfileConfig(name); err == nil {
+			secondaryText = fmt.Sprintf("    port %d  %s", config.Port, state)
+		}
+
+		list.AddItem(mainText, secondaryText, 0, func() {
+			closeModal()
+			if profileName != active {
+				switchProfile(profileName)
+			}
+		})
+	}
+
+	list.AddItem("  + New profile...", "", 0, func() {
+		rootPages.RemovePage("modal")
+		showNewProfileForm(app, rootPages, mainFlex, switchProfile)
+	})
+
+	list.SetDoneFunc(closeModal)
+	list.SetInputCapture(func(event *tcell.EventKey) *tcell.EventKey {
+		if event.Key() == tcell.KeyEscape {
+			closeModal()
+			return nil
+		}
+		return event
+	})
+
+	rootPages.AddPage("modal", centered(list, 40, 2*list.GetItemCount()+2), true, true)
+	app.SetFocus(list)
+}
+
+// showNewProfileForm asks for the name of a new profile and switches to it
+func showNewProfileForm(app *tview.Application, rootPages *tview.Pages, mainFlex *tview.Flex, switchProfile func(string)) {
+	closeModal := func() {
+		rootPages.RemovePage("modal")
+		app.SetFocus(mainFlex)
+	}
+
+	form := tview.NewForm()
+	form.SetBorder(true).SetTitle(" New Profile ")
+	styleForm(form)
+
+	form.AddInputField("Name", "", 24, nil, nil)
+	form.AddButton("Create", func() {
+		name := form.GetFormItemByLabel("Name").(*tview.InputField).GetText()
+		if err := ValidateProfileName(name); err != nil {
+			form.SetTitle(" Invalid name ")
+			return
+		}
+		if ProfileExists(name) {
+			form.SetTitle(" Profile exists ")
+			return
+		}
+		closeModal()
+		switchProfile(name)
+	})
+	form.AddButton("Cancel", closeModal)
+	form.SetCancelFunc(closeModal)
+
+	rootPages.AddPage("modal", centered(form, 44, 7), true, true)
+	app.SetFocus(form)
+}
+
+// showArchivePrompt asks for the path of a local archive to install from
+func showArchivePrompt(app *tview.Application, rootPages *tview.Pages, mainFlex *tview.Flex, install func(path string)) {
+	closeModal := func() {
+		rootPages.RemovePage("modal")
+		app.SetFocus(mainFlex)
+	}
+
+	form := tview.NewForm()
+	form.SetBorder(true).SetTitle(" Install From Archive ")
+	styleForm(form)
+
+	form.AddInputField("Path", "", 48, nil, nil)
+	form.AddButton("Install", func() {
+		path := strings.TrimSpace(form.GetFormItemByLabel("Path").(*tview.InputField).GetText())
+		if path == "" {
+			form.SetTitle(" Enter a .tar.gz or .zip path ")
+			return
+		}
+		if _, err := os.Stat(expandHome(path)); err != nil {
+			form.SetTitle(" File not found ")
+			return
+		}
+		closeModal()
+		install(path)
+	})
+	form.AddButton("Cancel", closeModal)
+	form.SetCancelFunc(closeModal)
+
+	rootPages.AddPage("modal", centered(form, 62, 7), true, true)
+	app.SetFocus(form)
+}
+
+// showCommandPalette lists every action with fuzzy search and runs the chosen one
+func showCommandPalette(app *tview.Application, actions []*Action, rootPages *tview.Pages, mainFlex *tview.Flex, run func(*Action)) {
+	closeModal := func() {
+		rootPages.RemovePage("modal")
+		app.SetFocus(mainFlex)
+	}
+
+	screenTitles := map[string]string{"": "Global"}
+	for _, nav := range screenNav {
+		screenTitles[nav.name] = nav.title
+	}
+
+	input := tview.NewInputField().
+		SetLabel(" > ").
+		SetLabelColor(theme.Label).
+		SetFieldBackgroundColor(theme.Field).
+		SetFieldTextColor(theme.Text)
+
+	list := styleList(tview.NewList()).
+		ShowSecondaryText(true).
+		SetHighlightFullLine(true)
+
+	var matches []*Action
+	selectAction := func(index int) {
+		if index < 0 || index >= len(matches) {
+			return
+		}
+		closeModal()
+		run(matches[index])
+	}
+
+	refresh := func(query string) {
+		matches = filterActions(actions, query)
+		list.Clear()
+		for _, action := range matches {
+			mainText := fmt.Sprintf(" %-34s [accent]%s[-]", action.Title, tview.Escape(action.KeyLabel()))
+			secondaryText := fmt.Sprintf("   %s · %s", screenTitles[action.Screen], action.Description)
+			list.AddItem(mainText, secondaryText, 0, nil)
+		}
+		if len(matches) == 0 {
+			list.AddItem(" [dim]No matching actions[-]", "", 0, nil)
+		}
+	}
+	refresh("")
+
+	input.SetChangedFunc(refresh)
+	input.SetInputCapture(func(event *tcell.EventKey) *tcell.EventKey {
+		current := list.GetCurrentItem()
+		switch event.Key() {
+		case tcell.KeyUp:
+			if current > 0 {
+				list.SetCurrentItem(current - 1)
+			}
+			return nil
+		case tcell.KeyDown:
+			if current < list.GetItemCount()-1 {
+				list.SetCurrentItem(current + 1)
+			}
+			return nil
+		case tcell.KeyEnter:
+			selectAction(current)
+			return nil
+		case tcell.KeyEscape:
+			closeModal()
+			return nil
+		}
+		return event
+	})
+	list.SetSelectedFunc(func(index int, mainText, secondaryText string, shortcut rune) {
+		selectAction(index)
+	})
+
+	palette := tview.NewFlex().
+		SetDirection(tview.FlexRow).
+		AddItem(input, 1, 0, true).
+		AddItem(list, 0, 1, false)
+	palette.SetBorder(true).
+		SetTitle(" Command Palette · ↑↓ Select  Enter Run  Esc Close ").
+		SetBorderColor(theme.Border)
+
+	rootPages.AddPage("modal", centered(palette, 72, 22), true, true)
+	app.SetFocus(input)
+}
+
+// centered wraps a primitive so it is displayed centered with a fixed size
+func centered(p tview.Primitive, width, height int) tview.Primitive {
+	return tview.NewFlex().
+		AddItem(nil, 0, 1, false).
+		AddItem(tview.NewFlex().
+			SetDirection(tview.FlexRow).
+			AddItem(nil, 0, 1, false).
+			AddItem(p, height, 1, true).
+			AddItem(nil, 0, 1, false), width, 1, true).
+		AddItem(nil, 0, 1, false)
+}
+
+// showPricingEditor displays the price table used for cost estimates. Prices
+// are changed through editConfig.
+func showPricingEditor(app *tview.Application, pm *ProxyManager, config *Config, editConfig func(change func(cfg *Config)), rootPages *tview.Pages, mainFlex *tview.Flex) {
+	closeModal := func() {
+		rootPages.RemovePage("modal")
+		app.SetFocus(mainFlex)
+	}
+
+	table := styleTable(tview.NewTable()).
+		SetBorders(false).
+		SetSelectable(true, false).
+		SetFixed(1, 0)
+	table.SetBorder(true).
+		SetTitle(" Pricing (USD per 1M tokens) · Enter Edit  A Add  D Delete  R Defaults  Esc Close ").
+		SetBorderColor(theme.Border)
+
+	var refresh func()
+	refresh = func() {
+		table.Clear()
+		headers := []string{"Provider", "Model", "Input", "Output", "Cache"}
+		for col, header := range headers {
+			table.SetCell(0, col, headerCell(header))
+		}
+		for row, price := range config.PriceTable() {
+			provider := "any"
+			if price.Provider != "" {
+				provider = GetProviderInfo(price.Provider).Name
+			}
+			cells := []string{
+				provider,
+				price.Model,
+				fmt.Sprintf("%.3f", price.InputPrice),
+				fmt.Sprintf("%.3f", price.OutputPrice),
+				fmt.Sprintf("%.3f", price.CachePrice),
+			}
+			for col, text := range cells {
+				align := tview.AlignRight
+				if col < 2 {
+					align = tview.AlignLeft
+				}
+				table.SetCell(row+1, col, tview.NewTableCell(" "+text+" ").SetAlign(align))
+			}
+		}
+	}
+
+	// save persists the table and shows it again
+	save := func() {
+		if err := SaveConfig(config); err != nil {
+			pm.AddLogExternal(LogLevelError, fmt.Sprintf("Failed to save pricing: %v", err))
+		}
+		refresh()
+		rootPages.RemovePage("pricing-edit")
+		app.SetFocus(table)
+	}
+
+	// editPricing changes the price table, starting from a copy of the
+	// defaults the first time prices are edited
+	editPricing := func(change func(pricing []ModelPrice) []ModelPrice) {
+		editConfig(func(cfg *Config) {
+			if cfg.Pricing == nil {
+				cfg.Pricing = DefaultPricing()
+			}
+			cfg.Pricing = change(cfg.Pricing)
+		})
+	}
+
+	// edit opens the form for row index (len for a new row)
+	edit := func(index int) {
+		price := ModelPrice{Model: "*"}
+		if prices := config.PriceTable(); index < len(prices) {
+			price = prices[index]
+		}
+
+		providers := append([]AIProvider{""}, GetAllProviders()...)
+		options := []string{"Any"}
+		selected := 0
+		for i, provider := range GetAllProviders() {
+			options = append(options, GetProviderInfo(provider).Name)
+			if provider == price.Provider {
+				selected = i + 1
+			}
+		}
+
+		form := tview.NewForm()
+		form.SetBorder(true).SetTitle(" Model Price ")
+		styleForm(form)
+
+		form.AddDropDown("Provider", options, selected, func(option string, optionIndex int) {
+			price.Provider = providers[optionIndex]
+		})
+		form.AddInputField("Model (* = prefix)", price.Model, 24, nil, func(text string) {
+			price.Model = strings.TrimSpace(text)
+		})
+		priceField := func(label string, value *float64) {
+			form.AddInputField(label, fmt.Sprintf("%g", *value), 12, nil, func(text string) {
+				if v, err := strconv.ParseFloat(strings.TrimSpace(text), 64); err == nil && v >= 0 {
+					*value = v
+				}
+			})
+		}
+		priceField("Input / 1M", &price.InputPrice)
+		priceField("Output / 1M", &price.OutputPrice)
+		priceField("Cache / 1M", &price.CachePrice)
+
+		cancel := func() {
+			rootPages.RemovePage("pricing-edit")
+			app.SetFocus(table)
+		}
+		form.AddButton("Save", func() {
+			if price.Model == "" {
+				form.SetTitle(" Model is required ")
+				return
+			}
+			editPricing(func(pricing []ModelPrice) []ModelPrice {
+				if index < len(pricing) {
+					pricing[index] = price
+					return pricing
+				}
+				return append(pricing, price)
+			})
+			save()
+		})
+		form.AddButton("Cancel", cancel)
+		form.SetCancelFunc(cancel)
+
+		rootPages.AddPage("pricing-edit", centered(form, 50, 15), true, true)
+		app.SetFocus(form)
+	}
+
+	table.SetSelectedFunc(func(row, column int) {
+		if row > 0 {
+			edit(row - 1)
+		}
+	})
+	table.SetInputCapture(func(event *tcell.EventKey) *tcell.EventKey {
+		row, _ := table.GetSelection()
+		switch {
+		case event.Key() == tcell.KeyEscape:
+			closeModal()
+			return nil
+		case event.Rune() == 'a' || event.Rune() == 'A':
+			edit(len(config.PriceTable()))
+			return nil
+		case event.Rune() == 'd' || event.Rune() == 'D':
+			if row > 0 && row <= len(config.PriceTable()) {
+				editPricing(func(pricing []ModelPrice) []ModelPrice {
+					if row > len(pricing) {
+						return pricing
+					}
+					return append(pricing[:row-1], pricing[row:]...)
+				})
+				save()
+			}
+			return nil
+		case event.Rune() == 'r' || event.Rune() == 'R':
+			editConfig(func(cfg *Config) {
+				cfg.Pricing = nil
+			})
+			save()
+			pm.AddLogExternal(LogLevelInfo, "Pricing reset to defaults")
+			return nil
+		}
+		return event
+	})
+
+	refresh()
+	rootPages.AddPage("modal", centered(table, 90, 20), true, true)
+	app.SetFocus(table)
+}
+
+// showRoutingEditor displays the routing strategy of each provider and the
+// weight and priority of its accounts. Changes are made through editConfig,
+// then saved and applied at once.
+func showRoutingEditor(app *tview.Application, pm *ProxyManager, config *Config, editConfig func(change func(cfg *Config)), tasks *TaskRunner, rootPages *tview.Pages, mainFlex *tview.Flex) {
+	closeModal := func() {
+		rootPages.RemovePage("modal")
+		app.SetFocus(mainFlex)
+	}
+
+	table := styleTable(tview.NewTable()).
+		SetBorders(false).
+		SetSelectable(true, false).
+		SetFixed(1, 0)
+	table.SetBorder(true).
+		SetTitle(" Routing · Enter Strategy  +/- Weight  [/] Priority  Esc Close ").
+		SetBorderColor(theme.Border)
+
+	// accountsOf returns the auth files of a provider in priority order
+	accountsOf := func(provider AIProvider) []string {
+		var accounts []string
+		for _, auth := range pm.GetAuthFiles() {
+			if auth.Provider == provider {
+				accounts = append(accounts, auth.ID)
+			}
+		}
+		config.OrderAccounts(accounts)
+		return accounts
+	}
+
+	// Each row is a provider, or one of its accounts when account is set
+	type routingRow struct {
+		provider AIProvider
+		account  string
+	}
+	var rows []routingRow
+
+	refresh := func() {
+		table.Clear()
+		headers := []string{"Provider / Account", "Strategy", "Applied By", "Weight", "Priority", "Status"}
+		for col, header := range headers {
+			table.SetCell(0, col, headerCell(header))
+		}
+
+		names := make(map[string]string)
+		statuses := make(map[string]string)
+		for _, auth := range pm.GetAuthFiles() {
+			names[auth.ID] = auth.Name
+			statuses[auth.ID] = auth.Status
+		}
+
+		rows = nil
+		for _, provider := range GetAllProviders() {
+			accounts := accountsOf(provider)
+			if len(accounts) == 0 && config.RoutingOverrides[provider] == "" {
+				continue
+			}
+
+			info := GetProviderInfo(provider)
+			strategy := RoutingStrategyName(config.StrategyFor(provider))
+			if config.RoutingOverrides[provider] == "" {
+				strategy += " (default)"
+			}
+			appliedBy := "proxy"
+			if config.RoutingEmulated(provider) {
+				appliedBy = "LazyL2M"
+			}
+			row := len(rows) + 1
+			rows = append(rows, routingRow{provider: provider})
+			table.SetCell(row, 0, tview.NewTableCell(fmt.Sprintf(" %s %s ", info.Symbol, info.Name)).SetTextColor(theme.Accent))
+			table.SetCell(row, 1, tview.NewTableCell(" "+strategy+" "))
+			table.SetCell(row, 2, tview.NewTableCell(" "+appliedBy+" "))
+
+			for i, account := range accounts {
+				row := len(rows) + 1
+				rows = append(rows, routingRow{provider: provider, account: account})
+				table.SetCell(row, 0, tview.NewTableCell("   "+names[account]+" ").SetMaxWidth(36))
+				table.SetCell(row, 3, tview.NewTableCell(fmt.Sprintf(" %d ", config.RoutingWeight(account))).SetAlign(tview.AlignRight))
+				table.SetCell(row, 4, tview.NewTableCell(fmt.Sprintf(" %d ", i+1)).SetAlign(tview.AlignRight))
+				table.SetCell(row, 5, tview.NewTableCell(" "+statuses[account]+" "))
+			}
+		}
+		if len(rows) == 0 {
+			table.SetCell(1, 0, tview.NewTableCell(" No connected accounts ").SetTextColor(theme.Muted).SetSelectable(false))
+		}
+	}
+
+	// reselect refreshes the table, keeping the selection on the same
+	// provider or account
+	reselect := func(selected routingRow) {
+		refresh()
+		for i, row := range rows {
+			if row == selected {
+				table.Select(i+1, 0)
+			}
+		}
+	}
+
+	// apply saves the config and applies it to the proxy and the accounts
+	apply := func(selected routingRow) {
+		if err := SaveConfig(config); err != nil {
+			pm.AddLogExternal(LogLevelError, fmt.Sprintf("Failed to save routing: %v", err))
+		}
+		pm.UpdateConfig()
+		reselect(selected)
+		tasks.Run(taskKey(pm, taskAccounts), "Applying routing", pm.ApplyAccountRules, func(err error) {
+			if rootPages.HasPage("modal") {
+				index, _ := table.GetSelection()
+				if index >= 1 && index <= len(rows) {
+					selected = rows[index-1]
+				}
+				reselect(selected)
+			}
+		})
+	}
+
+	table.SetInputCapture(func(event *tcell.EventKey) *tcell.EventKey {
+		index, _ := table.GetSelection()
+		if event.Key() == tcell.KeyEscape {
+			closeModal()
+			return nil
+		}
+		if index < 1 || index > len(rows) {
+			return event
+		}
+		selected := rows[index-1]
+
+		switch {
+		case event.Key() == tcell.KeyEnter && selected.account == "":
+			// Cycle through the default and each strategy
+			options := append([]RoutingStrategy{""}, RoutingStrategies()...)
+			current := slices.Index(options, config.RoutingOverrides[selected.provider])
+			next := options[(current+1)%len(options)]
+			editConfig(func(cfg *Config) {
+				if cfg.RoutingOverrides == nil {
+					cfg.RoutingOverrides = make(map[AIProvider]RoutingStrategy)
+				}
+				if next == "" {
+					delete(cfg.RoutingOverrides, selected.provider)
+				} else {
+					cfg.RoutingOverrides[selected.provider] = next
+				}
+			})
+			apply(selected)
+			return nil
+
+		case (event.Rune() == '+' || event.Rune() == '-') && selected.account != "":
+			weight := config.RoutingWeight(selected.account)
+			if event.Rune() == '+' {
+				weight++
+			} else if weight > 1 {
+				weight--
+			}
+			editConfig(func(cfg *Config) {
+				if cfg.RoutingWeights == nil {
+					cfg.RoutingWeights = make(map[string]int)
+				}
+				if weight == 1 {
+					delete(cfg.RoutingWeights, selected.account)
+				} else {
+					cfg.RoutingWeights[selected.account] = weight
+				}
+			})
+			apply(selected)
+			return nil
+
+		case (event.Rune() == '[' || event.Rune() == ']') && selected.account != "":
+			accounts := accountsOf(selected.provider)
+			i := slices.Index(accounts, selected.account)
+			j := i - 1
+			if event.Rune() == ']' {
+				j = i + 1
+			}
+			if j < 0 || j >= len(accounts) {
+				return nil
+			}
+			accounts[i], accounts[j] = accounts[j], accounts[i]
+
+			// The provider's accounts are listed after those of the others
+			editConfig(func(cfg *Config) {
+				order := slices.DeleteFunc(slices.Clone(cfg.RoutingOrder), func(account string) bool {
+					return slices.Contains(accounts, account)
+				})
+				cfg.RoutingOrder = append(order, accounts...)
+			})
+			apply(selected)
+			return nil
+		}
+		return event
+	})
+
+	refresh()
+	rootPages.AddPage("modal", centered(table, 110, 20), true, true)
+	app.SetFocus(table)
+}
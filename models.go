@@ -1,7 +1,9 @@
 package main
 
 import (
+	"maps"
 	"os/exec"
+	"slices"
 	"time"
 )
 
@@ -24,7 +26,7 @@ const (
 // ProviderInfo holds display information for a provider
 type ProviderInfo struct {
 	Name   string
-	Symbol string
+	Symbol string // Emoji, or a three-letter tag in ASCII mode
 	Color  string
 }
 
@@ -32,27 +34,27 @@ type ProviderInfo struct {
 func GetProviderInfo(provider AIProvider) ProviderInfo {
 	switch provider {
 	case ProviderGemini:
-		return ProviderInfo{Name: "Gemini", Symbol: "💎", Color: "blue"}
+		return ProviderInfo{Name: "Gemini", Symbol: icon("💎", "GEM"), Color: "blue"}
 	case ProviderClaude:
-		return ProviderInfo{Name: "Claude", Symbol: "🤖", Color: "orange"}
+		return ProviderInfo{Name: "Claude", Symbol: icon("🤖", "CLD"), Color: "orange"}
 	case ProviderCodex:
-		return ProviderInfo{Name: "Codex", Symbol: "⚡", Color: "green"}
+		return ProviderInfo{Name: "Codex", Symbol: icon("⚡", "CDX"), Color: "green"}
 	case ProviderQwen:
-		return ProviderInfo{Name: "Qwen", Symbol: "🐉", Color: "red"}
+		return ProviderInfo{Name: "Qwen", Symbol: icon("🐉", "QWN"), Color: "red"}
 	case ProviderIFlow:
-		return ProviderInfo{Name: "iFlow", Symbol: "🌊", Color: "cyan"}
+		return ProviderInfo{Name: "iFlow", Symbol: icon("🌊", "IFL"), Color: "cyan"}
 	case ProviderAntigravity:
-		return ProviderInfo{Name: "Antigravity", Symbol: "🚀", Color: "purple"}
+		return ProviderInfo{Name: "Antigravity", Symbol: icon("🚀", "AGY"), Color: "purple"}
 	case ProviderVertex:
-		return ProviderInfo{Name: "Vertex AI", Symbol: "🔷", Color: "blue"}
+		return ProviderInfo{Name: "Vertex AI", Symbol: icon("🔷", "VTX"), Color: "blue"}
 	case ProviderKiro:
-		return ProviderInfo{Name: "Kiro", Symbol: "🎯", Color: "yellow"}
+		return ProviderInfo{Name: "Kiro", Symbol: icon("🎯", "KIR"), Color: "yellow"}
 	case ProviderGitHubCopilot:
-		return ProviderInfo{Name: "GitHub Copilot", Symbol: "🐙", Color: "gray"}
+		return ProviderInfo{Name: "GitHub Copilot", Symbol: icon("🐙", "GHC"), Color: "gray"}
 	case ProviderCursor:
-		return ProviderInfo{Name: "Cursor", Symbol: "➡️", Color: "white"}
+		return ProviderInfo{Name: "Cursor", Symbol: icon("➡️", "CUR"), Color: "white"}
 	default:
-		return ProviderInfo{Name: string(provider), Symbol: "❓", Color: "white"}
+		return ProviderInfo{Name: string(provider), Symbol: icon("❓", "???"), Color: "white"}
 	}
 }
 
@@ -74,8 +76,11 @@ func GetAllProviders() []AIProvider {
 
 // ProxyStatus represents the proxy server status
 type ProxyStatus struct {
-	Running bool
-	Port    int
+	Running  bool
+	Starting bool // Start is waiting for the process to come up
+	Stopping bool // Stop is waiting for the process to exit
+	Port     int
+	ExitedAt time.Time // When the process exited without being stopped, zero otherwise
 }
 
 // AuthFile represents an authenticated account
@@ -91,23 +96,66 @@ type AuthFile struct {
 
 // UsageStats represents overall usage statistics
 type UsageStats struct {
-	TotalRequests   int     `json:"total_requests"`
-	SuccessRequests int     `json:"success_requests"`
-	FailedRequests  int     `json:"failed_requests"`
-	TotalTokens     int     `json:"total_tokens"`
-	SuccessRate     float64 `json:"success_rate"`
+	TotalRequests   int                 `json:"total_requests"`
+	SuccessRequests int                 `json:"success_requests"`
+	FailedRequests  int                 `json:"failed_requests"`
+	TotalTokens     int                 `json:"total_tokens"`
+	SuccessRate     float64             `json:"success_rate"`
+	APIs            map[string]APIUsage `json:"apis"` // Keyed by client API key
 	LastUpdated     time.Time
 }
 
+// APIUsage represents usage of one client API key
+type APIUsage struct {
+	TotalRequests int                   `json:"total_requests"`
+	TotalTokens   int                   `json:"total_tokens"`
+	Models        map[string]ModelUsage `json:"models"`
+}
+
+// ModelUsage represents usage of one model through an API key
+type ModelUsage struct {
+	TotalRequests int             `json:"total_requests"`
+	TotalTokens   int             `json:"total_tokens"`
+	Details       []RequestDetail `json:"details"`
+}
+
+// RequestDetail represents a single proxied request
+type RequestDetail struct {
+	Timestamp time.Time  `json:"timestamp"`
+	Source    string     `json:"source"` // Account that served the request
+	Tokens    TokenStats `json:"tokens"`
+	Failed    bool       `json:"failed"`
+	LatencyMs int64      `json:"latency_ms"`
+}
+
+// TokenStats represents the token split of a request
+type TokenStats struct {
+	InputTokens     int `json:"input_tokens"`
+	OutputTokens    int `json:"output_tokens"`
+	ReasoningTokens int `json:"reasoning_tokens"`
+	CachedTokens    int `json:"cached_tokens"`
+	TotalTokens     int `json:"total_tokens"`
+}
+
 // QuotaInfo represents quota information for an account
 type QuotaInfo struct {
-	AccountID    string     `json:"account_id"`
-	AccountName  string     `json:"account_name"`
-	Provider     AIProvider `json:"provider"`
+	AccountID    string       `json:"account_id"`
+	AccountName  string       `json:"account_name"`
+	Provider     AIProvider   `json:"provider"`
+	Used         int          `json:"used"`
+	Limit        int          `json:"limit"`
+	UsagePercent float64      `json:"usage_percent"`
+	Status       string       `json:"status"` // "ok", "warning", "exceeded"
+	ResetTime    *time.Time   `json:"reset_time"`
+	Models       []ModelQuota `json:"models,omitempty"` // Limits of single models, if the provider has any
+}
+
+// ModelQuota represents the quota of one model of an account
+type ModelQuota struct {
+	Model        string     `json:"model"`
 	Used         int        `json:"used"`
 	Limit        int        `json:"limit"`
 	UsagePercent float64    `json:"usage_percent"`
-	Status       string     `json:"status"` // "ok", "warning", "exceeded"
 	ResetTime    *time.Time `json:"reset_time"`
 }
 
@@ -170,19 +218,103 @@ type RoutingStrategy string
 const (
 	RoutingRoundRobin RoutingStrategy = "round-robin"
 	RoutingFillFirst  RoutingStrategy = "fill-first"
+	RoutingLeastUsed  RoutingStrategy = "least-used-quota" // The account with the lowest quota usage
+	RoutingWeighted   RoutingStrategy = "weighted"         // Requests shared by RoutingWeights
+	RoutingPriority   RoutingStrategy = "priority"         // RoutingOrder, failing over to the next account
+)
+
+// RoutingStrategies returns all routing strategies in display order
+func RoutingStrategies() []RoutingStrategy {
+	return []RoutingStrategy{RoutingRoundRobin, RoutingFillFirst, RoutingLeastUsed, RoutingWeighted, RoutingPriority}
+}
+
+// QuotaExceededBehavior selects what happens to an account whose quota is
+// exceeded
+type QuotaExceededBehavior string
+
+const (
+	QuotaExceededSkip     QuotaExceededBehavior = "skip"     // The proxy switches project or preview model
+	QuotaExceededStop     QuotaExceededBehavior = "stop"     // LazyL2M pauses the account until its quota resets
+	QuotaExceededContinue QuotaExceededBehavior = "continue" // Requests keep going to the account
+)
+
+// QuotaExceededBehaviors returns the behaviors in display order
+func QuotaExceededBehaviors() []QuotaExceededBehavior {
+	return []QuotaExceededBehavior{QuotaExceededSkip, QuotaExceededStop, QuotaExceededContinue}
+}
+
+// ReleaseChannel selects which CLIProxyAPI releases are installed
+type ReleaseChannel string
+
+const (
+	ReleaseChannelLatest     ReleaseChannel = "latest"
+	ReleaseChannelPrerelease ReleaseChannel = "prerelease"
 )
 
 // Config represents application configuration
 type Config struct {
-	Port                  int             `json:"port"`
-	RoutingStrategy       RoutingStrategy `json:"routing_strategy"`
-	AutoStart             bool            `json:"auto_start"`
-	DebugMode             bool            `json:"debug_mode"`
-	LogToFile             bool            `json:"log_to_file"`
-	UsageStatsEnabled     bool            `json:"usage_stats_enabled"`
-	RequestRetryCount     int             `json:"request_retry_count"`
-	APIKeys               []string        `json:"api_keys"`
-	QuotaExceededBehavior string          `json:"quota_exceeded_behavior"` // "skip", "stop", "continue"
+	Port                  int                            `json:"port"`
+	RoutingStrategy       RoutingStrategy                `json:"routing_strategy"`
+	RoutingOverrides      map[AIProvider]RoutingStrategy `json:"routing_overrides,omitempty"` // Strategy by provider, instead of RoutingStrategy
+	RoutingWeights        map[string]int                 `json:"routing_weights,omitempty"`   // Weight by auth file for "weighted", default 1
+	RoutingOrder          []string                       `json:"routing_order,omitempty"`     // Auth files in "priority" order, others follow by name
+	AutoStart             bool                           `json:"auto_start"`
+	DebugMode             bool                           `json:"debug_mode"`
+	LogToFile             bool                           `json:"log_to_file"`
+	UsageStatsEnabled     bool                           `json:"usage_stats_enabled"`
+	RequestRetryCount     int                            `json:"request_retry_count"`
+	APIKeys               []string                       `json:"api_keys"`
+	QuotaExceededBehavior QuotaExceededBehavior          `json:"quota_exceeded_behavior"`              // "skip", "stop", "continue"
+	QuotaSwitchProject    *bool                          `json:"quota_switch_project,omitempty"`       // Used by "skip", nil means true
+	QuotaSwitchPreview    *bool                          `json:"quota_switch_preview_model,omitempty"` // Used by "skip", nil means true
+	AuthDir               string                         `json:"auth_dir,omitempty"`                   // Defaults to ~/.cli-proxy-api
+	Pricing               []ModelPrice                   `json:"pricing"`                              // nil uses DefaultPricing
+	BinarySHA256          string                         `json:"binary_sha256,omitempty"`              // Pinned SHA-256 of the release asset
+	BinaryPublicKey       string                         `json:"binary_public_key,omitempty"`          // Ed25519 key that signs the checksums file
+	KeepVersions          int                            `json:"keep_versions,omitempty"`              // Installed binary versions to keep, 0 uses 3
+	ReleaseRepo           string                         `json:"release_repo,omitempty"`               // Defaults to router-for-me/CLIProxyAPIPlus
+	ReleaseChannel        ReleaseChannel                 `json:"release_channel,omitempty"`            // "latest" or "prerelease"
+	PinnedVersion         string                         `json:"pinned_version,omitempty"`             // Release tag to install instead of the newest
+	BinaryPath            string                         `json:"binary_path,omitempty"`                // Existing binary or PATH command to run instead
+	ReleaseMirror         string                         `json:"release_mirror,omitempty"`             // Base URL serving GitHub-compatible release JSON
+	Keymap                KeymapConfig                   `json:"keymap"`                               // Key preset and remapped actions
+	Theme                 string                         `json:"theme,omitempty"`                      // Built-in theme or themes/<name>.json, defaults to dark
+	Icons                 IconMode                       `json:"icons,omitempty"`                      // "auto", "emoji" or "ascii"
+	Layout                LayoutMode                     `json:"layout,omitempty"`                     // "auto", "full" or "compact"
+	Alerts                AlertsConfig                   `json:"alerts"`                               // Alert rules and notifiers
+
+	// Profile is the name of the profile this config was loaded from
+	Profile string `json:"-"`
+}
+
+// Clone returns a deep copy of the config
+func (c *Config) Clone() *Config {
+	clone := *c
+	clone.RoutingOverrides = maps.Clone(c.RoutingOverrides)
+	clone.RoutingWeights = maps.Clone(c.RoutingWeights)
+	clone.RoutingOrder = slices.Clone(c.RoutingOrder)
+	clone.APIKeys = slices.Clone(c.APIKeys)
+	clone.Pricing = slices.Clone(c.Pricing)
+	clone.Alerts.Rules = slices.Clone(c.Alerts.Rules)
+	if c.QuotaSwitchProject != nil {
+		value := *c.QuotaSwitchProject
+		clone.QuotaSwitchProject = &value
+	}
+	if c.QuotaSwitchPreview != nil {
+		value := *c.QuotaSwitchPreview
+		clone.QuotaSwitchPreview = &value
+	}
+	if c.Keymap.Contexts != nil {
+		clone.Keymap.Contexts = make(map[string]map[string][]string, len(c.Keymap.Contexts))
+		for context, bindings := range c.Keymap.Contexts {
+			copied := make(map[string][]string, len(bindings))
+			for action, keys := range bindings {
+				copied[action] = slices.Clone(keys)
+			}
+			clone.Keymap.Contexts[context] = copied
+		}
+	}
+	return &clone
 }
 
 // NewDefaultConfig returns a config with default values
@@ -196,6 +328,7 @@ func NewDefaultConfig() *Config {
 		UsageStatsEnabled:     true,
 		RequestRetryCount:     3,
 		APIKeys:               []string{},
-		QuotaExceededBehavior: "skip",
+		QuotaExceededBehavior: QuotaExceededSkip,
+		Profile:               defaultProfile,
 	}
 }
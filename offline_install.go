@@ -0,0 +1,132 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// localRepo is recorded as the repo of binaries installed from a local archive
+const localRepo = "local"
+
+// InstallFromArchive installs the binary from a local .tar.gz or .zip archive,
+// or a bare binary, for machines that cannot reach GitHub
+func (pm *ProxyManager) InstallFromArchive(path string) error {
+	if pm.IsCustomBinary() {
+		return fmt.Errorf("a custom binary is configured (%s); clear Binary Path in Settings to install releases", pm.Config().BinaryPath)
+	}
+
+	pm.mutex.Lock()
+	if pm.isDownloading {
+		pm.mutex.Unlock()
+		return fmt.Errorf("another installation is in progress")
+	}
+	pm.isDownloading = true
+	pm.downloadProgress = 0
+	pm.lastError = ""
+	pm.events.Publish(EventDownloadProgress)
+	pm.mutex.Unlock()
+
+	defer func() {
+		pm.mutex.Lock()
+		pm.isDownloading = false
+		pm.events.Publish(EventDownloadProgress)
+		pm.mutex.Unlock()
+	}()
+
+	path = expandHome(strings.TrimSpace(path))
+	name := filepath.Base(path)
+	pm.AddLogExternal(LogLevelInfo, fmt.Sprintf("Installing CLIProxyAPI from %s...", path))
+
+	info, err := os.Stat(path)
+	if err == nil && info.IsDir() {
+		err = fmt.Errorf("%s is a directory", path)
+	}
+	if err != nil {
+		pm.mutex.Lock()
+		pm.lastError = err.Error()
+		pm.mutex.Unlock()
+		pm.AddLogExternal(LogLevelError, fmt.Sprintf("Failed to open archive: %v", err))
+		return err
+	}
+
+	// Verify against a pinned hash and any checksums shipped with the archive
+	checksum, err := fileSHA256(path)
+	if err == nil {
+		err = pm.verifyLocalArchive(path, checksum)
+	}
+	if err != nil {
+		pm.mutex.Lock()
+		pm.lastError = err.Error()
+		pm.mutex.Unlock()
+		pm.AddLogExternal(LogLevelError, fmt.Sprintf("Verification failed: %v", err))
+		return err
+	}
+
+	pm.setDownloadProgress(0.7)
+
+	// Release archives carry the version in their name
+	version := versionPattern.FindString(name)
+	if version == "" {
+		version = localRepo
+	}
+
+	dir, err := pm.extractAndStage(path, name, version)
+	if err == nil {
+		if err := writeManifest(dir, version, localRepo, name, checksum); err != nil {
+			pm.AddLogExternal(LogLevelWarn, fmt.Sprintf("Failed to record installed version: %v", err))
+		}
+		if err = pm.activateVersion(dir); err != nil {
+			os.RemoveAll(dir)
+		}
+	}
+	if err != nil {
+		pm.mutex.Lock()
+		pm.lastError = err.Error()
+		pm.mutex.Unlock()
+		pm.AddLogExternal(LogLevelError, fmt.Sprintf("Failed to install: %v", err))
+		return err
+	}
+	pm.AddLogExternal(LogLevelInfo, fmt.Sprintf("Binary installed to %s", dir))
+	pm.PruneVersions()
+
+	pm.setDownloadProgress(1.0)
+
+	pm.AddLogExternal(LogLevelInfo, fmt.Sprintf("CLIProxyAPI %s installed successfully from %s!", version, name))
+	return nil
+}
+
+// verifyLocalArchive checks a local archive, given its SHA-256, against a
+// checksums file next to it, such as "<archive>.sha256" or "checksums.txt",
+// with the same rules as downloads
+func (pm *ProxyManager) verifyLocalArchive(path, checksum string) error {
+	name := filepath.Base(path)
+
+	candidates := []string{path + ".sha256"}
+	if matches, err := filepath.Glob(filepath.Join(filepath.Dir(path), "*checksum*")); err == nil {
+		candidates = append(candidates, matches...)
+	}
+
+	// The first checksums file that lists the archive is used
+	var checksums *checksumFile
+	for _, candidate := range candidates {
+		if strings.HasSuffix(candidate, ".sig") {
+			continue
+		}
+		data, err := os.ReadFile(candidate)
+		if err != nil {
+			continue
+		}
+		if _, err := parseChecksum(data, name); err != nil {
+			continue
+		}
+		checksums = &checksumFile{name: filepath.Base(candidate), data: data}
+		if signature, err := os.ReadFile(candidate + ".sig"); err == nil {
+			checksums.signature = signature
+		}
+		break
+	}
+
+	return pm.verifyChecksums(name, checksum, checksums)
+}
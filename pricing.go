@@ -0,0 +1,176 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// ModelPrice holds list prices in USD per million tokens for a model.
+// Model may end with "*" to match every model with that prefix, and an
+// empty Provider matches any provider.
+type ModelPrice struct {
+	Provider    AIProvider `json:"provider"`
+	Model       string     `json:"model"`
+	InputPrice  float64    `json:"input_per_mtok"`
+	OutputPrice float64    `json:"output_per_mtok"`
+	CachePrice  float64    `json:"cache_per_mtok"`
+}
+
+// DefaultPricing returns the built-in list prices used until the user edits them
+func DefaultPricing() []ModelPrice {
+	return []ModelPrice{
+		{Provider: ProviderClaude, Model: "claude-opus-*", InputPrice: 15, OutputPrice: 75, CachePrice: 1.5},
+		{Provider: ProviderClaude, Model: "claude-sonnet-*", InputPrice: 3, OutputPrice: 15, CachePrice: 0.3},
+		{Provider: ProviderClaude, Model: "claude-haiku-*", InputPrice: 1, OutputPrice: 5, CachePrice: 0.1},
+		{Provider: ProviderGemini, Model: "gemini-2.5-pro*", InputPrice: 1.25, OutputPrice: 10, CachePrice: 0.31},
+		{Provider: ProviderGemini, Model: "gemini-2.5-flash*", InputPrice: 0.3, OutputPrice: 2.5, CachePrice: 0.075},
+		{Provider: ProviderCodex, Model: "gpt-5*", InputPrice: 1.25, OutputPrice: 10, CachePrice: 0.125},
+		{Provider: ProviderQwen, Model: "qwen3-coder*", InputPrice: 1, OutputPrice: 5, CachePrice: 0.1},
+	}
+}
+
+// PriceTable returns the configured prices, or the defaults if none are set
+func (c *Config) PriceTable() []ModelPrice {
+	if c.Pricing == nil {
+		return DefaultPricing()
+	}
+	return c.Pricing
+}
+
+// matches reports whether the price applies to a model and how specific
+// the match is (higher is better, -1 means no match)
+func (p ModelPrice) matches(provider AIProvider, model string) int {
+	if p.Provider != "" && provider != "" && p.Provider != provider {
+		return -1
+	}
+
+	pattern := strings.ToLower(p.Model)
+	name := strings.ToLower(model)
+	if prefix, ok := strings.CutSuffix(pattern, "*"); ok {
+		if strings.HasPrefix(name, prefix) {
+			return len(prefix)
+		}
+		return -1
+	}
+	if pattern == name {
+		// Exact matches beat any prefix
+		return len(pattern) + 1000
+	}
+	return -1
+}
+
+// FindPrice returns the most specific price for a model
+func FindPrice(prices []ModelPrice, provider AIProvider, model string) (ModelPrice, bool) {
+	best, bestScore := ModelPrice{}, -1
+	for _, price := range prices {
+		if score := price.matches(provider, model); score > bestScore {
+			best, bestScore = price, score
+		}
+	}
+	return best, bestScore >= 0
+}
+
+// Cost returns the estimated cost of a request's tokens in USD
+func (p ModelPrice) Cost(tokens TokenStats) float64 {
+	uncached := tokens.InputTokens - tokens.CachedTokens
+	if uncached < 0 {
+		uncached = 0
+	}
+	output := tokens.OutputTokens + tokens.ReasoningTokens
+
+	return (float64(uncached)*p.InputPrice +
+		float64(tokens.CachedTokens)*p.CachePrice +
+		float64(output)*p.OutputPrice) / 1000000
+}
+
+// CostEstimate holds estimated costs grouped by day, account and model
+type CostEstimate struct {
+	Total     float64
+	ByDay     map[string]float64 // Keyed by YYYY-MM-DD
+	ByAccount map[string]float64
+	ByModel   map[string]float64
+	Unpriced  int // Requests whose model has no price or no token details
+}
+
+// CostEntry is one row of a cost grouping
+type CostEntry struct {
+	Name string
+	Cost float64
+}
+
+// EstimateCosts prices every request detail reported by the proxy
+func EstimateCosts(stats UsageStats, prices []ModelPrice, accounts []AuthFile) CostEstimate {
+	estimate := CostEstimate{
+		ByDay:     map[string]float64{},
+		ByAccount: map[string]float64{},
+		ByModel:   map[string]float64{},
+	}
+
+	accountProviders := map[string]AIProvider{}
+	for _, auth := range accounts {
+		accountProviders[auth.Email] = auth.Provider
+	}
+
+	for _, api := range stats.APIs {
+		for model, modelUsage := range api.Models {
+			if len(modelUsage.Details) == 0 {
+				estimate.Unpriced += modelUsage.TotalRequests
+				continue
+			}
+
+			for _, detail := range modelUsage.Details {
+				provider := providerForModel(model)
+				if p, ok := accountProviders[detail.Source]; ok {
+					provider = p
+				}
+
+				price, ok := FindPrice(prices, provider, model)
+				if !ok {
+					estimate.Unpriced++
+					continue
+				}
+
+				cost := price.Cost(detail.Tokens)
+				estimate.Total += cost
+				estimate.ByModel[model] += cost
+				account := detail.Source
+				if account == "" {
+					account = "unknown"
+				}
+				estimate.ByAccount[account] += cost
+				if !detail.Timestamp.IsZero() {
+					estimate.ByDay[detail.Timestamp.Local().Format("2006-01-02")] += cost
+				}
+			}
+		}
+	}
+
+	return estimate
+}
+
+// TopCosts returns the n most expensive entries of a grouping
+func TopCosts(costs map[string]float64, n int) []CostEntry {
+	entries := make([]CostEntry, 0, len(costs))
+	for name, cost := range costs {
+		entries = append(entries, CostEntry{Name: name, Cost: cost})
+	}
+	sort.Slice(entries, func(i, j int) bool {
+		if entries[i].Cost != entries[j].Cost {
+			return entries[i].Cost > entries[j].Cost
+		}
+		return entries[i].Name < entries[j].Name
+	})
+	if len(entries) > n {
+		entries = entries[:n]
+	}
+	return entries
+}
+
+// formatCost formats a USD amount, keeping precision for small values
+func formatCost(cost float64) string {
+	if cost > 0 && cost < 1 {
+		return fmt.Sprintf("$%.4f", cost)
+	}
+	return fmt.Sprintf("$%.2f", cost)
+}
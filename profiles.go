@@ -0,0 +1,160 @@
+package main
+
+import (
+	"fmt"
+	"sync"
+)
+
+// ProfileSet keeps one proxy manager per loaded profile so proxies of
+// different profiles can keep running side by side on their own ports
+type ProfileSet struct {
+	managers map[string]*ProxyManager
+	configs  map[string]*Config
+	active   string
+	mutex    sync.RWMutex
+}
+
+// NewProfileSet creates an empty profile set
+func NewProfileSet() *ProfileSet {
+	return &ProfileSet{
+		managers: map[string]*ProxyManager{},
+		configs:  map[string]*Config{},
+	}
+}
+
+// Load returns the proxy manager and config for a profile, creating them on
+// first use. loaded is true when the profile was not loaded before.
+func (ps *ProfileSet) Load(name string) (pm *ProxyManager, config *Config, loaded bool, err error) {
+	if err := ValidateProfileName(name); err != nil {
+		return nil, nil, false, err
+	}
+
+	ps.mutex.Lock()
+	defer ps.mutex.Unlock()
+
+	if pm, ok := ps.managers[name]; ok {
+		return pm, ps.configs[name], false, nil
+	}
+
+	if !ProfileExists(name) {
+		if _, err := CreateProfile(name); err != nil {
+			return nil, nil, false, err
+		}
+	}
+
+	config, err = LoadProfileConfig(name)
+	pm = NewProxyManager(config)
+	ps.managers[name] = pm
+	ps.configs[name] = config
+
+	return pm, config, true, err
+}
+
+// Activate loads a profile and makes it the active one
+func (ps *ProfileSet) Activate(name string) (*ProxyManager, *Config, bool, error) {
+	pm, config, loaded, err := ps.Load(name)
+	if pm == nil {
+		return nil, nil, false, err
+	}
+
+	ps.mutex.Lock()
+	ps.active = name
+	ps.mutex.Unlock()
+
+	return pm, config, loaded, err
+}
+
+// Active returns the proxy manager and config of the active profile
+func (ps *ProfileSet) Active() (*ProxyManager, *Config) {
+	ps.mutex.RLock()
+	defer ps.mutex.RUnlock()
+	return ps.managers[ps.active], ps.configs[ps.active]
+}
+
+// Managers returns the proxy managers of all loaded profiles
+func (ps *ProfileSet) Managers() []*ProxyManager {
+	ps.mutex.RLock()
+	defer ps.mutex.RUnlock()
+
+	managers := make([]*ProxyManager, 0, len(ps.managers))
+	for _, pm := range ps.managers {
+		managers = append(managers, pm)
+	}
+	return managers
+}
+
+// Unload forgets a profile and closes its proxy manager. The active profile
+// and profiles whose proxy is running or starting stay loaded; Unload reports
+// whether the profile was unloaded.
+func (ps *ProfileSet) Unload(name string) bool {
+	ps.mutex.Lock()
+	defer ps.mutex.Unlock()
+
+	pm, ok := ps.managers[name]
+	if !ok || name == ps.active {
+		return false
+	}
+	if status := pm.GetStatus(); status.Running || status.Starting {
+		return false
+	}
+
+	delete(ps.managers, name)
+	delete(ps.configs, name)
+	pm.Close()
+	return true
+}
+
+// ActiveName returns the name of the active profile
+func (ps *ProfileSet) ActiveName() string {
+	ps.mutex.RLock()
+	defer ps.mutex.RUnlock()
+	return ps.active
+}
+
+// Running returns the names of loaded profiles whose proxy is running
+func (ps *ProfileSet) Running() []string {
+	ps.mutex.RLock()
+	defer ps.mutex.RUnlock()
+
+	var running []string
+	for _, name := range ListProfiles() {
+		if pm, ok := ps.managers[name]; ok && pm.GetStatus().Running {
+			running = append(running, name)
+		}
+	}
+	return running
+}
+
+// CheckPortFree returns an error if another profile's proxy is running on
+// the port the given profile wants to use
+func (ps *ProfileSet) CheckPortFree(name string) error {
+	ps.mutex.RLock()
+	defer ps.mutex.RUnlock()
+
+	config, ok := ps.configs[name]
+	if !ok {
+		return nil
+	}
+
+	for other, pm := range ps.managers {
+		if other == name || !pm.GetStatus().Running {
+			continue
+		}
+		if ps.configs[other].Port == config.Port {
+			return fmt.Errorf("port %d is already used by profile %q", config.Port, other)
+		}
+	}
+	return nil
+}
+
+// StopAll stops the proxies of every loaded profile
+func (ps *ProfileSet) StopAll() {
+	ps.mutex.RLock()
+	defer ps.mutex.RUnlock()
+
+	for _, pm := range ps.managers {
+		if status := pm.GetStatus(); status.Running || status.Starting {
+			pm.Stop()
+		}
+	}
+}
@@ -1,17 +1,16 @@
 package main
 
 import (
-	"archive/tar"
-	"archive/zip"
-	"compress/gzip"
+	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"net/http"
 	"os"
 	"os/exec"
 	"path/filepath"
-	"runtime"
+	"sort"
 	"strings"
 	"sync"
 	"syscall"
@@ -21,7 +20,6 @@ import (
 const (
 	maxLogEntries      = 1000
 	githubRepo         = "router-for-me/CLIProxyAPIPlus"
-	githubAPIURL       = "https://api.github.com/repos/" + githubRepo + "/releases/latest"
 	defaultBinaryName  = "CLIProxyAPI"
 	managementBasePath = "/v0/management"
 )
@@ -31,22 +29,44 @@ type ProxyManager struct {
 	config        *Config
 	status        ProxyStatus
 	process       *exec.Cmd
+	processExited chan struct{} // Closed when the process has exited
 	authFiles     []AuthFile
 	usageStats    UsageStats
 	quotaInfos    []QuotaInfo
+	quotaHistory  *QuotaHistory
+	routedTo      map[AIProvider]routedAccount // Account emulated routing picked, by provider
+	ownWrites     map[string]authFileStamp     // Auth files LazyL2M last rewrote, by name
 	logEntries    []LogEntry
+	usageHistory  *UsageHistory
+	events        *EventBus
+	done          chan struct{} // Closed when the profile is unloaded
+	closeOnce     sync.Once
 	mutex         sync.RWMutex
+	configMutex   sync.RWMutex // Guards config, edited on the event loop
+	accountsMutex sync.Mutex   // Serializes pausing and holding accounts
 
 	// Paths
-	binaryPath    string
-	configPath    string
-	authDir       string
-	managementKey string
+	binaryPath        string // Binary that is run, managed or custom
+	managedBinaryPath string // Link to the active managed install
+	configPath        string
+	authDir           string
+	managementKey     string
 
 	// State
 	isDownloading    bool
 	downloadProgress float64
 	lastError        string
+	installedVersion string
+	latestVersion    string
+	lastUpdateCheck  time.Time
+
+	// Download progress
+	downloadedBytes int64
+	downloadOffset  int64 // Bytes resumed from a partial download
+	downloadTotal   int64
+	downloadStarted time.Time
+	cancelDownload  context.CancelFunc
+	lastProgress    time.Time // When download progress was last published
 }
 
 // NewProxyManager creates a new proxy manager
@@ -54,24 +74,43 @@ func NewProxyManager(config *Config) *ProxyManager {
 	homeDir, _ := os.UserHomeDir()
 	appDir := filepath.Join(homeDir, ".local", "share", "lazyl2m")
 	authDir := filepath.Join(homeDir, ".cli-proxy-api")
+	if config.AuthDir != "" {
+		authDir = config.AuthDir
+	}
+
+	// Each non-default profile keeps its proxy config in its own directory,
+	// while the binary is shared by all profiles
+	profileDir := appDir
+	if config.Profile != "" && config.Profile != defaultProfile {
+		profileDir = filepath.Join(appDir, profilesDir, config.Profile)
+	}
 
 	// Create directories if they don't exist
 	os.MkdirAll(appDir, 0755)
+	os.MkdirAll(profileDir, 0755)
 	os.MkdirAll(authDir, 0755)
 
 	pm := &ProxyManager{
-		config:        config,
-		status:        ProxyStatus{Running: false, Port: config.Port},
-		authFiles:     []AuthFile{},
-		quotaInfos:    []QuotaInfo{},
-		logEntries:    []LogEntry{},
-		usageStats:    UsageStats{},
-		binaryPath:    filepath.Join(appDir, defaultBinaryName),
-		configPath:    filepath.Join(appDir, "config.yaml"),
-		authDir:       authDir,
-		managementKey: generateManagementKey(),
+		config:            config,
+		status:            ProxyStatus{Running: false, Port: config.Port},
+		authFiles:         []AuthFile{},
+		quotaInfos:        []QuotaInfo{},
+		quotaHistory:      NewQuotaHistory(),
+		routedTo:          make(map[AIProvider]routedAccount),
+		ownWrites:         make(map[string]authFileStamp),
+		logEntries:        []LogEntry{},
+		usageStats:        UsageStats{},
+		usageHistory:      NewUsageHistory(profileDir),
+		events:            NewEventBus(),
+		done:              make(chan struct{}),
+		managedBinaryPath: filepath.Join(appDir, defaultBinaryName),
+		configPath:        filepath.Join(profileDir, "config.yaml"),
+		authDir:           authDir,
+		managementKey:     generateManagementKey(),
 	}
 
+	pm.binaryPath = pm.resolveBinaryPath()
+
 	// Ensure config file exists
 	pm.ensureConfigExists()
 
@@ -108,6 +147,14 @@ func (pm *ProxyManager) GetDownloadProgress() float64 {
 	return pm.downloadProgress
 }
 
+// setDownloadProgress records a step of the running install
+func (pm *ProxyManager) setDownloadProgress(progress float64) {
+	pm.mutex.Lock()
+	defer pm.mutex.Unlock()
+	pm.downloadProgress = progress
+	pm.events.Publish(EventDownloadProgress)
+}
+
 // GetLastError returns the last error message
 func (pm *ProxyManager) GetLastError() string {
 	pm.mutex.RLock()
@@ -115,14 +162,57 @@ func (pm *ProxyManager) GetLastError() string {
 	return pm.lastError
 }
 
+// GetProfile returns the name of the profile this manager belongs to
+func (pm *ProxyManager) GetProfile() string {
+	pm.configMutex.RLock()
+	defer pm.configMutex.RUnlock()
+	return pm.config.Profile
+}
+
+// Config returns a copy of the profile's config, safe to read off the event
+// loop while settings are edited
+func (pm *ProxyManager) Config() *Config {
+	pm.configMutex.RLock()
+	defer pm.configMutex.RUnlock()
+	return pm.config.Clone()
+}
+
+// EditConfig changes the profile's config. It is called on the event loop,
+// which reads the config without copying it.
+func (pm *ProxyManager) EditConfig(change func(cfg *Config)) {
+	pm.configMutex.Lock()
+	defer pm.configMutex.Unlock()
+	change(pm.config)
+}
+
+// Done returns a channel that is closed when the profile is unloaded, so
+// background work for it can stop
+func (pm *ProxyManager) Done() <-chan struct{} {
+	return pm.done
+}
+
+// Close marks the profile as unloaded
+func (pm *ProxyManager) Close() {
+	pm.closeOnce.Do(func() {
+		close(pm.done)
+	})
+}
+
 // GetEndpoint returns the API endpoint URL
 func (pm *ProxyManager) GetEndpoint() string {
-	return fmt.Sprintf("http://127.0.0.1:%d/v1", pm.config.Port)
+	return fmt.Sprintf("http://127.0.0.1:%d/v1", pm.port())
 }
 
 // GetManagementURL returns the management API URL
 func (pm *ProxyManager) GetManagementURL() string {
-	return fmt.Sprintf("http://127.0.0.1:%d%s", pm.config.Port, managementBasePath)
+	return fmt.Sprintf("http://127.0.0.1:%d%s", pm.port(), managementBasePath)
+}
+
+// port returns the configured proxy port
+func (pm *ProxyManager) port() int {
+	pm.configMutex.RLock()
+	defer pm.configMutex.RUnlock()
+	return pm.config.Port
 }
 
 // ensureConfigExists creates default config if it doesn't exist
@@ -130,13 +220,19 @@ func (pm *ProxyManager) ensureConfigExists() {
 	if _, err := os.Stat(pm.configPath); err == nil {
 		return
 	}
+	pm.WriteProxyConfig()
+}
 
+// WriteProxyConfig writes the proxy config file from the current settings.
+// A running proxy reloads it.
+func (pm *ProxyManager) WriteProxyConfig() error {
+	cfg := pm.Config()
+	switchProject, switchPreviewModel := cfg.QuotaSwitching()
 	defaultConfig := fmt.Sprintf(`host: "127.0.0.1"
 port: %d
 auth-dir: "%s"
 
-api-keys:
-  - "lazyl2m-local-%d"
+api-keys:%s
 
 remote-management:
   allow-remote: false
@@ -150,66 +246,141 @@ routing:
   strategy: "%s"
 
 quota-exceeded:
-  switch-project: true
-  switch-preview-model: true
+  switch-project: %t
+  switch-preview-model: %t
 
 request-retry: %d
 max-retry-interval: 30
 `,
-		pm.config.Port,
+		cfg.Port,
 		pm.authDir,
-		time.Now().UnixNano(),
+		yamlList(cfg.APIKeys),
 		pm.managementKey,
-		pm.config.DebugMode,
-		pm.config.LogToFile,
-		pm.config.UsageStatsEnabled,
-		pm.config.RoutingStrategy,
-		pm.config.RequestRetryCount,
+		cfg.DebugMode,
+		cfg.LogToFile,
+		cfg.UsageStatsEnabled,
+		cfg.ProxyRoutingStrategy(),
+		switchProject,
+		switchPreviewModel,
+		cfg.RequestRetryCount,
 	)
 
-	os.WriteFile(pm.configPath, []byte(defaultConfig), 0644)
+	return os.WriteFile(pm.configPath, []byte(defaultConfig), 0644)
+}
+
+// yamlList formats values as a YAML block list, or an empty flow list
+func yamlList(values []string) string {
+	if len(values) == 0 {
+		return " []"
+	}
+	var b strings.Builder
+	for _, value := range values {
+		fmt.Fprintf(&b, "\n  - %q", value)
+	}
+	return b.String()
 }
 
 // UpdateConfig updates the config file with current settings
 func (pm *ProxyManager) UpdateConfig() error {
-	os.Remove(pm.configPath)
-	pm.ensureConfigExists()
+	err := pm.WriteProxyConfig()
+
+	// The binary source may have changed; its version is looked up again
+	// only if it did
+	binaryPath := pm.resolveBinaryPath()
+	pm.mutex.Lock()
+	if binaryPath != pm.binaryPath {
+		pm.installedVersion = ""
+	}
+	pm.binaryPath = binaryPath
+	pm.latestVersion = ""
+	pm.lastUpdateCheck = time.Time{}
+	pm.mutex.Unlock()
+	return err
+}
+
+// ApplyAccountRules pauses, resumes, holds and releases accounts for the
+// current quota and routing settings. It rewrites auth files and reloads them
+// from the proxy, so it runs off the event loop.
+func (pm *ProxyManager) ApplyAccountRules() error {
+	// Accounts paused by "stop" or held for routing are released when it
+	// was turned off
+	pm.EnforceQuotaExceeded()
+	pm.EnforceRouting()
 	return nil
 }
 
 // Start starts the proxy server
 func (pm *ProxyManager) Start() error {
+	process, err := pm.launch()
+	if err != nil {
+		return err
+	}
+
+	// Wait for the server to start (check if process is running after a brief
+	// delay). The lock is released meanwhile so the UI can read the state.
+	time.Sleep(1500 * time.Millisecond)
+
 	pm.mutex.Lock()
 	defer pm.mutex.Unlock()
+	pm.status.Starting = false
+	defer pm.events.Publish(EventStatusChanged)
 
-	if pm.status.Running {
-		return fmt.Errorf("proxy server already running")
+	if pm.process == process {
+		// Check if process is still alive
+		if err := process.Process.Signal(syscall.Signal(0)); err == nil {
+			pm.status.Running = true
+			pm.usageHistory.ResetBaseline()
+			pm.AddLog(LogLevelInfo, "Proxy server started successfully")
+			return nil
+		}
+	}
+
+	pm.lastError = "Process failed to start"
+	return fmt.Errorf("failed to start proxy server")
+}
+
+// launch starts the proxy process and the goroutines that read its output and
+// wait for it to exit
+func (pm *ProxyManager) launch() (*exec.Cmd, error) {
+	pm.mutex.Lock()
+	defer pm.mutex.Unlock()
+
+	if pm.status.Running || pm.status.Starting {
+		return nil, fmt.Errorf("proxy server already running")
+	}
+	if pm.status.Stopping {
+		return nil, fmt.Errorf("proxy server is stopping")
 	}
 
 	if !pm.IsBinaryInstalled() {
-		return fmt.Errorf("CLIProxyAPI binary not installed. Please install it first")
+		return nil, fmt.Errorf("CLIProxyAPI binary not installed. Please install it first")
 	}
 
-	pm.AddLog(LogLevelInfo, fmt.Sprintf("Starting proxy server on port %d", pm.config.Port))
+	pm.AddLog(LogLevelInfo, fmt.Sprintf("Starting proxy server on port %d", pm.port()))
 	pm.lastError = ""
+	pm.status.ExitedAt = time.Time{}
 
 	// Create the process
-	pm.process = exec.Command(pm.binaryPath, "-config", pm.configPath)
-	pm.process.Dir = filepath.Dir(pm.binaryPath)
+	process := exec.Command(pm.binaryPath, "-config", pm.configPath)
+	process.Dir = filepath.Dir(pm.configPath)
 
 	// Set up pipes for output
-	stdout, _ := pm.process.StdoutPipe()
-	stderr, _ := pm.process.StderrPipe()
+	stdout, _ := process.StdoutPipe()
+	stderr, _ := process.StderrPipe()
 
 	// Set environment
-	pm.process.Env = append(os.Environ(), "TERM=xterm-256color")
+	process.Env = append(os.Environ(), "TERM=xterm-256color")
 
 	// Start the process
-	if err := pm.process.Start(); err != nil {
+	if err := process.Start(); err != nil {
 		pm.lastError = err.Error()
 		pm.AddLog(LogLevelError, fmt.Sprintf("Failed to start proxy: %v", err))
-		return err
+		return nil, err
 	}
+	pm.process = process
+	pm.processExited = make(chan struct{})
+	pm.status.Starting = true
+	pm.events.Publish(EventStatusChanged)
 
 	// Handle process output in background
 	go func() {
@@ -239,74 +410,82 @@ func (pm *ProxyManager) Start() error {
 	}()
 
 	// Monitor process exit
+	exited := pm.processExited
 	go func() {
-		if pm.process != nil {
-			err := pm.process.Wait()
-			pm.mutex.Lock()
+		err := process.Wait()
+		pm.mutex.Lock()
+		if pm.process == process {
 			pm.status.Running = false
 			pm.process = nil
-			if err != nil {
-				pm.lastError = err.Error()
-				pm.AddLog(LogLevelError, fmt.Sprintf("Proxy exited with error: %v", err))
-			} else {
-				pm.AddLog(LogLevelInfo, "Proxy process exited")
+			if !pm.status.Stopping {
+				pm.status.ExitedAt = time.Now()
 			}
-			pm.mutex.Unlock()
+			pm.events.Publish(EventStatusChanged)
 		}
-	}()
-
-	// Wait for the server to start (check if process is running after a brief delay)
-	time.Sleep(1500 * time.Millisecond)
-
-	if pm.process != nil && pm.process.Process != nil {
-		// Check if process is still alive
-		if err := pm.process.Process.Signal(syscall.Signal(0)); err == nil {
-			pm.status.Running = true
-			pm.AddLog(LogLevelInfo, "Proxy server started successfully")
-			return nil
+		switch {
+		case pm.status.Stopping:
+			// Stop logs the outcome
+		case err != nil:
+			pm.lastError = err.Error()
+			pm.AddLog(LogLevelError, fmt.Sprintf("Proxy exited with error: %v", err))
+		default:
+			pm.AddLog(LogLevelInfo, "Proxy process exited")
 		}
-	}
+		pm.mutex.Unlock()
+		close(exited)
+	}()
 
-	pm.lastError = "Process failed to start"
-	return fmt.Errorf("failed to start proxy server")
+	return process, nil
 }
 
 // Stop stops the proxy server
 func (pm *ProxyManager) Stop() error {
 	pm.mutex.Lock()
-	defer pm.mutex.Unlock()
-
+	// Let a pending start finish so its process isn't left behind
+	for pm.status.Starting {
+		pm.mutex.Unlock()
+		time.Sleep(100 * time.Millisecond)
+		pm.mutex.Lock()
+	}
 	if !pm.status.Running {
+		pm.mutex.Unlock()
 		return fmt.Errorf("proxy server not running")
 	}
-
+	if pm.status.Stopping {
+		pm.mutex.Unlock()
+		return fmt.Errorf("proxy server is already stopping")
+	}
+	pm.status.Stopping = true
+	pm.events.Publish(EventStatusChanged)
+	process, exited := pm.process, pm.processExited
 	pm.AddLog(LogLevelInfo, "Stopping proxy server")
+	pm.mutex.Unlock()
 
-	if pm.process != nil && pm.process.Process != nil {
+	// Wait without the lock so the UI can read the state meanwhile
+	if process != nil && process.Process != nil {
 		// Try graceful termination first
-		pm.process.Process.Signal(syscall.SIGTERM)
-
-		// Wait a bit for graceful shutdown
-		done := make(chan error, 1)
-		go func() {
-			done <- pm.process.Wait()
-		}()
+		process.Process.Signal(syscall.SIGTERM)
 
 		select {
-		case <-done:
+		case <-exited:
 			// Process exited gracefully
 		case <-time.After(2 * time.Second):
 			// Force kill if still running
-			pm.process.Process.Kill()
-			pm.AddLog(LogLevelWarn, "Force killed proxy process")
+			process.Process.Kill()
+			<-exited
+			pm.AddLogExternal(LogLevelWarn, "Force killed proxy process")
 		}
-		pm.process = nil
 	}
 
 	// Also kill any processes on the port (cleanup orphans)
-	pm.killProcessOnPort(pm.config.Port)
+	pm.killProcessOnPort(pm.port())
 
+	pm.mutex.Lock()
+	defer pm.mutex.Unlock()
+	pm.process = nil
 	pm.status.Running = false
+	pm.status.Stopping = false
+	pm.events.Publish(EventStatusChanged)
 	pm.AddLog(LogLevelInfo, "Proxy server stopped")
 
 	return nil
@@ -338,31 +517,22 @@ func (pm *ProxyManager) GetStatus() ProxyStatus {
 
 // FetchAuthFiles fetches authenticated accounts from the management API
 func (pm *ProxyManager) FetchAuthFiles() error {
-	pm.mutex.Lock()
-	defer pm.mutex.Unlock()
-
-	if !pm.status.Running {
+	if !pm.GetStatus().Running {
 		// If server is not running, try to read from auth directory directly
-		pm.authFiles = pm.scanAuthDirectory()
+		pm.setAuthFiles(pm.scanAuthDirectory())
 		return nil
 	}
 
-	url := fmt.Sprintf("%s/auth-files", pm.GetManagementURL())
-
-	client := &http.Client{Timeout: 10 * time.Second}
-	req, _ := http.NewRequest("GET", url, nil)
-	req.Header.Set("X-Management-Key", pm.managementKey)
-
-	resp, err := client.Do(req)
+	resp, err := pm.managementGet("/auth-files")
 	if err != nil {
 		// Fallback to scanning auth directory
-		pm.authFiles = pm.scanAuthDirectory()
+		pm.setAuthFiles(pm.scanAuthDirectory())
 		return nil
 	}
 	defer resp.Body.Close()
 
 	if resp.StatusCode != http.StatusOK {
-		pm.authFiles = pm.scanAuthDirectory()
+		pm.setAuthFiles(pm.scanAuthDirectory())
 		return nil
 	}
 
@@ -376,12 +546,32 @@ func (pm *ProxyManager) FetchAuthFiles() error {
 		return err
 	}
 
-	pm.authFiles = authFiles
-	pm.AddLog(LogLevelDebug, fmt.Sprintf("Fetched %d auth files from API", len(authFiles)))
+	pm.setAuthFiles(authFiles)
+	pm.AddLogExternal(LogLevelDebug, fmt.Sprintf("Fetched %d auth files from API", len(authFiles)))
 
 	return nil
 }
 
+// setAuthFiles replaces the known auth files
+func (pm *ProxyManager) setAuthFiles(authFiles []AuthFile) {
+	pm.mutex.Lock()
+	defer pm.mutex.Unlock()
+	pm.authFiles = authFiles
+	pm.events.Publish(EventAccountsChanged)
+}
+
+// managementGet requests an endpoint of the management API. It is called
+// without holding the lock, so a slow proxy doesn't block readers.
+func (pm *ProxyManager) managementGet(path string) (*http.Response, error) {
+	client := &http.Client{Timeout: 10 * time.Second}
+	req, err := http.NewRequest("GET", pm.GetManagementURL()+path, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("X-Management-Key", pm.managementKey)
+	return client.Do(req)
+}
+
 // scanAuthDirectory reads auth files directly from the auth directory
 func (pm *ProxyManager) scanAuthDirectory() []AuthFile {
 	var authFiles []AuthFile
@@ -409,11 +599,20 @@ func (pm *ProxyManager) scanAuthDirectory() []AuthFile {
 		var authData map[string]interface{}
 		json.Unmarshal(data, &authData)
 
+		status := "active"
+		if disabled, _ := authData["disabled"].(bool); disabled {
+			status = "disabled"
+			_, held := authData[routingHeldKey]
+			if _, paused := authData[pausedUntilKey]; held && !paused {
+				status = "standby"
+			}
+		}
+
 		auth := AuthFile{
 			ID:       entry.Name(),
 			Name:     email,
 			Provider: provider,
-			Status:   "active",
+			Status:   status,
 			Email:    email,
 		}
 
@@ -423,30 +622,88 @@ func (pm *ProxyManager) scanAuthDirectory() []AuthFile {
 	return authFiles
 }
 
+// GetAuthDir returns the directory holding auth files
+func (pm *ProxyManager) GetAuthDir() string {
+	return pm.authDir
+}
+
+// ApplyAuthDirEvents refreshes auth files after changes in the auth directory
+// and logs a line for every account that was added, removed or changed
+func (pm *ProxyManager) ApplyAuthDirEvents(events []AuthDirEvent) {
+	events = pm.dropOwnWrites(events)
+	if len(events) == 0 {
+		return
+	}
+	sort.Slice(events, func(i, j int) bool { return events[i].Name < events[j].Name })
+
+	for _, event := range events {
+		provider, email := pm.parseAuthFileName(event.Name)
+		info := GetProviderInfo(provider)
+
+		switch event.Op {
+		case AuthDirAdded:
+			pm.AddLogExternal(LogLevelInfo, fmt.Sprintf("New %s account %s added", info.Name, email))
+		case AuthDirRemoved:
+			pm.AddLogExternal(LogLevelInfo, fmt.Sprintf("%s account %s removed", info.Name, email))
+		case AuthDirChanged:
+			pm.AddLogExternal(LogLevelInfo, fmt.Sprintf("%s account %s updated", info.Name, email))
+		}
+	}
+
+	pm.FetchAuthFiles()
+}
+
+// dropOwnWrites removes changes of auth files that are still as LazyL2M
+// wrote them when pausing or holding accounts
+func (pm *ProxyManager) dropOwnWrites(events []AuthDirEvent) []AuthDirEvent {
+	pm.mutex.Lock()
+	defer pm.mutex.Unlock()
+
+	kept := events[:0]
+	for _, event := range events {
+		stamp, ok := pm.ownWrites[event.Name]
+		if !ok {
+			kept = append(kept, event)
+			continue
+		}
+		delete(pm.ownWrites, event.Name)
+
+		info, err := os.Stat(filepath.Join(pm.authDir, event.Name))
+		if event.Op != AuthDirChanged || err != nil || !info.ModTime().Equal(stamp.modTime) || info.Size() != stamp.size {
+			kept = append(kept, event)
+		}
+	}
+	return kept
+}
+
 // parseAuthFileName extracts provider and email from auth file name
 func (pm *ProxyManager) parseAuthFileName(filename string) (AIProvider, string) {
 	// Filename format: provider-email.json (e.g., gemini-cli-user@email.com.json)
 	name := strings.TrimSuffix(filename, ".json")
 
-	providers := map[string]AIProvider{
-		"gemini-cli":     ProviderGemini,
-		"gemini":         ProviderGemini,
-		"claude":         ProviderClaude,
-		"codex":          ProviderCodex,
-		"qwen":           ProviderQwen,
-		"iflow":          ProviderIFlow,
-		"antigravity":    ProviderAntigravity,
-		"vertex":         ProviderVertex,
-		"kiro":           ProviderKiro,
-		"github-copilot": ProviderGitHubCopilot,
-		"copilot":        ProviderGitHubCopilot,
-		"cursor":         ProviderCursor,
-	}
-
-	for prefix, prov := range providers {
-		if strings.HasPrefix(name, prefix+"-") {
-			email := strings.TrimPrefix(name, prefix+"-")
-			return prov, email
+	// Longer prefixes come first, so gemini-cli isn't taken for gemini
+	providers := []struct {
+		prefix   string
+		provider AIProvider
+	}{
+		{"gemini-cli", ProviderGemini},
+		{"gemini", ProviderGemini},
+		{"claude", ProviderClaude},
+		{"codex", ProviderCodex},
+		{"qwen", ProviderQwen},
+		{"iflow", ProviderIFlow},
+		{"antigravity", ProviderAntigravity},
+		{"vertex", ProviderVertex},
+		{"kiro", ProviderKiro},
+		{"github-copilot", ProviderGitHubCopilot},
+		{"copilot", ProviderGitHubCopilot},
+		{"cursor", ProviderCursor},
+	}
+
+	for _, p := range providers {
+		if strings.HasPrefix(name, p.prefix+"-") {
+			email := strings.TrimPrefix(name, p.prefix+"-")
+			return p.provider, email
 		}
 	}
 
@@ -455,30 +712,24 @@ func (pm *ProxyManager) parseAuthFileName(filename string) (AIProvider, string)
 
 // FetchUsageStats fetches usage statistics from the management API
 func (pm *ProxyManager) FetchUsageStats() error {
-	pm.mutex.Lock()
-	defer pm.mutex.Unlock()
-
-	if !pm.status.Running {
+	if !pm.GetStatus().Running {
+		pm.mutex.Lock()
 		pm.usageStats = UsageStats{LastUpdated: time.Now()}
+		pm.events.Publish(EventStatsUpdated)
+		pm.mutex.Unlock()
 		return nil
 	}
 
-	url := fmt.Sprintf("%s/usage-statistics", pm.GetManagementURL())
-
-	client := &http.Client{Timeout: 10 * time.Second}
-	req, _ := http.NewRequest("GET", url, nil)
-	req.Header.Set("X-Management-Key", pm.managementKey)
-
-	resp, err := client.Do(req)
+	resp, err := pm.managementGet("/usage-statistics")
 	if err != nil {
 		// Keep last stats with updated timestamp
-		pm.usageStats.LastUpdated = time.Now()
+		pm.touchUsageStats()
 		return nil
 	}
 	defer resp.Body.Close()
 
 	if resp.StatusCode != http.StatusOK {
-		pm.usageStats.LastUpdated = time.Now()
+		pm.touchUsageStats()
 		return nil
 	}
 
@@ -487,18 +738,36 @@ func (pm *ProxyManager) FetchUsageStats() error {
 		return err
 	}
 
+	// The proxy may wrap the statistics in a "usage" object
+	var wrapped struct {
+		Usage *UsageStats `json:"usage"`
+	}
 	var stats UsageStats
-	if err := json.Unmarshal(body, &stats); err != nil {
+	if err := json.Unmarshal(body, &wrapped); err == nil && wrapped.Usage != nil {
+		stats = *wrapped.Usage
+	} else if err := json.Unmarshal(body, &stats); err != nil {
 		return err
 	}
 
 	stats.LastUpdated = time.Now()
+	pm.mutex.Lock()
 	pm.usageStats = stats
+	pm.usageHistory.Record(stats)
+	pm.events.Publish(EventStatsUpdated)
 	pm.AddLog(LogLevelDebug, "Updated usage statistics")
+	pm.mutex.Unlock()
 
 	return nil
 }
 
+// touchUsageStats keeps the last stats but marks them as checked now
+func (pm *ProxyManager) touchUsageStats() {
+	pm.mutex.Lock()
+	defer pm.mutex.Unlock()
+	pm.usageStats.LastUpdated = time.Now()
+	pm.events.Publish(EventStatsUpdated)
+}
+
 // GetAuthFiles returns the current auth files
 func (pm *ProxyManager) GetAuthFiles() []AuthFile {
 	pm.mutex.RLock()
@@ -513,12 +782,29 @@ func (pm *ProxyManager) GetUsageStats() UsageStats {
 	return pm.usageStats
 }
 
+// GetUsageHistory returns the usage time series
+func (pm *ProxyManager) GetUsageHistory() *UsageHistory {
+	return pm.usageHistory
+}
+
+// GetCostEstimate prices the current usage statistics with the configured price table
+func (pm *ProxyManager) GetCostEstimate() CostEstimate {
+	prices := pm.Config().PriceTable()
+	pm.mutex.RLock()
+	defer pm.mutex.RUnlock()
+	return EstimateCosts(pm.usageStats, prices, pm.authFiles)
+}
+
 // GetQuotaInfos returns quota information for all accounts
 func (pm *ProxyManager) GetQuotaInfos() []QuotaInfo {
 	pm.mutex.RLock()
 	defer pm.mutex.RUnlock()
 
-	// Generate quota info from auth files
+	if len(pm.quotaInfos) > 0 {
+		return append([]QuotaInfo(nil), pm.quotaInfos...)
+	}
+
+	// Generate quota info from auth files when the proxy reports none
 	quotas := []QuotaInfo{}
 	for _, auth := range pm.authFiles {
 		// Simulate quota data
@@ -549,6 +835,48 @@ func (pm *ProxyManager) GetQuotaInfos() []QuotaInfo {
 	return quotas
 }
 
+// GetReportedQuotas returns the quotas reported by the proxy, without the
+// estimates GetQuotaInfos falls back to
+func (pm *ProxyManager) GetReportedQuotas() []QuotaInfo {
+	pm.mutex.RLock()
+	defer pm.mutex.RUnlock()
+	return append([]QuotaInfo(nil), pm.quotaInfos...)
+}
+
+// GetQuotaHistory returns the recorded quota usage of an account
+func (pm *ProxyManager) GetQuotaHistory(accountID string) []QuotaSample {
+	return pm.quotaHistory.Samples(accountID)
+}
+
+// AccountHealth counts accounts by whether they can serve requests
+type AccountHealth struct {
+	OK      int
+	Warning int // Close to their quota
+	Failed  int // Expired, failing or out of quota
+}
+
+// GetAccountHealth sorts the accounts by their status and the quota the
+// proxy reports; estimated quotas don't count
+func (pm *ProxyManager) GetAccountHealth() AccountHealth {
+	quotaStatus := make(map[string]string)
+	for _, quota := range pm.GetReportedQuotas() {
+		quotaStatus[filepath.Base(quota.AccountID)] = quota.Status
+	}
+
+	var health AccountHealth
+	for _, auth := range pm.GetAuthFiles() {
+		switch {
+		case (auth.Status != "active" && auth.Status != "standby") || quotaStatus[auth.ID] == "exceeded":
+			health.Failed++
+		case quotaStatus[auth.ID] == "warning":
+			health.Warning++
+		default:
+			health.OK++
+		}
+	}
+	return health
+}
+
 // GetLogs returns all log entries
 func (pm *ProxyManager) GetLogs() []LogEntry {
 	pm.mutex.RLock()
@@ -570,6 +898,7 @@ func (pm *ProxyManager) AddLog(level LogLevel, message string) {
 	if len(pm.logEntries) > maxLogEntries {
 		pm.logEntries = pm.logEntries[len(pm.logEntries)-maxLogEntries:]
 	}
+	pm.events.Publish(EventLogAppended)
 }
 
 // AddLogExternal adds a log entry (external, acquires lock)
@@ -584,6 +913,37 @@ func (pm *ProxyManager) ClearLogs() {
 	pm.mutex.Lock()
 	defer pm.mutex.Unlock()
 	pm.logEntries = []LogEntry{}
+	pm.events.Publish(EventLogAppended)
+}
+
+// ExportLogs writes all log entries to a timestamped file under the config
+// directory and returns its path
+func (pm *ProxyManager) ExportLogs() (string, error) {
+	homeDir, err := os.UserHomeDir()
+	if err != nil {
+		return "", err
+	}
+	dir := filepath.Join(homeDir, configDir, "logs")
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return "", err
+	}
+
+	pm.mutex.RLock()
+	var sb strings.Builder
+	for _, entry := range pm.logEntries {
+		sb.WriteString(fmt.Sprintf("%s %-5s %s\n", entry.Timestamp.Format(time.RFC3339), strings.ToUpper(string(entry.Level)), entry.Message))
+	}
+	pm.mutex.RUnlock()
+	profile := pm.GetProfile()
+
+	if profile == "" {
+		profile = defaultProfile
+	}
+	path := filepath.Join(dir, fmt.Sprintf("lazyl2m-%s-%s.log", profile, time.Now().Format("20060102-150405")))
+	if err := os.WriteFile(path, []byte(sb.String()), 0644); err != nil {
+		return "", err
+	}
+	return path, nil
 }
 
 // Mock data generators for when API is not available
@@ -629,24 +989,54 @@ func (pm *ProxyManager) getMockUsageStats() UsageStats {
 	}
 }
 
-// DownloadAndInstallBinary downloads and installs the CLIProxyAPI binary
+// DownloadAndInstallBinary downloads and installs the CLIProxyAPI binary.
+// It can be interrupted with CancelDownload.
 func (pm *ProxyManager) DownloadAndInstallBinary() error {
+	return pm.installRelease(pm.activateVersion)
+}
+
+// installRelease downloads, verifies and stages the release to install, then
+// hands its version directory to activate to make it the active binary
+func (pm *ProxyManager) installRelease(activate func(dir string) error) error {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
 	pm.mutex.Lock()
+	if pm.isDownloading {
+		pm.mutex.Unlock()
+		return fmt.Errorf("another installation is in progress")
+	}
 	pm.isDownloading = true
 	pm.downloadProgress = 0
+	pm.downloadedBytes = 0
+	pm.downloadOffset = 0
+	pm.downloadTotal = -1
+	pm.downloadStarted = time.Time{}
+	pm.cancelDownload = cancel
 	pm.lastError = ""
+	pm.events.Publish(EventDownloadProgress)
 	pm.mutex.Unlock()
 
 	defer func() {
 		pm.mutex.Lock()
 		pm.isDownloading = false
+		pm.cancelDownload = nil
+		pm.events.Publish(EventDownloadProgress)
 		pm.mutex.Unlock()
 	}()
 
-	pm.AddLogExternal(LogLevelInfo, "Starting CLIProxyAPI download...")
+	if pm.IsCustomBinary() {
+		err := fmt.Errorf("a custom binary is configured (%s); clear Binary Path in Settings to install releases", pm.Config().BinaryPath)
+		pm.mutex.Lock()
+		pm.lastError = err.Error()
+		pm.mutex.Unlock()
+		return err
+	}
+
+	pm.AddLogExternal(LogLevelInfo, fmt.Sprintf("Starting CLIProxyAPI download from %s...", pm.releaseRepo()))
 
-	// Fetch latest release info
-	releaseInfo, err := pm.fetchLatestRelease()
+	// Fetch release info
+	releaseInfo, err := pm.fetchRelease()
 	if err != nil {
 		pm.mutex.Lock()
 		pm.lastError = err.Error()
@@ -655,14 +1045,11 @@ func (pm *ProxyManager) DownloadAndInstallBinary() error {
 		return err
 	}
 
-	pm.mutex.Lock()
-	pm.downloadProgress = 0.1
-	pm.mutex.Unlock()
+	pm.setDownloadProgress(0.1)
 
 	// Find compatible asset
-	asset := pm.findCompatibleAsset(releaseInfo)
-	if asset == nil {
-		err := fmt.Errorf("no compatible binary found for %s/%s", runtime.GOOS, runtime.GOARCH)
+	asset, err := pm.findCompatibleAsset(releaseInfo)
+	if err != nil {
 		pm.mutex.Lock()
 		pm.lastError = err.Error()
 		pm.mutex.Unlock()
@@ -672,52 +1059,95 @@ func (pm *ProxyManager) DownloadAndInstallBinary() error {
 
 	pm.AddLogExternal(LogLevelInfo, fmt.Sprintf("Found asset: %s", asset.Name))
 
-	// Download the asset
-	data, err := pm.downloadAsset(asset.DownloadURL)
+	// Stream the asset to disk, resuming an earlier partial download
+	downloadPath := pm.partialDownloadPath(asset.Name)
+	if err := pm.downloadToFile(ctx, asset.DownloadURL, downloadPath, asset.Size); err != nil {
+		pm.mutex.Lock()
+		pm.lastError = err.Error()
+		pm.mutex.Unlock()
+		if errors.Is(err, errDownloadCanceled) {
+			pm.AddLogExternal(LogLevelWarn, "Download canceled. Installing again resumes it")
+		} else {
+			pm.AddLogExternal(LogLevelError, fmt.Sprintf("Failed to download: %v", err))
+		}
+		return err
+	}
+
+	pm.setDownloadProgress(0.6)
+
+	// Verify the download before anything is installed
+	checksum, err := fileSHA256(downloadPath)
+	if err == nil {
+		err = pm.verifyAsset(releaseInfo, asset, checksum)
+	}
 	if err != nil {
+		// A corrupt partial file must not be resumed
+		os.Remove(downloadPath)
 		pm.mutex.Lock()
 		pm.lastError = err.Error()
 		pm.mutex.Unlock()
-		pm.AddLogExternal(LogLevelError, fmt.Sprintf("Failed to download: %v", err))
+		pm.AddLogExternal(LogLevelError, fmt.Sprintf("Verification failed: %v", err))
 		return err
 	}
 
-	pm.mutex.Lock()
-	pm.downloadProgress = 0.7
-	pm.mutex.Unlock()
+	if ctx.Err() != nil {
+		pm.mutex.Lock()
+		pm.lastError = errDownloadCanceled.Error()
+		pm.mutex.Unlock()
+		pm.AddLogExternal(LogLevelWarn, "Installation canceled")
+		return errDownloadCanceled
+	}
+
+	pm.setDownloadProgress(0.7)
 
-	// Extract and install
-	if err := pm.extractAndInstall(data, asset.Name); err != nil {
+	// Extract and stage it next to the active binary
+	dir, err := pm.extractAndStage(downloadPath, asset.Name, releaseInfo.TagName)
+	os.Remove(downloadPath)
+	if err == nil {
+		// Record the release so updates can be detected
+		if err := writeManifest(dir, releaseInfo.TagName, pm.releaseRepo(), asset.Name, checksum); err != nil {
+			pm.AddLogExternal(LogLevelWarn, fmt.Sprintf("Failed to record installed version: %v", err))
+		}
+		if err = activate(dir); err != nil {
+			os.RemoveAll(dir)
+		}
+	}
+	if err != nil {
 		pm.mutex.Lock()
 		pm.lastError = err.Error()
 		pm.mutex.Unlock()
 		pm.AddLogExternal(LogLevelError, fmt.Sprintf("Failed to install: %v", err))
 		return err
 	}
+	pm.AddLogExternal(LogLevelInfo, fmt.Sprintf("Binary installed to %s", dir))
+	pm.PruneVersions()
 
-	pm.mutex.Lock()
-	pm.downloadProgress = 1.0
-	pm.mutex.Unlock()
+	pm.setDownloadProgress(1.0)
 
-	pm.AddLogExternal(LogLevelInfo, "CLIProxyAPI installed successfully!")
+	pm.AddLogExternal(LogLevelInfo, fmt.Sprintf("CLIProxyAPI %s installed successfully!", releaseInfo.TagName))
 	return nil
 }
 
 // GitHub release structures
 type releaseInfo struct {
-	TagName string       `json:"tag_name"`
-	Assets  []assetInfo  `json:"assets"`
+	TagName    string      `json:"tag_name"`
+	Draft      bool        `json:"draft"`
+	Prerelease bool        `json:"prerelease"`
+	Assets     []assetInfo `json:"assets"`
 }
 
 type assetInfo struct {
 	Name        string `json:"name"`
 	DownloadURL string `json:"browser_download_url"`
+	Size        int64  `json:"size"` // 0 if the release doesn't list it
 }
 
-// fetchLatestRelease fetches the latest release info from GitHub
-func (pm *ProxyManager) fetchLatestRelease() (*releaseInfo, error) {
+// fetchRelease fetches info of the release to install from GitHub, following
+// the configured repo, channel and pinned version
+func (pm *ProxyManager) fetchRelease() (*releaseInfo, error) {
+	releaseURL := pm.releaseURL()
 	client := &http.Client{Timeout: 30 * time.Second}
-	req, _ := http.NewRequest("GET", githubAPIURL, nil)
+	req, _ := http.NewRequest("GET", releaseURL, nil)
 	req.Header.Set("User-Agent", "LazyL2M/1.0")
 	req.Header.Set("Accept", "application/vnd.github.v3+json")
 
@@ -728,7 +1158,7 @@ func (pm *ProxyManager) fetchLatestRelease() (*releaseInfo, error) {
 	defer resp.Body.Close()
 
 	if resp.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("GitHub API returned status %d", resp.StatusCode)
+		return nil, fmt.Errorf("release API returned status %d", resp.StatusCode)
 	}
 
 	body, err := io.ReadAll(resp.Body)
@@ -736,63 +1166,31 @@ func (pm *ProxyManager) fetchLatestRelease() (*releaseInfo, error) {
 		return nil, err
 	}
 
+	// The prerelease channel lists releases, newest first
+	if strings.HasPrefix(strings.TrimSpace(string(body)), "[") {
+		var releases []releaseInfo
+		if err := json.Unmarshal(body, &releases); err != nil {
+			return nil, err
+		}
+		for i := range releases {
+			if !releases[i].Draft {
+				resolveAssetURLs(&releases[i], releaseURL)
+				return &releases[i], nil
+			}
+		}
+		return nil, fmt.Errorf("no releases found in %s", pm.releaseRepo())
+	}
+
 	var release releaseInfo
 	if err := json.Unmarshal(body, &release); err != nil {
 		return nil, err
 	}
+	resolveAssetURLs(&release, releaseURL)
 
 	return &release, nil
 }
 
-// findCompatibleAsset finds a compatible binary asset for the current platform
-func (pm *ProxyManager) findCompatibleAsset(release *releaseInfo) *assetInfo {
-	// Determine target platform
-	var arch string
-	switch runtime.GOARCH {
-	case "amd64":
-		arch = "amd64"
-	case "arm64":
-		arch = "arm64"
-	default:
-		arch = runtime.GOARCH
-	}
-
-	platform := runtime.GOOS
-	targetPattern := fmt.Sprintf("%s_%s", platform, arch)
-
-	skipPatterns := []string{"windows", "linux", "checksum", ".sha256", ".md5"}
-	if platform != "linux" {
-		skipPatterns = append(skipPatterns, "linux")
-	}
-	if platform != "darwin" {
-		skipPatterns = append(skipPatterns, "darwin")
-	}
-
-	for _, asset := range release.Assets {
-		name := strings.ToLower(asset.Name)
-
-		// Skip non-matching platforms
-		shouldSkip := false
-		for _, skip := range skipPatterns {
-			if strings.Contains(name, skip) && !strings.Contains(targetPattern, skip) {
-				shouldSkip = true
-				break
-			}
-		}
-		if shouldSkip {
-			continue
-		}
-
-		// Check for target pattern
-		if strings.Contains(name, targetPattern) {
-			return &asset
-		}
-	}
-
-	return nil
-}
-
-// downloadAsset downloads an asset from the given URL
+// downloadAsset downloads a small asset, such as a checksums file, into memory
 func (pm *ProxyManager) downloadAsset(url string) ([]byte, error) {
 	client := &http.Client{Timeout: 5 * time.Minute}
 	req, _ := http.NewRequest("GET", url, nil)
@@ -813,198 +1211,58 @@ func (pm *ProxyManager) downloadAsset(url string) ([]byte, error) {
 		return nil, err
 	}
 
-	pm.mutex.Lock()
-	pm.downloadProgress = 0.6
-	pm.mutex.Unlock()
-
 	return data, nil
 }
 
-// extractAndInstall extracts and installs the binary
-func (pm *ProxyManager) extractAndInstall(data []byte, assetName string) error {
+// extractAndStage extracts the downloaded asset and stages the binary as the
+// given version, returning its version directory
+func (pm *ProxyManager) extractAndStage(downloadedFile, assetName, version string) (string, error) {
 	tempDir, err := os.MkdirTemp("", "lazyl2m-install-*")
-	if err != nil {
-		return err
-	}
-	defer os.RemoveAll(tempDir)
-
-	downloadedFile := filepath.Join(tempDir, assetName)
-	if err := os.WriteFile(downloadedFile, data, 0644); err != nil {
-		return err
-	}
-
-	var binaryPath string
-
-	if strings.HasSuffix(assetName, ".tar.gz") || strings.HasSuffix(assetName, ".tgz") {
-		// Extract tar.gz
-		binaryPath, err = pm.extractTarGz(downloadedFile, tempDir)
-		if err != nil {
-			return err
-		}
-	} else if strings.HasSuffix(assetName, ".zip") {
-		// Extract zip
-		binaryPath, err = pm.extractZip(downloadedFile, tempDir)
-		if err != nil {
-			return err
-		}
-	} else {
-		// Assume it's the binary itself
-		binaryPath = downloadedFile
-	}
-
-	if binaryPath == "" {
-		return fmt.Errorf("could not find binary in archive")
-	}
-
-	// Copy to final destination
-	binaryData, err := os.ReadFile(binaryPath)
-	if err != nil {
-		return err
-	}
-
-	// Remove old binary if exists
-	os.Remove(pm.binaryPath)
-
-	// Write new binary
-	if err := os.WriteFile(pm.binaryPath, binaryData, 0755); err != nil {
-		return err
-	}
-
-	// Make executable
-	os.Chmod(pm.binaryPath, 0755)
-
-	pm.AddLogExternal(LogLevelInfo, fmt.Sprintf("Binary installed to %s", pm.binaryPath))
-	return nil
-}
-
-// extractTarGz extracts a tar.gz archive and returns the path to the binary
-func (pm *ProxyManager) extractTarGz(archivePath, destDir string) (string, error) {
-	file, err := os.Open(archivePath)
-	if err != nil {
-		return "", err
-	}
-	defer file.Close()
-
-	gzr, err := gzip.NewReader(file)
-	if err != nil {
-		return "", err
-	}
-	defer gzr.Close()
-
-	tr := tar.NewReader(gzr)
-
-	var binaryPath string
-	binaryNames := []string{"CLIProxyAPI", "cli-proxy-api", "cli-proxy-api-plus", "proxy"}
-
-	for {
-		header, err := tr.Next()
-		if err == io.EOF {
-			break
-		}
-		if err != nil {
-			return "", err
-		}
-
-		target := filepath.Join(destDir, header.Name)
-
-		switch header.Typeflag {
-		case tar.TypeDir:
-			os.MkdirAll(target, 0755)
-		case tar.TypeReg:
-			outFile, err := os.Create(target)
-			if err != nil {
-				return "", err
-			}
-			io.Copy(outFile, tr)
-			outFile.Close()
-
-			// Check if this is the binary
-			baseName := filepath.Base(header.Name)
-			for _, name := range binaryNames {
-				if strings.EqualFold(baseName, name) {
-					binaryPath = target
-					break
-				}
-			}
-
-			// If no match by name, check if it's executable
-			if binaryPath == "" && header.Mode&0111 != 0 && !strings.HasSuffix(baseName, ".sh") {
-				binaryPath = target
-			}
-		}
-	}
-
-	return binaryPath, nil
-}
-
-// extractZip extracts a zip archive and returns the path to the binary
-func (pm *ProxyManager) extractZip(archivePath, destDir string) (string, error) {
-	r, err := zip.OpenReader(archivePath)
 	if err != nil {
 		return "", err
 	}
-	defer r.Close()
-
-	var binaryPath string
-	binaryNames := []string{"CLIProxyAPI", "cli-proxy-api", "cli-proxy-api-plus", "proxy"}
+	defer os.RemoveAll(tempDir)
 
-	for _, f := range r.File {
-		target := filepath.Join(destDir, f.Name)
+	var files []extractedFile
+	lowerName := strings.ToLower(assetName)
 
-		if f.FileInfo().IsDir() {
-			os.MkdirAll(target, 0755)
-			continue
+	binary := extractedFile{Name: assetName, Path: downloadedFile}
+	reason := "asset is the binary"
+	if strings.HasSuffix(lowerName, ".tar.gz") || strings.HasSuffix(lowerName, ".tgz") || strings.HasSuffix(lowerName, ".zip") {
+		if strings.HasSuffix(lowerName, ".zip") {
+			files, err = extractZip(downloadedFile, tempDir)
+		} else {
+			files, err = extractTarGz(downloadedFile, tempDir)
 		}
-
-		rc, err := f.Open()
 		if err != nil {
-			return "", err
+			return "", fmt.Errorf("failed to extract %s: %w", assetName, err)
 		}
 
-		outFile, err := os.Create(target)
+		binary, reason, err = selectBinary(files)
 		if err != nil {
-			rc.Close()
 			return "", err
 		}
-
-		io.Copy(outFile, rc)
-		outFile.Close()
-		rc.Close()
-
-		// Check if this is the binary
-		baseName := filepath.Base(f.Name)
-		for _, name := range binaryNames {
-			if strings.EqualFold(baseName, name) {
-				binaryPath = target
-				break
-			}
-		}
-
-		// If no match by name, check if it might be executable
-		if binaryPath == "" && f.Mode()&0111 != 0 && !strings.HasSuffix(baseName, ".sh") {
-			binaryPath = target
-		}
 	}
+	pm.AddLogExternal(LogLevelInfo, fmt.Sprintf("Selected %s from %s (%s)", binary.Name, assetName, reason))
 
-	return binaryPath, nil
+	// Copy to its own version directory
+	return pm.stageVersion(binary.Path, version)
 }
 
 // FetchQuotaInfo fetches quota info from the management API
 func (pm *ProxyManager) FetchQuotaInfo() error {
-	pm.mutex.Lock()
-	defer pm.mutex.Unlock()
-
-	if !pm.status.Running {
+	if !pm.GetStatus().Running {
 		return nil
 	}
+	// Only reported usage is sampled, but accounts are paused or switched
+	// even when the proxy reports none
+	defer func() {
+		pm.quotaHistory.Record(pm.GetReportedQuotas())
+		pm.EnforceQuotaExceeded()
+		pm.EnforceRouting()
+	}()
 
-	url := fmt.Sprintf("%s/quotas", pm.GetManagementURL())
-
-	client := &http.Client{Timeout: 10 * time.Second}
-	req, _ := http.NewRequest("GET", url, nil)
-	req.Header.Set("X-Management-Key", pm.managementKey)
-
-	resp, err := client.Do(req)
+	resp, err := pm.managementGet("/quotas")
 	if err != nil {
 		return nil
 	}
@@ -1024,6 +1282,9 @@ func (pm *ProxyManager) FetchQuotaInfo() error {
 		return err
 	}
 
+	pm.mutex.Lock()
 	pm.quotaInfos = quotas
+	pm.events.Publish(EventAccountsChanged)
+	pm.mutex.Unlock()
 	return nil
 }
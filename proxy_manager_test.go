@@ -0,0 +1,43 @@
+package main
+
+import (
+	"os"
+	"strings"
+	"testing"
+)
+
+func TestWriteProxyConfigAPIKeys(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	pm := NewProxyManager(&Config{Port: 8317, APIKeys: []string{"sk-first", "sk-second"}})
+
+	read := func() string {
+		t.Helper()
+		data, err := os.ReadFile(pm.configPath)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return string(data)
+	}
+
+	if got, want := read(), "api-keys:\n  - \"sk-first\"\n  - \"sk-second\"\n"; !strings.Contains(got, want) {
+		t.Fatalf("config missing the profile keys:\n%s", got)
+	}
+
+	// Rewriting keeps the same keys rather than adding new ones
+	if err := pm.WriteProxyConfig(); err != nil {
+		t.Fatal(err)
+	}
+	if got := read(); strings.Count(got, "  - ") != 2 {
+		t.Fatalf("config keys changed on rewrite:\n%s", got)
+	}
+
+	pm.EditConfig(func(cfg *Config) {
+		cfg.APIKeys = nil
+	})
+	if err := pm.WriteProxyConfig(); err != nil {
+		t.Fatal(err)
+	}
+	if got := read(); !strings.Contains(got, "api-keys: []\n") {
+		t.Fatalf("config without keys:\n%s", got)
+	}
+}
@@ -0,0 +1,311 @@
+package main
+
+import (
+	"cmp"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	quotaHistoryRetention = 24 * time.Hour
+	quotaBucketSize       = time.Minute
+	quotaSparklineWidth   = 48 // Samples shown in the quota details
+
+	// Set in the auth files of accounts paused for their quota, so only
+	// those are resumed. Holds the reset time, or "" when it is unknown.
+	pausedUntilKey = "lazyl2m_paused_until"
+)
+
+// QuotaSortColumn is a column the quota table can be sorted by
+type QuotaSortColumn string
+
+const (
+	QuotaSortByUsage    QuotaSortColumn = "usage"
+	QuotaSortByReset    QuotaSortColumn = "reset time"
+	QuotaSortByProvider QuotaSortColumn = "provider"
+)
+
+// QuotaSortColumns returns all quota sort columns in display order
+func QuotaSortColumns() []QuotaSortColumn {
+	return []QuotaSortColumn{QuotaSortByUsage, QuotaSortByReset, QuotaSortByProvider}
+}
+
+// SortQuotas sorts quotas by a column; descending puts the largest, latest
+// or last provider first. Quotas without a reset time come last either way.
+// Ties are ordered by account name.
+func SortQuotas(quotas []QuotaInfo, column QuotaSortColumn, descending bool) {
+	compare := func(a, b QuotaInfo) int {
+		switch column {
+		case QuotaSortByUsage:
+			return cmp.Compare(a.UsagePercent, b.UsagePercent)
+		case QuotaSortByReset:
+			return a.ResetTime.Compare(*b.ResetTime)
+		default:
+			return cmp.Compare(GetProviderInfo(a.Provider).Name, GetProviderInfo(b.Provider).Name)
+		}
+	}
+
+	sort.SliceStable(quotas, func(i, j int) bool {
+		a, b := quotas[i], quotas[j]
+		if column == QuotaSortByReset && (a.ResetTime == nil || b.ResetTime == nil) {
+			return a.ResetTime != nil && b.ResetTime == nil
+		}
+		c := compare(a, b)
+		if descending {
+			c = -c
+		}
+		if c == 0 {
+			return strings.ToLower(a.AccountName) < strings.ToLower(b.AccountName)
+		}
+		return c < 0
+	})
+}
+
+// FilterQuotas returns the quotas whose provider or account contains query,
+// ignoring case. problemsOnly keeps only quotas in warning or exceeded state.
+func FilterQuotas(quotas []QuotaInfo, query string, problemsOnly bool) []QuotaInfo {
+	query = strings.ToLower(strings.TrimSpace(query))
+
+	var filtered []QuotaInfo
+	for _, quota := range quotas {
+		if problemsOnly && quota.Status != "warning" && quota.Status != "exceeded" {
+			continue
+		}
+		if query != "" {
+			text := strings.ToLower(strings.Join([]string{
+				string(quota.Provider),
+				GetProviderInfo(quota.Provider).Name,
+				quota.AccountName,
+				quota.AccountID,
+			}, " "))
+			if !strings.Contains(text, query) {
+				continue
+			}
+		}
+		filtered = append(filtered, quota)
+	}
+	return filtered
+}
+
+// QuotaSample is the quota usage of an account at one point in time
+type QuotaSample struct {
+	Timestamp    time.Time
+	Used         int
+	Limit        int
+	UsagePercent float64
+}
+
+// QuotaHistory keeps the quota usage of each account over the last day, one
+// sample per minute. It is kept in memory only.
+type QuotaHistory struct {
+	samples map[string][]QuotaSample // By account ID, oldest first
+	mutex   sync.RWMutex
+}
+
+// NewQuotaHistory creates an empty quota history
+func NewQuotaHistory() *QuotaHistory {
+	return &QuotaHistory{samples: make(map[string][]QuotaSample)}
+}
+
+// Record adds the current usage of quotas; a newer sample in the same minute
+// replaces the older one
+func (h *QuotaHistory) Record(quotas []QuotaInfo) {
+	h.mutex.Lock()
+	defer h.mutex.Unlock()
+
+	now := time.Now().Truncate(quotaBucketSize)
+	cutoff := now.Add(-quotaHistoryRetention)
+	for _, quota := range quotas {
+		sample := QuotaSample{Timestamp: now, Used: quota.Used, Limit: quota.Limit, UsagePercent: quota.UsagePercent}
+
+		samples := h.samples[quota.AccountID]
+		if n := len(samples); n > 0 && samples[n-1].Timestamp.Equal(now) {
+			samples[n-1] = sample
+		} else {
+			samples = append(samples, sample)
+		}
+
+		i := 0
+		for i < len(samples) && samples[i].Timestamp.Before(cutoff) {
+			i++
+		}
+		h.samples[quota.AccountID] = samples[i:]
+	}
+}
+
+// Samples returns the recorded usage of an account, oldest first
+func (h *QuotaHistory) Samples(accountID string) []QuotaSample {
+	h.mutex.RLock()
+	defer h.mutex.RUnlock()
+	return append([]QuotaSample(nil), h.samples[accountID]...)
+}
+
+// QuotaSwitching returns the proxy's quota-exceeded options for the
+// configured behavior. Only "skip" lets the proxy switch; "stop" pauses the
+// account instead and "continue" keeps using it.
+func (c *Config) QuotaSwitching() (switchProject, switchPreviewModel bool) {
+	if c.QuotaExceededBehavior != QuotaExceededSkip && c.QuotaExceededBehavior != "" {
+		return false, false
+	}
+	return c.QuotaSwitchProject == nil || *c.QuotaSwitchProject,
+		c.QuotaSwitchPreview == nil || *c.QuotaSwitchPreview
+}
+
+// quotaExhausted reports whether an account has used all of its quota
+func quotaExhausted(quota QuotaInfo) bool {
+	return quota.UsagePercent >= 100 || (quota.Limit > 0 && quota.Used >= quota.Limit)
+}
+
+// EnforceQuotaExceeded pauses accounts whose quota the proxy reports as
+// exhausted when the behavior is "stop". Accounts it paused are resumed once
+// their quota resets, the proxy reports quota left, or the behavior changes.
+// Accounts are paused by disabling their auth file, which the proxy reloads.
+func (pm *ProxyManager) EnforceQuotaExceeded() {
+	pm.accountsMutex.Lock()
+	defer pm.accountsMutex.Unlock()
+
+	stop := pm.Config().QuotaExceededBehavior == QuotaExceededStop
+
+	quotas := make(map[string]QuotaInfo)
+	for _, quota := range pm.GetReportedQuotas() {
+		quotas[filepath.Base(quota.AccountID)] = quota
+	}
+
+	entries, err := os.ReadDir(pm.authDir)
+	if err != nil {
+		return
+	}
+
+	changed := false
+	now := time.Now()
+	for _, entry := range entries {
+		if entry.IsDir() || !strings.HasSuffix(entry.Name(), ".json") {
+			continue
+		}
+		path := filepath.Join(pm.authDir, entry.Name())
+		fields, err := readAuthFields(path)
+		if err != nil {
+			continue
+		}
+		quota, reported := quotas[entry.Name()]
+
+		if raw, paused := fields[pausedUntilKey]; paused {
+			var until time.Time
+			var text string
+			if json.Unmarshal(raw, &text) == nil && text != "" {
+				until, _ = time.Parse(time.RFC3339, text)
+			}
+			reset := !until.IsZero() && !now.Before(until)
+			if stop && !reset && (!reported || quotaExhausted(quota)) {
+				continue
+			}
+
+			delete(fields, pausedUntilKey)
+			updateDisabled(fields)
+			if err := pm.writeAuthFile(path, fields); err != nil {
+				pm.AddLogExternal(LogLevelError, fmt.Sprintf("Failed to resume account %s: %v", entry.Name(), err))
+				continue
+			}
+			pm.AddLogExternal(LogLevelInfo, fmt.Sprintf("Resumed account %s", entry.Name()))
+			changed = true
+			continue
+		}
+
+		// Accounts disabled by the user are left alone
+		if !stop || !reported || !quotaExhausted(quota) || userDisabled(fields) {
+			continue
+		}
+
+		// Without a future reset time the account waits for the proxy to
+		// report quota left, so stale data doesn't resume it right away
+		until, untilText := "", "until the proxy reports quota left"
+		if quota.ResetTime != nil && quota.ResetTime.After(now) {
+			until = quota.ResetTime.Format(time.RFC3339)
+			untilText = "until " + quota.ResetTime.Format("Jan 02 15:04")
+		}
+		fields[pausedUntilKey], _ = json.Marshal(until)
+		updateDisabled(fields)
+		if err := pm.writeAuthFile(path, fields); err != nil {
+			pm.AddLogExternal(LogLevelError, fmt.Sprintf("Failed to pause account %s: %v", entry.Name(), err))
+			continue
+		}
+		pm.AddLogExternal(LogLevelWarn, fmt.Sprintf("Paused account %s: quota exhausted, %s", entry.Name(), untilText))
+		changed = true
+	}
+
+	if changed {
+		pm.FetchAuthFiles()
+	}
+}
+
+// readAuthFields reads the top-level fields of an auth file
+func readAuthFields(path string) (map[string]json.RawMessage, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	fields := make(map[string]json.RawMessage)
+	if err := json.Unmarshal(data, &fields); err != nil {
+		return nil, err
+	}
+	return fields, nil
+}
+
+// writeAuthFields replaces an auth file, keeping its permissions
+func writeAuthFields(path string, fields map[string]json.RawMessage) error {
+	data, err := json.MarshalIndent(fields, "", "  ")
+	if err != nil {
+		return err
+	}
+	info, err := os.Stat(path)
+	if err != nil {
+		return err
+	}
+
+	tmpPath := path + ".tmp"
+	if err := os.WriteFile(tmpPath, data, info.Mode().Perm()); err != nil {
+		return err
+	}
+	return os.Rename(tmpPath, path)
+}
+
+// writeAuthFile replaces an auth file and remembers the write, so the auth
+// directory watcher doesn't report it as a change by someone else
+func (pm *ProxyManager) writeAuthFile(path string, fields map[string]json.RawMessage) error {
+	if err := writeAuthFields(path, fields); err != nil {
+		return err
+	}
+	if info, err := os.Stat(path); err == nil {
+		pm.mutex.Lock()
+		pm.ownWrites[filepath.Base(path)] = authFileStamp{modTime: info.ModTime(), size: info.Size()}
+		pm.mutex.Unlock()
+	}
+	return nil
+}
+
+// userDisabled reports whether an auth file was disabled other than by
+// LazyL2M pausing or holding it
+func userDisabled(fields map[string]json.RawMessage) bool {
+	var disabled bool
+	json.Unmarshal(fields["disabled"], &disabled)
+	_, paused := fields[pausedUntilKey]
+	_, held := fields[routingHeldKey]
+	return disabled && !paused && !held
+}
+
+// updateDisabled disables an auth file while LazyL2M pauses or holds it
+func updateDisabled(fields map[string]json.RawMessage) {
+	_, paused := fields[pausedUntilKey]
+	_, held := fields[routingHeldKey]
+	if paused || held {
+		fields["disabled"] = json.RawMessage("true")
+	} else {
+		delete(fields, "disabled")
+	}
+}
@@ -0,0 +1,154 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+const githubAPIBase = "https://api.github.com/repos/"
+
+// repoPattern matches GitHub "owner/name" repository names
+var repoPattern = regexp.MustCompile(`^[A-Za-z0-9_.-]+/[A-Za-z0-9_.-]+$`)
+
+// ValidateRepo checks that a release repository is in owner/name form
+func ValidateRepo(repo string) error {
+	if repo == "" || repoPattern.MatchString(repo) {
+		return nil
+	}
+	return fmt.Errorf("release repo must be in owner/name form, got %q", repo)
+}
+
+// releaseRepo returns the repository releases are installed from
+func (pm *ProxyManager) releaseRepo() string {
+	if repo := strings.TrimSpace(pm.Config().ReleaseRepo); repo != "" {
+		return repo
+	}
+	return githubRepo
+}
+
+// ValidateMirror checks that a release mirror is an http(s) URL
+func ValidateMirror(mirror string) error {
+	if mirror == "" {
+		return nil
+	}
+	u, err := url.Parse(mirror)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return fmt.Errorf("release mirror must be an http(s) URL, got %q", mirror)
+	}
+	return nil
+}
+
+// releaseURL returns the API URL of the release to install: the pinned tag,
+// the newest release including prereleases, or the latest one. A mirror
+// replaces https://api.github.com and must serve the same paths.
+func (pm *ProxyManager) releaseURL() string {
+	cfg := pm.Config()
+	apiBase := githubAPIBase
+	if mirror := strings.TrimSpace(cfg.ReleaseMirror); mirror != "" {
+		apiBase = strings.TrimRight(mirror, "/") + "/repos/"
+	}
+
+	base := apiBase + pm.releaseRepo() + "/releases"
+	if tag := strings.TrimSpace(cfg.PinnedVersion); tag != "" {
+		return base + "/tags/" + url.PathEscape(tag)
+	}
+	if cfg.ReleaseChannel == ReleaseChannelPrerelease {
+		return base + "?per_page=20"
+	}
+	return base + "/latest"
+}
+
+// resolveAssetURLs makes asset URLs relative to the release URL absolute, so
+// a static mirror can list assets next to its release JSON
+func resolveAssetURLs(release *releaseInfo, releaseURL string) {
+	base, err := url.Parse(releaseURL)
+	if err != nil {
+		return
+	}
+	for i := range release.Assets {
+		if ref, err := url.Parse(release.Assets[i].DownloadURL); err == nil {
+			release.Assets[i].DownloadURL = base.ResolveReference(ref).String()
+		}
+	}
+}
+
+// IsCustomBinary reports whether a binary outside LazyL2M's managed installs
+// is configured
+func (pm *ProxyManager) IsCustomBinary() bool {
+	return strings.TrimSpace(pm.Config().BinaryPath) != ""
+}
+
+// resolveBinaryPath returns the binary to run: the configured custom binary
+// or the managed install
+func (pm *ProxyManager) resolveBinaryPath() string {
+	custom := strings.TrimSpace(pm.Config().BinaryPath)
+	if custom == "" {
+		return pm.managedBinaryPath
+	}
+	if resolved, err := resolveCustomBinary(custom); err == nil {
+		return resolved
+	}
+	// Keep the configured path so the binary shows as not installed
+	return custom
+}
+
+// resolveCustomBinary turns a configured binary into an absolute path. A bare
+// command name is looked up in PATH.
+func resolveCustomBinary(path string) (string, error) {
+	path = expandHome(path)
+
+	if !strings.ContainsRune(path, os.PathSeparator) {
+		return exec.LookPath(path)
+	}
+
+	path, err := filepath.Abs(path)
+	if err != nil {
+		return "", err
+	}
+	info, err := os.Stat(path)
+	if err != nil {
+		return "", err
+	}
+	if info.IsDir() {
+		return "", fmt.Errorf("%s is a directory", path)
+	}
+	if info.Mode()&0111 == 0 {
+		return "", fmt.Errorf("%s is not executable", path)
+	}
+	return path, nil
+}
+
+// expandHome replaces a leading "~/" with the home directory
+func expandHome(path string) string {
+	if rest, ok := strings.CutPrefix(path, "~/"); ok {
+		homeDir, _ := os.UserHomeDir()
+		return filepath.Join(homeDir, rest)
+	}
+	return path
+}
+
+// ValidateBinary checks a custom binary by running it with --version and
+// returns its resolved path and version
+func ValidateBinary(path string) (string, string, error) {
+	resolved, err := resolveCustomBinary(path)
+	if err != nil {
+		return "", "", err
+	}
+
+	version, err := probeBinaryVersion(resolved)
+	if err != nil {
+		// The binary ran but doesn't report a version
+		var exitErr *exec.ExitError
+		if errors.As(err, &exitErr) || errors.Is(err, errNoVersion) {
+			return resolved, "unknown", nil
+		}
+		return "", "", fmt.Errorf("failed to run %s: %w", resolved, err)
+	}
+	return resolved, version, nil
+}
@@ -0,0 +1,314 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+)
+
+const (
+	// Set in the auth files of accounts held back by an emulated routing
+	// strategy, so only those are released again
+	routingHeldKey = "lazyl2m_routing_held"
+
+	routingMinSwitchInterval = time.Minute // Least-used and weighted keep an account at least this long
+	leastUsedSwitchMargin    = 5           // Usage percent another account must be below the current one
+)
+
+// routedAccount is the account emulated routing sends a provider's requests to
+type routedAccount struct {
+	Account string
+	Since   time.Time
+}
+
+// RoutingStrategyName returns the display name of a strategy
+func RoutingStrategyName(strategy RoutingStrategy) string {
+	switch strategy {
+	case RoutingFillFirst:
+		return "Fill First"
+	case RoutingLeastUsed:
+		return "Least Used Quota"
+	case RoutingWeighted:
+		return "Weighted"
+	case RoutingPriority:
+		return "Priority"
+	default:
+		return "Round Robin"
+	}
+}
+
+// StrategyFor returns the routing strategy of a provider
+func (c *Config) StrategyFor(provider AIProvider) RoutingStrategy {
+	if strategy := c.RoutingOverrides[provider]; strategy != "" {
+		return strategy
+	}
+	if c.RoutingStrategy == "" {
+		return RoutingRoundRobin
+	}
+	return c.RoutingStrategy
+}
+
+// ProxyRoutingStrategy returns the strategy written to the proxy config. The
+// proxy uses one strategy for all providers and only knows round-robin and
+// fill-first, so it is fill-first only when no provider needs round-robin.
+func (c *Config) ProxyRoutingStrategy() RoutingStrategy {
+	if c.RoutingStrategy != RoutingFillFirst {
+		return RoutingRoundRobin
+	}
+	for _, strategy := range c.RoutingOverrides {
+		if strategy == RoutingRoundRobin {
+			return RoutingRoundRobin
+		}
+	}
+	return RoutingFillFirst
+}
+
+// RoutingEmulated reports whether LazyL2M picks the account of a provider
+// itself because the proxy can't express its strategy
+func (c *Config) RoutingEmulated(provider AIProvider) bool {
+	return c.StrategyFor(provider) != c.ProxyRoutingStrategy()
+}
+
+// RoutingWeight returns the weight of an auth file for "weighted"
+func (c *Config) RoutingWeight(account string) int {
+	if weight := c.RoutingWeights[account]; weight > 0 {
+		return weight
+	}
+	return 1
+}
+
+// OrderAccounts sorts auth files by RoutingOrder; the others follow by name
+func (c *Config) OrderAccounts(accounts []string) {
+	rank := make(map[string]int, len(c.RoutingOrder))
+	for i, account := range c.RoutingOrder {
+		rank[account] = i + 1
+	}
+	sort.SliceStable(accounts, func(i, j int) bool {
+		a, b := rank[accounts[i]], rank[accounts[j]]
+		switch {
+		case a != 0 && b != 0:
+			return a < b
+		case a != 0 || b != 0:
+			return a != 0
+		default:
+			return strings.ToLower(accounts[i]) < strings.ToLower(accounts[j])
+		}
+	})
+}
+
+// ValidateRouting checks the routing strategies and weights
+func ValidateRouting(c *Config) error {
+	known := func(strategy RoutingStrategy) bool {
+		return strategy == "" || slices.Contains(RoutingStrategies(), strategy)
+	}
+	if !known(c.RoutingStrategy) {
+		return fmt.Errorf("unknown routing strategy %q", c.RoutingStrategy)
+	}
+	for provider, strategy := range c.RoutingOverrides {
+		if !known(strategy) {
+			return fmt.Errorf("unknown routing strategy %q for %s", strategy, provider)
+		}
+	}
+	for account, weight := range c.RoutingWeights {
+		if weight < 1 {
+			return fmt.Errorf("routing weight of %s must be at least 1", account)
+		}
+	}
+	return nil
+}
+
+// EnforceRouting applies the strategies the proxy can't express. For each
+// such provider one available account is kept enabled and the others are
+// held back by disabling their auth files, which the proxy reloads. Held
+// accounts are released when the strategy changes or no other account is
+// available.
+func (pm *ProxyManager) EnforceRouting() {
+	pm.accountsMutex.Lock()
+	defer pm.accountsMutex.Unlock()
+
+	entries, err := os.ReadDir(pm.authDir)
+	if err != nil {
+		return
+	}
+
+	cfg := pm.Config()
+	quotas := make(map[string]QuotaInfo)
+	for _, quota := range pm.GetReportedQuotas() {
+		quotas[filepath.Base(quota.AccountID)] = quota
+	}
+	authFiles := pm.GetAuthFiles()
+	statuses := make(map[string]string)
+	for _, auth := range authFiles {
+		statuses[auth.ID] = auth.Status
+	}
+
+	type account struct {
+		name   string
+		path   string
+		fields map[string]json.RawMessage
+	}
+	providers := make(map[AIProvider][]account)
+	for _, entry := range entries {
+		if entry.IsDir() || !strings.HasSuffix(entry.Name(), ".json") {
+			continue
+		}
+		path := filepath.Join(pm.authDir, entry.Name())
+		fields, err := readAuthFields(path)
+		if err != nil {
+			continue
+		}
+		provider, _ := pm.parseAuthFileName(entry.Name())
+		providers[provider] = append(providers[provider], account{entry.Name(), path, fields})
+	}
+
+	changed := false
+	now := time.Now()
+	for provider, accounts := range providers {
+		// Paused, disabled, failing and exhausted accounts are never picked
+		var available []string
+		if cfg.RoutingEmulated(provider) {
+			for _, a := range accounts {
+				_, paused := a.fields[pausedUntilKey]
+				status := statuses[a.name]
+				quota, reported := quotas[a.name]
+				if paused || userDisabled(a.fields) || status == "expired" || status == "error" ||
+					(reported && quotaExhausted(quota)) {
+					continue
+				}
+				available = append(available, a.name)
+			}
+		}
+
+		picked := ""
+		if len(available) > 0 {
+			picked = pm.pickAccount(cfg, provider, available, quotas, authFiles, now)
+		}
+
+		if picked == "" {
+			delete(pm.routedTo, provider)
+		} else if pm.routedTo[provider].Account != picked {
+			pm.routedTo[provider] = routedAccount{Account: picked, Since: now}
+			pm.AddLogExternal(LogLevelInfo, fmt.Sprintf("Routing %s requests to %s (%s)",
+				GetProviderInfo(provider).Name, picked, RoutingStrategyName(cfg.StrategyFor(provider))))
+		}
+
+		for _, a := range accounts {
+			_, held := a.fields[routingHeldKey]
+			hold := picked != "" && a.name != picked && slices.Contains(available, a.name)
+			if held == hold {
+				continue
+			}
+
+			if hold {
+				a.fields[routingHeldKey] = json.RawMessage("true")
+			} else {
+				delete(a.fields, routingHeldKey)
+			}
+			updateDisabled(a.fields)
+			if err := pm.writeAuthFile(a.path, a.fields); err != nil {
+				pm.AddLogExternal(LogLevelError, fmt.Sprintf("Failed to update account %s for routing: %v", a.name, err))
+				continue
+			}
+			changed = true
+		}
+	}
+
+	if changed {
+		pm.FetchAuthFiles()
+	}
+}
+
+// pickAccount returns the account of a provider that gets its requests next,
+// under the routing settings of cfg
+func (pm *ProxyManager) pickAccount(cfg *Config, provider AIProvider, available []string, quotas map[string]QuotaInfo, authFiles []AuthFile, now time.Time) string {
+	strategy := cfg.StrategyFor(provider)
+	if strategy == RoutingFillFirst {
+		sort.Slice(available, func(i, j int) bool {
+			return strings.ToLower(available[i]) < strings.ToLower(available[j])
+		})
+	} else {
+		cfg.OrderAccounts(available)
+	}
+
+	// Least-used and weighted stay on an account for a while, so requests
+	// aren't moved back and forth on every refresh
+	current := pm.routedTo[provider]
+	if !slices.Contains(available, current.Account) {
+		current = routedAccount{}
+	}
+	if current.Account != "" && (strategy == RoutingLeastUsed || strategy == RoutingWeighted) &&
+		now.Sub(current.Since) < routingMinSwitchInterval {
+		return current.Account
+	}
+
+	switch strategy {
+	case RoutingLeastUsed:
+		// Accounts without reported quota are only used when none has one
+		best := ""
+		for _, account := range available {
+			quota, ok := quotas[account]
+			if ok && (best == "" || quota.UsagePercent < quotas[best].UsagePercent) {
+				best = account
+			}
+		}
+		if best == "" {
+			if current.Account != "" {
+				return current.Account
+			}
+			return available[0]
+		}
+		if quota, ok := quotas[current.Account]; ok &&
+			quota.UsagePercent-quotas[best].UsagePercent < leastUsedSwitchMargin {
+			return current.Account
+		}
+		return best
+
+	case RoutingWeighted:
+		// The account furthest below its share of the requests served so far
+		served := servedRequests(pm.GetUsageStats(), authFiles)
+		best := available[0]
+		for _, account := range available[1:] {
+			a := float64(served[account]) / float64(cfg.RoutingWeight(account))
+			b := float64(served[best]) / float64(cfg.RoutingWeight(best))
+			if a < b || (a == b && cfg.RoutingWeight(account) > cfg.RoutingWeight(best)) {
+				best = account
+			}
+		}
+		return best
+
+	default:
+		// Fill-first and priority use the first account until it fails or
+		// runs out of quota, and go back to it once it recovers
+		return available[0]
+	}
+}
+
+// servedRequests counts the requests each auth file served, from the
+// per-request details of the usage statistics
+func servedRequests(stats UsageStats, accounts []AuthFile) map[string]int {
+	ids := make(map[string]string)
+	for _, auth := range accounts {
+		for _, source := range []string{auth.Email, auth.Name, auth.ID} {
+			if source != "" {
+				ids[source] = auth.ID
+			}
+		}
+	}
+
+	served := make(map[string]int)
+	for _, api := range stats.APIs {
+		for _, model := range api.Models {
+			for _, detail := range model.Details {
+				if id, ok := ids[detail.Source]; ok {
+					served[id]++
+				}
+			}
+		}
+	}
+	return served
+}
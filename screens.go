@@ -5,6 +5,7 @@ import (
 	"encoding/hex"
 	"fmt"
 	"strings"
+	"time"
 
 	"github.com/gdamore/tcell/v2"
 	"github.com/rivo/tview"
@@ -16,28 +17,44 @@ type Screen interface {
 	Update()
 }
 
+// EventHandler is implemented by screens that redraw the parts showing what
+// an event changed
+type EventHandler interface {
+	HandleEvent(event Event)
+}
+
 // DashboardScreen shows server status and statistics
 type DashboardScreen struct {
+	collapsible
+
 	view         *tview.Flex
 	statusText   *tview.TextView
 	statsText    *tview.TextView
+	trendsText   *tview.TextView
+	trendsBox    *tview.Flex
+	costBox      *tview.Flex
+	costDays     *tview.TextView
+	costAccounts *tview.TextView
+	costModels   *tview.TextView
 	accountsText *tview.TextView
 	pm           *ProxyManager
+	tasks        *TaskRunner
+	windowIndex  int
 }
 
-func NewDashboardScreen(pm *ProxyManager) *DashboardScreen {
-	ds := &DashboardScreen{pm: pm}
+func NewDashboardScreen(pm *ProxyManager, tasks *TaskRunner) *DashboardScreen {
+	ds := &DashboardScreen{pm: pm, tasks: tasks}
 
 	// ASCII Logo Header
-	logo := `[#00d7ff::b]
+	logo := `[heading::b]
  ██╗      █████╗ ███████╗██╗   ██╗██╗     ██████╗ ███╗   ███╗
  ██║     ██╔══██╗╚══███╔╝╚██╗ ██╔╝██║     ╚════██╗████╗ ████║
  ██║     ███████║  ███╔╝  ╚████╔╝ ██║      █████╔╝██╔████╔██║
  ██║     ██╔══██║ ███╔╝    ╚██╔╝  ██║     ██╔═══╝ ██║╚██╔╝██║
  ███████╗██║  ██║███████╗   ██║   ███████╗███████╗██║ ╚═╝ ██║
  ╚══════╝╚═╝  ╚═╝╚══════╝   ╚═╝   ╚══════╝╚══════╝╚═╝     ╚═╝[-]
- [gray]───────────────────────────────────────────────────────────[-]
- [#87d7ff]          CLIProxyAPI Management Terminal v1.0[-]`
+ [dim]───────────────────────────────────────────────────────────[-]
+ [accent]          CLIProxyAPI Management Terminal v1.0[-]`
 
 	header := tview.NewTextView().
 		SetText(logo).
@@ -52,7 +69,7 @@ func NewDashboardScreen(pm *ProxyManager) *DashboardScreen {
 	statusBox := tview.NewFlex().
 		SetDirection(tview.FlexRow).
 		AddItem(ds.statusText, 0, 1, false)
-	statusBox.SetBorder(true).SetTitle(" 🖥️  Server Status ").SetTitleAlign(tview.AlignLeft).SetBorderColor(tcell.ColorDodgerBlue)
+	statusBox.SetBorder(true).SetTitle(" " + iconText("🖥️", "", "Server Status") + " ").SetTitleAlign(tview.AlignLeft).SetBorderColor(theme.Border)
 
 	// Usage statistics section with box border
 	ds.statsText = tview.NewTextView().
@@ -62,7 +79,29 @@ func NewDashboardScreen(pm *ProxyManager) *DashboardScreen {
 	statsBox := tview.NewFlex().
 		SetDirection(tview.FlexRow).
 		AddItem(ds.statsText, 0, 1, false)
-	statsBox.SetBorder(true).SetTitle(" 📊 Usage Statistics ").SetTitleAlign(tview.AlignLeft).SetBorderColor(tcell.ColorDodgerBlue)
+	statsBox.SetBorder(true).SetTitle(" " + iconText("📊", "", "Usage Statistics") + " ").SetTitleAlign(tview.AlignLeft).SetBorderColor(theme.Border)
+
+	// Usage trends section with charts for the selected time window
+	ds.trendsText = tview.NewTextView().
+		SetDynamicColors(true).
+		SetScrollable(false)
+
+	ds.trendsBox = tview.NewFlex().
+		SetDirection(tview.FlexRow).
+		AddItem(ds.trendsText, 0, 1, false)
+	ds.trendsBox.SetBorder(true).SetTitleAlign(tview.AlignLeft).SetBorderColor(theme.Border)
+
+	// Estimated cost section grouped by day, account and model
+	ds.costDays = tview.NewTextView().SetDynamicColors(true)
+	ds.costAccounts = tview.NewTextView().SetDynamicColors(true)
+	ds.costModels = tview.NewTextView().SetDynamicColors(true)
+
+	ds.costBox = tview.NewFlex().
+		SetDirection(tview.FlexColumn).
+		AddItem(ds.costDays, 0, 1, false).
+		AddItem(ds.costAccounts, 0, 1, false).
+		AddItem(ds.costModels, 0, 1, false)
+	ds.costBox.SetBorder(true).SetTitleAlign(tview.AlignLeft).SetBorderColor(theme.Border)
 
 	// Connected accounts section with box border
 	ds.accountsText = tview.NewTextView().
@@ -72,7 +111,7 @@ func NewDashboardScreen(pm *ProxyManager) *DashboardScreen {
 	accountsBox := tview.NewFlex().
 		SetDirection(tview.FlexRow).
 		AddItem(ds.accountsText, 0, 1, false)
-	accountsBox.SetBorder(true).SetTitle(" 👤 Connected Accounts ").SetTitleAlign(tview.AlignLeft).SetBorderColor(tcell.ColorDodgerBlue)
+	accountsBox.SetBorder(true).SetTitle(" " + iconText("👤", "", "Connected Accounts") + " ").SetTitleAlign(tview.AlignLeft).SetBorderColor(theme.Border)
 
 	// Info panels in horizontal layout
 	infoRow := tview.NewFlex().
@@ -80,19 +119,19 @@ func NewDashboardScreen(pm *ProxyManager) *DashboardScreen {
 		AddItem(statusBox, 0, 1, false).
 		AddItem(statsBox, 0, 2, false)
 
-	// Help text with styled shortcuts
-	help := tview.NewTextView().
-		SetText("[#5f87af]╔══════════════════════════════════════════════════════════════════╗\n║ [#87d7ff]S[-][white] Toggle Server  [#87d7ff]I[-][white] Install  [#87d7ff]R[-][white] Refresh  [#87d7ff]Tab[-][white] Focus  [#87d7ff]X[-][white] Quit [#5f87af]║\n╚══════════════════════════════════════════════════════════════════╝[-]").
-		SetTextAlign(tview.AlignCenter).
-		SetDynamicColors(true)
-
 	// Main layout
 	ds.view = tview.NewFlex().
 		SetDirection(tview.FlexRow).
 		AddItem(header, 10, 0, false).
 		AddItem(infoRow, 10, 0, false).
-		AddItem(accountsBox, 0, 1, false).
-		AddItem(help, 4, 0, false)
+		AddItem(ds.trendsBox, 8, 0, false).
+		AddItem(ds.costBox, 7, 0, false).
+		AddItem(accountsBox, 0, 1, false)
+
+	// The compact layout keeps the status and accounts only
+	ds.hideWhenCompact(ds.view, header, 10)
+	ds.hideWhenCompact(ds.view, ds.trendsBox, 8)
+	ds.hideWhenCompact(ds.view, ds.costBox, 7)
 
 	ds.Update()
 	return ds
@@ -103,50 +142,112 @@ func (ds *DashboardScreen) GetView() tview.Primitive {
 }
 
 func (ds *DashboardScreen) Update() {
+	ds.updateStatus()
+	ds.updateStats()
+	ds.updateAccounts()
+	ds.updateTrends()
+	ds.updateCosts()
+}
+
+// HandleEvent redraws the sections showing what changed
+func (ds *DashboardScreen) HandleEvent(event Event) {
+	switch event {
+	case EventStatusChanged, EventDownloadProgress:
+		ds.updateStatus()
+	case EventStatsUpdated:
+		ds.updateStats()
+		ds.updateTrends()
+		ds.updateCosts()
+	case EventAccountsChanged:
+		ds.updateAccounts()
+	}
+}
+
+// updateStatus shows whether the proxy runs and the install progress
+func (ds *DashboardScreen) updateStatus() {
 	status := ds.pm.GetStatus()
-	stats := ds.pm.GetUsageStats()
-	authFiles := ds.pm.GetAuthFiles()
 
 	// Check if binary is installed
 	if !ds.pm.IsBinaryInstalled() {
 		if ds.pm.IsDownloading() {
 			progress := ds.pm.GetDownloadProgress() * 100
 			ds.statusText.SetText(fmt.Sprintf(
-				"\n  [yellow]◉ DOWNLOADING[-]\n\n"+
-					"  [#87d7ff]Progress:[white] %.0f%%[-]\n"+
-					"  [gray]Installing CLIProxyAPI...[-]",
-				progress,
+				"\n  [warning]◉ DOWNLOADING[-]\n\n"+
+					"  [accent]Progress:[text] %.0f%%[-]\n"+
+					"  [text]%s[-]\n"+
+					"  [dim]Installing CLIProxyAPI... 'C' to cancel[-]",
+				progress, formatDownloadStats(ds.pm.GetDownloadStats()),
 			))
 		} else {
 			ds.statusText.SetText(
-				"\n  [red]◉ NOT INSTALLED[-]\n\n" +
-					"  [gray]CLIProxyAPI binary not found[-]\n" +
-					"  [yellow]Press 'I' to install[-]",
+				"\n  [bad]◉ NOT INSTALLED[-]\n\n" +
+					"  [dim]CLIProxyAPI binary not found[-]\n" +
+					"  [warning]Press 'I' to install, 'O' from a file[-]",
 			)
 		}
 	} else {
 		// Update status with visual indicator
-		statusIcon := "[red]◉ STOPPED[-]"
-		statusDetail := "[gray]Server is not running. Press 'S' to start[-]"
-		if status.Running {
-			statusIcon = "[green]◉ RUNNING[-]"
-			statusDetail = "[white]Accepting connections[-]"
+		statusIcon := "[bad]◉ STOPPED[-]"
+		statusDetail := "[dim]Server is not running. Press 'S' to start[-]"
+		switch {
+		case status.Starting:
+			statusIcon = "[warning]◉ STARTING[-]"
+			statusDetail = "[dim]Waiting for the proxy to come up...[-]"
+		case status.Stopping:
+			statusIcon = "[warning]◉ STOPPING[-]"
+			statusDetail = "[dim]Waiting for the proxy to exit...[-]"
+		case status.Running:
+			statusIcon = "[good]◉ RUNNING[-]"
+			statusDetail = "[text]Accepting connections[-]"
+		}
+		// An available update takes the place of the status detail
+		if latest, ok := ds.pm.GetAvailableUpdate(); ok {
+			statusDetail = fmt.Sprintf("[warning]%s (press 'U')[-]", iconText("⬆️", "", "Update available: "+latest))
+		}
+		if ds.pm.IsDownloading() {
+			statusDetail = fmt.Sprintf("[warning]Upgrading... %.0f%% %s[-]",
+				ds.pm.GetDownloadProgress()*100, formatDownloadStats(ds.pm.GetDownloadStats()))
+		}
+		version, known := ds.pm.CachedInstalledVersion()
+		if !known {
+			version = "[dim]checking...[-]"
+			ds.probeVersion()
 		}
 		ds.statusText.SetText(fmt.Sprintf(
-			"\n  %s\n\n  [#87d7ff]Port:[white] %d[-]\n  [#87d7ff]Endpoint:[white] %s[-]\n  %s",
-			statusIcon, status.Port, ds.pm.GetEndpoint(), statusDetail,
+			"\n  %s\n\n  [accent]Port:[text] %d[-]\n  [accent]Endpoint:[text] %s[-]\n  [accent]Version:[text] %s[-]\n  %s",
+			statusIcon, status.Port, ds.pm.GetEndpoint(), version, statusDetail,
 		))
 	}
+}
+
+// probeVersion runs the binary in the background to find its version, which
+// is cached, and redraws when it is known
+func (ds *DashboardScreen) probeVersion() {
+	key := taskKey(ds.pm, taskVersion)
+	if ds.tasks.Running(key) {
+		return
+	}
+	ds.tasks.Run(key, "Checking CLIProxyAPI version", func() error {
+		ds.pm.GetInstalledVersion()
+		return nil
+	}, func(error) {
+		ds.Update()
+	})
+}
+
+// updateStats shows request and token totals
+func (ds *DashboardScreen) updateStats() {
+	stats := ds.pm.GetUsageStats()
 
 	// Update statistics with visual bars
 	successBar := createProgressBar(stats.SuccessRate, 20)
 	ds.statsText.SetText(fmt.Sprintf(
-		"\n  [#87d7ff]Total Requests:  [-] [white]%d[-]\n"+
-			"  [#87d7ff]Success:         [-] [green]%d[-]\n"+
-			"  [#87d7ff]Failed:          [-] [red]%d[-]\n"+
-			"  [#87d7ff]Total Tokens:    [-] [cyan]%d[-]\n\n"+
-			"  [#87d7ff]Success Rate:[-]    %s [yellow]%.1f%%[-]\n\n"+
-			"  [gray]Updated: %s[-]",
+		"\n  [accent]Total Requests:  [-] [text]%d[-]\n"+
+			"  [accent]Success:         [-] [good]%d[-]\n"+
+			"  [accent]Failed:          [-] [bad]%d[-]\n"+
+			"  [accent]Total Tokens:    [-] [info]%d[-]\n\n"+
+			"  [accent]Success Rate:[-]    %s [warning]%.1f%%[-]\n\n"+
+			"  [dim]Updated: %s[-]",
 		stats.TotalRequests,
 		stats.SuccessRequests,
 		stats.FailedRequests,
@@ -155,33 +256,169 @@ func (ds *DashboardScreen) Update() {
 		stats.SuccessRate,
 		stats.LastUpdated.Format("15:04:05"),
 	))
+}
+
+// updateAccounts lists the connected accounts
+func (ds *DashboardScreen) updateAccounts() {
+	authFiles := ds.pm.GetAuthFiles()
 
 	// Update accounts with table-like formatting
 	var accountsList strings.Builder
 	accountsList.WriteString("\n")
 	if len(authFiles) == 0 {
-		accountsList.WriteString("  [gray]No connected accounts. Start the server to sync.[-]\n")
+		accountsList.WriteString("  [dim]No connected accounts. Start the server to sync.[-]\n")
 	} else {
 		for i, auth := range authFiles {
 			info := GetProviderInfo(auth.Provider)
-			statusColor := "[green]●[-]"
+			statusColor := "[good]●[-]"
 			statusText := "active"
-			if auth.Status != "active" {
-				statusColor = "[red]●[-]"
+			if auth.Status == "standby" { // Held back by routing, but healthy
+				statusColor = "[dim]●[-]"
+				statusText = auth.Status
+			} else if auth.Status != "active" {
+				statusColor = "[bad]●[-]"
 				statusText = auth.Status
 			}
 			accountsList.WriteString(fmt.Sprintf(
-				"  %s %s %-20s %s [gray]%s[-]\n",
-				statusColor, info.Symbol, auth.Name, fmt.Sprintf("[#5f87af](%s)[-]", info.Name), statusText,
+				"  %s %s %s %s [dim]%s[-]\n",
+				statusColor, info.Symbol, padRight(auth.Name, 20), fmt.Sprintf("[muted](%s)[-]", info.Name), statusText,
 			))
 			if i < len(authFiles)-1 {
-				accountsList.WriteString("  [#303030]────────────────────────────────────────[-]\n")
+				accountsList.WriteString("  [faint]────────────────────────────────────────[-]\n")
 			}
 		}
 	}
 	ds.accountsText.SetText(accountsList.String())
 }
 
+// updateCosts shows estimated costs at list prices by day, account and model
+func (ds *DashboardScreen) updateCosts() {
+	estimate := ds.pm.GetCostEstimate()
+
+	title := fmt.Sprintf(" %s ", iconText("💰", "", "Estimated Cost · Total "+formatCost(estimate.Total)))
+	if estimate.Unpriced > 0 {
+		title += fmt.Sprintf("[dim](%d unpriced)[-] ", estimate.Unpriced)
+	}
+	ds.costBox.SetTitle(title)
+
+	// Last days, newest first
+	var days strings.Builder
+	days.WriteString("  [accent::b]By Day[::-]\n")
+	now := time.Now()
+	for i := 0; i < 4; i++ {
+		day := now.AddDate(0, 0, -i)
+		days.WriteString(fmt.Sprintf("  [dim]%s[-] [text]%10s[-]\n", day.Format("Mon Jan 02"), formatCost(estimate.ByDay[day.Format("2006-01-02")])))
+	}
+	ds.costDays.SetText(days.String())
+
+	ds.costAccounts.SetText(formatCostList("By Account", TopCosts(estimate.ByAccount, 4)))
+	ds.costModels.SetText(formatCostList("By Model", TopCosts(estimate.ByModel, 4)))
+}
+
+// formatCostList renders a titled list of cost entries
+func formatCostList(title string, entries []CostEntry) string {
+	var list strings.Builder
+	list.WriteString(fmt.Sprintf("  [accent::b]%s[::-]\n", title))
+	if len(entries) == 0 {
+		list.WriteString("  [dim]No priced requests yet[-]\n")
+	}
+	for _, entry := range entries {
+		list.WriteString(fmt.Sprintf("  [dim]%s[-] [text]%10s[-]\n", fitWidth(entry.Name, 22), formatCost(entry.Cost)))
+	}
+	return list.String()
+}
+
+// RegisterActions registers the dashboard's own actions
+func (ds *DashboardScreen) RegisterActions(reg *ActionRegistry) {
+	reg.Register(Action{
+		ID:          "dashboard.refresh",
+		Title:       "Refresh dashboard",
+		Description: "Reload accounts and usage statistics",
+		Screen:      "dashboard",
+		Keys:        []string{"r", "R"},
+		Hint:        "Refresh",
+		Run: func() {
+			ds.tasks.Run(taskKey(ds.pm, taskRefresh), "Refreshing dashboard", func() error {
+				ds.pm.FetchAuthFiles()
+				return ds.pm.FetchUsageStats()
+			}, func(err error) {
+				ds.Update()
+				if err != nil {
+					ds.tasks.Report(ds.pm, LogLevelError, fmt.Sprintf("Failed to refresh dashboard: %v", err))
+					return
+				}
+				ds.tasks.Report(ds.pm, LogLevelInfo, "Dashboard refreshed")
+			})
+		},
+	})
+	reg.Register(Action{
+		ID:          "dashboard.trends-window",
+		Title:       "Cycle trends window",
+		Description: "Show usage trends over the next time window",
+		Screen:      "dashboard",
+		Keys:        []string{"t", "T"},
+		Hint:        "Trends",
+		Run:         ds.CycleUsageWindow,
+	})
+}
+
+// CycleUsageWindow switches the trends charts to the next time window
+func (ds *DashboardScreen) CycleUsageWindow() {
+	ds.windowIndex = (ds.windowIndex + 1) % len(UsageWindows())
+	ds.updateTrends()
+}
+
+// updateTrends draws the request bar chart and error/token sparklines
+func (ds *DashboardScreen) updateTrends() {
+	window := UsageWindows()[ds.windowIndex]
+	series := ds.pm.GetUsageHistory().Series(window)
+	total := SumUsage(series)
+
+	ds.trendsBox.SetTitle(fmt.Sprintf(" %s ", iconText("📈", "", "Usage Trends · "+window.Name)))
+
+	requests := make([]int, len(series))
+	errors := make([]int, len(series))
+	tokens := make([]int, len(series))
+	for i, sample := range series {
+		requests[i] = sample.Requests
+		errors[i] = sample.Errors
+		tokens[i] = sample.Tokens
+	}
+
+	// Fit the charts into the space left after labels and totals
+	_, _, width, _ := ds.trendsText.GetInnerRect()
+	width -= 24
+	if width < 10 {
+		width = 40
+	}
+	requests = resampleValues(requests, width)
+	errors = resampleValues(errors, width)
+	tokens = resampleValues(tokens, width)
+
+	var trends strings.Builder
+	for i, row := range createBarChart(requests, 4, "accent") {
+		label := ""
+		if i == 0 {
+			label = "Requests"
+		}
+		count := ""
+		if i == 3 {
+			count = formatCount(total.Requests)
+		}
+		trends.WriteString(fmt.Sprintf("  [accent]%-10s[-] %s [text]%8s[-]\n", label, row, count))
+	}
+	trends.WriteString(fmt.Sprintf("  [accent]%-10s[-] %s [text]%8s[-]\n", "Errors", createSparkline(errors, "bad"), formatCount(total.Errors)))
+	trends.WriteString(fmt.Sprintf("  [accent]%-10s[-] %s [text]%8s[-]", "Tokens", createSparkline(tokens, "info"), formatCount(total.Tokens)))
+
+	ds.trendsText.SetText(trends.String())
+}
+
+// screenHeading returns the banner at the top of a screen
+func screenHeading(emoji, title string) string {
+	rule := strings.Repeat("━", 44)
+	return fmt.Sprintf("[heading::b]%s\n         %s\n%s[::-]", rule, iconText(emoji, "", title), rule)
+}
+
 // createProgressBar creates a visual progress bar
 func createProgressBar(percent float64, width int) string {
 	if percent > 100 {
@@ -192,85 +429,317 @@ func createProgressBar(percent float64, width int) string {
 	}
 	filled := int(percent / 100 * float64(width))
 	empty := width - filled
-	bar := "[green]" + strings.Repeat("█", filled) + "[-][#303030]" + strings.Repeat("░", empty) + "[-]"
+	bar := "[good]" + strings.Repeat("█", filled) + "[-][faint]" + strings.Repeat("░", empty) + "[-]"
 	return bar
 }
 
+// sparkBlocks are the glyphs used for sparklines and bar charts, lowest first
+var sparkBlocks = []rune("▁▂▃▄▅▆▇█")
+
+// resampleValues merges adjacent values so that at most width values remain
+func resampleValues(values []int, width int) []int {
+	if width <= 0 || len(values) <= width {
+		return values
+	}
+	factor := (len(values) + width - 1) / width
+	resampled := make([]int, 0, width)
+	for i := 0; i < len(values); i += factor {
+		sum := 0
+		for j := i; j < i+factor && j < len(values); j++ {
+			sum += values[j]
+		}
+		resampled = append(resampled, sum)
+	}
+	return resampled
+}
+
+// maxValue returns the largest value, or 0 for an empty slice
+func maxValue(values []int) int {
+	max := 0
+	for _, v := range values {
+		if v > max {
+			max = v
+		}
+	}
+	return max
+}
+
+// createSparkline creates a one-line chart of values
+func createSparkline(values []int, color string) string {
+	max := maxValue(values)
+	var line strings.Builder
+	line.WriteString("[" + color + "]")
+	for _, v := range values {
+		if v == 0 || max == 0 {
+			line.WriteString("[faint]▁[" + color + "]")
+			continue
+		}
+		level := v * (len(sparkBlocks) - 1) / max
+		line.WriteRune(sparkBlocks[level])
+	}
+	line.WriteString("[-]")
+	return line.String()
+}
+
+// createBarChart creates a vertical bar chart of values, returned as rows
+// from top to bottom
+func createBarChart(values []int, height int, color string) []string {
+	max := maxValue(values)
+	levels := len(sparkBlocks)
+	rows := make([]string, height)
+
+	for row := 0; row < height; row++ {
+		// Number of eighths below this row
+		base := (height - 1 - row) * levels
+		var line strings.Builder
+		line.WriteString("[" + color + "]")
+		for _, v := range values {
+			filled := 0
+			if max > 0 {
+				filled = v * height * levels / max
+			}
+			switch {
+			case filled >= base+levels:
+				line.WriteRune('█')
+			case filled > base:
+				line.WriteRune(sparkBlocks[filled-base-1])
+			case row == height-1:
+				line.WriteString("[faint]▁[" + color + "]")
+			default:
+				line.WriteRune(' ')
+			}
+		}
+		line.WriteString("[-]")
+		rows[row] = line.String()
+	}
+
+	return rows
+}
+
+// formatCount formats a count compactly, e.g. 1.2k or 3.4M
+func formatCount(n int) string {
+	switch {
+	case n >= 1000000:
+		return fmt.Sprintf("%.1fM", float64(n)/1000000)
+	case n >= 10000:
+		return fmt.Sprintf("%.1fk", float64(n)/1000)
+	default:
+		return fmt.Sprintf("%d", n)
+	}
+}
+
 // QuotaScreen shows quota usage for all accounts
 type QuotaScreen struct {
-	view  *tview.Flex
-	table *tview.Table
-	pm    *ProxyManager
+	collapsible
+
+	view         *tview.Flex
+	table        *tview.Table
+	summary      *tview.TextView
+	filter       *tview.InputField
+	pm           *ProxyManager
+	app          *tview.Application
+	quotas       []QuotaInfo // Rows of the table, in display order
+	sortColumn   int
+	ascending    bool
+	problemsOnly bool
 }
 
-func NewQuotaScreen(pm *ProxyManager) *QuotaScreen {
-	qs := &QuotaScreen{pm: pm}
+func NewQuotaScreen(pm *ProxyManager, app *tview.Application) *QuotaScreen {
+	qs := &QuotaScreen{pm: pm, app: app}
 
 	title := tview.NewTextView().
-		SetText("[#00d7ff::b]━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━\n         📈 QUOTA USAGE MONITOR\n━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━[::-]").
+		SetText(screenHeading("📈", "QUOTA USAGE MONITOR")).
 		SetTextAlign(tview.AlignCenter).
 		SetDynamicColors(true)
 
-	qs.table = tview.NewTable().
+	qs.summary = tview.NewTextView().
+		SetDynamicColors(true)
+
+	qs.filter = tview.NewInputField().
+		SetLabel(" Filter: ").
+		SetLabelColor(theme.Label).
+		SetFieldBackgroundColor(theme.Field).
+		SetFieldTextColor(theme.Text).
+		SetPlaceholder("provider or account").
+		SetPlaceholderTextColor(theme.Muted)
+	qs.filter.SetChangedFunc(func(text string) {
+		qs.Update()
+	})
+	qs.filter.SetDoneFunc(func(key tcell.Key) {
+		// Esc clears the filter, Enter keeps it
+		if key == tcell.KeyEscape {
+			qs.filter.SetText("")
+		}
+		app.SetFocus(qs.table)
+	})
+
+	qs.table = styleTable(tview.NewTable()).
 		SetBorders(true).
 		SetSelectable(true, false).
 		SetFixed(1, 0)
-	qs.table.SetBorderColor(tcell.ColorDodgerBlue)
-
-	help := tview.NewTextView().
-		SetText("[#5f87af]╔════════════════════════════════════════════════════════════╗\n║  [#87d7ff]R[-][white] Refresh Data   [#87d7ff]Tab[-][white] Switch Focus   [#87d7ff]↑↓[-][white] Navigate      [#5f87af]║\n╚════════════════════════════════════════════════════════════╝[-]").
-		SetTextAlign(tview.AlignCenter).
-		SetDynamicColors(true)
 
-	tableContainer := tview.NewFlex().SetDirection(tview.FlexRow).AddItem(qs.table, 0, 1, true)
-	tableContainer.SetBorder(true).SetTitle(" Quota Details ").SetBorderColor(tcell.ColorDodgerBlue)
+	tableContainer := tview.NewFlex().SetDirection(tview.FlexRow).
+		AddItem(qs.summary, 1, 0, false).
+		AddItem(qs.filter, 1, 0, false).
+		AddItem(qs.table, 0, 1, true)
+	tableContainer.SetBorder(true).SetTitle(" Quota Details ").SetBorderColor(theme.Border)
 
 	qs.view = tview.NewFlex().
 		SetDirection(tview.FlexRow).
-		AddItem(title, 4, 0, false).
-		AddItem(tableContainer, 0, 1, true).
-		AddItem(help, 4, 0, false)
+		AddItem(title, headingHeight, 0, false).
+		AddItem(tableContainer, 0, 1, true)
+
+	qs.hideWhenCompact(qs.view, title, headingHeight)
 
 	qs.Update()
 	return qs
 }
 
+// RegisterActions registers the quota screen's own actions
+func (qs *QuotaScreen) RegisterActions(reg *ActionRegistry) {
+	reg.Register(Action{
+		ID:          "quota.sort",
+		Title:       "Sort quota by next column",
+		Description: "Sort accounts by usage, reset time or provider",
+		Screen:      "quota",
+		Keys:        []string{"o", "O"},
+		Hint:        "Sort",
+		Run:         qs.CycleSort,
+	})
+	reg.Register(Action{
+		ID:          "quota.reverse",
+		Title:       "Reverse quota sort order",
+		Description: "Flip between ascending and descending order",
+		Screen:      "quota",
+		Keys:        []string{"v", "V"},
+		Hint:        "Reverse",
+		Run:         qs.ReverseSort,
+	})
+	reg.Register(Action{
+		ID:          "quota.filter",
+		Title:       "Filter quota",
+		Description: "Show only accounts whose provider or name matches a text",
+		Screen:      "quota",
+		Keys:        []string{"/"},
+		Hint:        "Filter",
+		Run: func() {
+			qs.app.SetFocus(qs.filter)
+		},
+	})
+	reg.Register(Action{
+		ID:          "quota.problems",
+		Title:       "Toggle problem accounts only",
+		Description: "Show only accounts close to or over their quota",
+		Screen:      "quota",
+		Keys:        []string{"f", "F"},
+		Hint:        "Problems",
+		Run:         qs.ToggleProblemsOnly,
+	})
+	reg.Register(Action{
+		ID:          "quota.refresh",
+		Title:       "Refresh quota",
+		Description: "Redraw quota usage per account",
+		Screen:      "quota",
+		Keys:        []string{"r", "R"},
+		Hint:        "Refresh",
+		Run: func() {
+			qs.Update()
+			qs.pm.AddLogExternal(LogLevelInfo, "Quota data refreshed")
+		},
+	})
+}
+
 func (qs *QuotaScreen) GetView() tview.Primitive {
 	return qs.view
 }
 
+// HandleEvent redraws the table when accounts or quotas change
+func (qs *QuotaScreen) HandleEvent(event Event) {
+	if event == EventAccountsChanged {
+		qs.Update()
+	}
+}
+
+// CycleSort sorts the table by the next column
+func (qs *QuotaScreen) CycleSort() {
+	qs.sortColumn = (qs.sortColumn + 1) % len(QuotaSortColumns())
+	// Highest usage first, resets and providers in order
+	qs.ascending = QuotaSortColumns()[qs.sortColumn] != QuotaSortByUsage
+	qs.Update()
+}
+
+// ReverseSort flips the sort direction
+func (qs *QuotaScreen) ReverseSort() {
+	qs.ascending = !qs.ascending
+	qs.Update()
+}
+
+// ToggleProblemsOnly shows only accounts in warning or exceeded state, or all
+func (qs *QuotaScreen) ToggleProblemsOnly() {
+	qs.problemsOnly = !qs.problemsOnly
+	qs.Update()
+}
+
+// GetSelectedQuota returns the quota of the selected account, or nil
+func (qs *QuotaScreen) GetSelectedQuota() *QuotaInfo {
+	row, _ := qs.table.GetSelection()
+	if row < 1 || row > len(qs.quotas) {
+		return nil
+	}
+	return &qs.quotas[row-1]
+}
+
 func (qs *QuotaScreen) Update() {
+	column := QuotaSortColumns()[qs.sortColumn]
+
+	// Keep the selected account and scroll position when rows move
+	selectedID := ""
+	if quota := qs.GetSelectedQuota(); quota != nil {
+		selectedID = quota.AccountID
+	}
+	selectedRow, _ := qs.table.GetSelection()
+	offset, _ := qs.table.GetOffset()
+
+	all := qs.pm.GetQuotaInfos()
+	qs.quotas = FilterQuotas(all, qs.filter.GetText(), qs.problemsOnly)
+	SortQuotas(qs.quotas, column, !qs.ascending)
+
+	direction := "↓"
+	if qs.ascending {
+		direction = "↑"
+	}
+	summary := fmt.Sprintf(" [accent]Sort:[text] %s %s   [accent]Showing:[text] %d of %d[-]", column, direction, len(qs.quotas), len(all))
+	if qs.problemsOnly {
+		summary += "   [warning]warning/exceeded only[-]"
+	}
+	qs.summary.SetText(summary)
+
 	qs.table.Clear()
 
 	// Headers with enhanced styling
 	headers := []string{"Provider", "Account", "Used", "Limit", "Usage", "Status", "Reset Time"}
 	for col, header := range headers {
-		cell := tview.NewTableCell(fmt.Sprintf(" [#00d7ff::b]%s[::-] ", header)).
-			SetAlign(tview.AlignCenter).
-			SetSelectable(false).
-			SetBackgroundColor(tcell.ColorDarkSlateGray)
+		cell := headerCell(header).SetAlign(tview.AlignCenter)
 		qs.table.SetCell(0, col, cell)
 	}
 
 	// Data rows with visual progress bars
-	quotas := qs.pm.GetQuotaInfos()
-	for row, quota := range quotas {
+	for row, quota := range qs.quotas {
 		info := GetProviderInfo(quota.Provider)
 
 		// Status color and icon
-		statusColor := "[green]"
-		statusIcon := "✓"
+		statusColor := "[good]"
+		statusIcon := icon("✓", "+")
 		if quota.Status == "warning" {
-			statusColor = "[yellow]"
-			statusIcon = "⚠"
-			statusIcon = "⚠"
+			statusColor = "[warning]"
+			statusIcon = icon("⚠", "!")
 		} else if quota.Status == "exceeded" {
-			statusColor = "[red]"
-			statusIcon = "✗"
+			statusColor = "[bad]"
+			statusIcon = icon("✗", "x")
 		}
 
 		// Reset time
-		resetTime := "[gray]N/A[-]"
+		resetTime := "[dim]N/A[-]"
 		if quota.ResetTime != nil {
 			resetTime = quota.ResetTime.Format("Jan 02 15:04")
 		}
@@ -298,7 +767,18 @@ func (qs *QuotaScreen) Update() {
 				SetSelectable(true)
 			qs.table.SetCell(row+1, col, cell)
 		}
+
+		if quota.AccountID == selectedID {
+			selectedRow = row + 1
+		}
+	}
+
+	if len(qs.quotas) == 0 {
+		qs.summary.SetText(summary + "   [dim]No matching accounts[-]")
+		return
 	}
+	qs.table.SetOffset(offset, 0)
+	qs.table.Select(max(1, min(selectedRow, len(qs.quotas))), 0)
 }
 
 // createMiniProgressBar creates a compact progress bar
@@ -312,46 +792,241 @@ func createMiniProgressBar(percent float64, width int) string {
 	filled := int(percent / 100 * float64(width))
 	empty := width - filled
 
-	color := "[green]"
+	color := "[good]"
 	if percent > 90 {
-		color = "[red]"
+		color = "[bad]"
 	} else if percent > 70 {
-		color = "[yellow]"
+		color = "[warning]"
 	}
-	return color + strings.Repeat("▓", filled) + "[-][#404040]" + strings.Repeat("░", empty) + "[-]"
+	return color + strings.Repeat("▓", filled) + "[-][faint]" + strings.Repeat("░", empty) + "[-]"
 }
 
-// ProvidersScreen shows all supported providers
-type ProvidersScreen struct {
-	view *tview.Flex
-	list *tview.List
-	pm   *ProxyManager
+// UsageScreen shows usage broken down by provider, model, account or API key
+type UsageScreen struct {
+	collapsible
+
+	view       *tview.Flex
+	table      *tview.Table
+	summary    *tview.TextView
+	pm         *ProxyManager
+	tasks      *TaskRunner
+	dimension  int
+	sortColumn int
+	ascending  bool
 }
 
-func NewProvidersScreen(pm *ProxyManager) *ProvidersScreen {
-	ps := &ProvidersScreen{pm: pm}
+func NewUsageScreen(pm *ProxyManager, tasks *TaskRunner) *UsageScreen {
+	us := &UsageScreen{pm: pm, tasks: tasks}
 
 	title := tview.NewTextView().
-		SetText("[#00d7ff::b]━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━\n         🤖 AI PROVIDERS\n━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━[::-]").
+		SetText(screenHeading("📑", "USAGE BREAKDOWN")).
 		SetTextAlign(tview.AlignCenter).
 		SetDynamicColors(true)
 
-	ps.list = tview.NewList().
-		ShowSecondaryText(true).
-		SetHighlightFullLine(true).
-		SetSecondaryTextColor(tcell.ColorDarkCyan)
-	ps.list.SetBorder(true).SetTitle(" Available Providers ").SetBorderColor(tcell.ColorDodgerBlue)
+	us.summary = tview.NewTextView().
+		SetDynamicColors(true)
+
+	us.table = styleTable(tview.NewTable()).
+		SetBorders(true).
+		SetSelectable(true, false).
+		SetFixed(1, 0)
+
+	tableContainer := tview.NewFlex().SetDirection(tview.FlexRow).
+		AddItem(us.summary, 1, 0, false).
+		AddItem(us.table, 0, 1, true)
+	tableContainer.SetBorder(true).SetBorderColor(theme.Border)
+
+	us.view = tview.NewFlex().
+		SetDirection(tview.FlexRow).
+		AddItem(title, headingHeight, 0, false).
+		AddItem(tableContainer, 0, 1, true)
+
+	us.hideWhenCompact(us.view, title, headingHeight)
+
+	us.Update()
+	return us
+}
+
+func (us *UsageScreen) GetView() tview.Primitive {
+	return us.view
+}
+
+// RegisterActions registers the usage screen's own actions
+func (us *UsageScreen) RegisterActions(reg *ActionRegistry) {
+	reg.Register(Action{
+		ID:          "usage.group-by",
+		Title:       "Group usage by next dimension",
+		Description: "Break usage down by provider, model, account or API key",
+		Screen:      "usage",
+		Keys:        []string{"b", "B"},
+		Hint:        "Group By",
+		Run:         us.CycleDimension,
+	})
+	reg.Register(Action{
+		ID:          "usage.sort",
+		Title:       "Sort usage by next column",
+		Description: "Change the column the usage table is sorted by",
+		Screen:      "usage",
+		Keys:        []string{"o", "O"},
+		Hint:        "Sort",
+		Run:         us.CycleSort,
+	})
+	reg.Register(Action{
+		ID:          "usage.reverse",
+		Title:       "Reverse usage sort order",
+		Description: "Flip between ascending and descending order",
+		Screen:      "usage",
+		Keys:        []string{"v", "V"},
+		Hint:        "Reverse",
+		Run:         us.ReverseSort,
+	})
+	reg.Register(Action{
+		ID:          "usage.refresh",
+		Title:       "Refresh usage",
+		Description: "Reload usage statistics from the proxy",
+		Screen:      "usage",
+		Keys:        []string{"r", "R"},
+		Hint:        "Refresh",
+		Run: func() {
+			us.tasks.Run(taskKey(us.pm, taskRefresh), "Refreshing usage", us.pm.FetchUsageStats, func(err error) {
+				us.Update()
+				if err != nil {
+					us.tasks.Report(us.pm, LogLevelError, fmt.Sprintf("Failed to refresh usage: %v", err))
+					return
+				}
+				us.tasks.Report(us.pm, LogLevelInfo, "Usage data refreshed")
+			})
+		},
+	})
+}
+
+// CycleDimension groups the table by the next dimension
+func (us *UsageScreen) CycleDimension() {
+	us.dimension = (us.dimension + 1) % len(BreakdownDimensions())
+	us.Update()
+}
+
+// CycleSort sorts the table by the next column
+func (us *UsageScreen) CycleSort() {
+	us.sortColumn = (us.sortColumn + 1) % len(BreakdownSortColumns())
+	// Names read best A-Z, numbers largest first
+	us.ascending = BreakdownSortColumns()[us.sortColumn] == SortByName
+	us.Update()
+}
+
+// ReverseSort flips the sort direction
+func (us *UsageScreen) ReverseSort() {
+	us.ascending = !us.ascending
+	us.Update()
+}
+
+// HandleEvent redraws the table when new statistics arrive
+func (us *UsageScreen) HandleEvent(event Event) {
+	if event == EventStatsUpdated {
+		us.Update()
+	}
+}
+
+func (us *UsageScreen) Update() {
+	dimension := BreakdownDimensions()[us.dimension]
+	column := BreakdownSortColumns()[us.sortColumn]
+
+	rows := ComputeUsageBreakdown(us.pm.GetUsageStats(), dimension, us.pm.GetAuthFiles())
+	SortUsageBreakdown(rows, column, !us.ascending)
+
+	direction := "↓"
+	if us.ascending {
+		direction = "↑"
+	}
+	us.summary.SetText(fmt.Sprintf(" [accent]Group by:[text] %s   [accent]Sort:[text] %s %s[-]", dimension, column, direction))
+
+	selected, _ := us.table.GetSelection()
+	us.table.Clear()
+
+	// Headers with enhanced styling
+	headers := []string{strings.ToUpper(string(dimension[:1])) + string(dimension[1:]), "Requests", "Errors", "Error %", "Input", "Output", "Total Tokens", "Avg Latency"}
+	for col, header := range headers {
+		cell := headerCell(header).SetAlign(tview.AlignCenter)
+		us.table.SetCell(0, col, cell)
+	}
+
+	if len(rows) == 0 {
+		us.summary.SetText(us.summary.GetText(false) + "   [dim]No usage recorded yet[-]")
+		return
+	}
+
+	for row, usage := range rows {
+		name := usage.Name
+		if dimension == BreakdownProvider && usage.Provider != "" {
+			name = fmt.Sprintf("%s %s", GetProviderInfo(usage.Provider).Symbol, usage.Name)
+		}
+
+		errorColor := "[good]"
+		if usage.ErrorRate() > 10 {
+			errorColor = "[bad]"
+		} else if usage.ErrorRate() > 2 {
+			errorColor = "[warning]"
+		}
+
+		latency := "[dim]N/A[-]"
+		if usage.AvgLatency() > 0 {
+			latency = fmt.Sprintf("%dms", usage.AvgLatency().Milliseconds())
+		}
+
+		cells := []string{
+			" " + name,
+			fmt.Sprintf("%d", usage.Requests),
+			fmt.Sprintf("%d", usage.Failed),
+			fmt.Sprintf("%s%.1f%%[-]", errorColor, usage.ErrorRate()),
+			formatCount(usage.InputTokens),
+			formatCount(usage.OutputTokens),
+			formatCount(usage.TotalTokens),
+			latency,
+		}
 
-	help := tview.NewTextView().
-		SetText("[#5f87af]╔════════════════════════════════════════════════════════════╗\n║  [#87d7ff]Enter[-][white] View Details   [#87d7ff]R[-][white] Refresh   [#87d7ff]Tab[-][white] Switch Focus      [#5f87af]║\n╚════════════════════════════════════════════════════════════╝[-]").
+		for col, text := range cells {
+			align := tview.AlignRight
+			if col == 0 {
+				align = tview.AlignLeft
+			}
+			us.table.SetCell(row+1, col, tview.NewTableCell(text+" ").SetAlign(align))
+		}
+	}
+
+	if selected > 0 && selected <= len(rows) {
+		us.table.Select(selected, 0)
+	}
+}
+
+// ProvidersScreen shows all supported providers
+type ProvidersScreen struct {
+	collapsible
+
+	view  *tview.Flex
+	list  *tview.List
+	pm    *ProxyManager
+	tasks *TaskRunner
+}
+
+func NewProvidersScreen(pm *ProxyManager, tasks *TaskRunner) *ProvidersScreen {
+	ps := &ProvidersScreen{pm: pm, tasks: tasks}
+
+	title := tview.NewTextView().
+		SetText(screenHeading("🤖", "AI PROVIDERS")).
 		SetTextAlign(tview.AlignCenter).
 		SetDynamicColors(true)
 
+	ps.list = styleList(tview.NewList()).
+		ShowSecondaryText(true).
+		SetHighlightFullLine(true)
+	ps.list.SetBorder(true).SetTitle(" Available Providers ").SetBorderColor(theme.Border)
+
 	ps.view = tview.NewFlex().
 		SetDirection(tview.FlexRow).
-		AddItem(title, 4, 0, false).
-		AddItem(ps.list, 0, 1, true).
-		AddItem(help, 4, 0, false)
+		AddItem(title, headingHeight, 0, false).
+		AddItem(ps.list, 0, 1, true)
+
+	ps.hideWhenCompact(ps.view, title, headingHeight)
 
 	ps.Update()
 	return ps
@@ -361,6 +1036,28 @@ func (ps *ProvidersScreen) GetView() tview.Primitive {
 	return ps.view
 }
 
+// RegisterActions registers the providers screen's own actions
+func (ps *ProvidersScreen) RegisterActions(reg *ActionRegistry) {
+	reg.Register(Action{
+		ID:          "providers.refresh",
+		Title:       "Refresh providers",
+		Description: "Rescan connected accounts",
+		Screen:      "providers",
+		Keys:        []string{"r", "R"},
+		Hint:        "Refresh",
+		Run: func() {
+			ps.tasks.Run(taskKey(ps.pm, taskRefresh), "Refreshing providers", ps.pm.FetchAuthFiles, func(err error) {
+				ps.Update()
+				if err != nil {
+					ps.tasks.Report(ps.pm, LogLevelError, fmt.Sprintf("Failed to refresh providers: %v", err))
+					return
+				}
+				ps.tasks.Report(ps.pm, LogLevelInfo, "Providers refreshed")
+			})
+		},
+	})
+}
+
 // GetList returns the list component for external access
 func (ps *ProvidersScreen) GetList() *tview.List {
 	return ps.list
@@ -399,6 +1096,13 @@ func (ps *ProvidersScreen) GetAccountsForProvider(provider AIProvider) []AuthFil
 	return accounts
 }
 
+// HandleEvent redraws the account counts when accounts change
+func (ps *ProvidersScreen) HandleEvent(event Event) {
+	if event == EventAccountsChanged {
+		ps.Update()
+	}
+}
+
 func (ps *ProvidersScreen) Update() {
 	ps.list.Clear()
 
@@ -425,6 +1129,8 @@ func (ps *ProvidersScreen) Update() {
 
 // AgentsScreen shows CLI agent configuration status
 type AgentsScreen struct {
+	collapsible
+
 	view  *tview.Flex
 	table *tview.Table
 }
@@ -433,29 +1139,24 @@ func NewAgentsScreen() *AgentsScreen {
 	as := &AgentsScreen{}
 
 	title := tview.NewTextView().
-		SetText("[#00d7ff::b]━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━\n         ⚙️  CLI AGENTS\n━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━[::-]").
+		SetText(screenHeading("⚙️", "CLI AGENTS")).
 		SetTextAlign(tview.AlignCenter).
 		SetDynamicColors(true)
 
-	as.table = tview.NewTable().
+	as.table = styleTable(tview.NewTable()).
 		SetBorders(true).
 		SetSelectable(true, false).
 		SetFixed(1, 0)
-	as.table.SetBorderColor(tcell.ColorDodgerBlue)
 
 	tableContainer := tview.NewFlex().SetDirection(tview.FlexRow).AddItem(as.table, 0, 1, true)
-	tableContainer.SetBorder(true).SetTitle(" Agent Status ").SetBorderColor(tcell.ColorDodgerBlue)
-
-	help := tview.NewTextView().
-		SetText("[#5f87af]╔════════════════════════════════════════════════════════════╗\n║  [#87d7ff]Enter[-][white] View Details   [#87d7ff]R[-][white] Refresh   [#87d7ff]Tab[-][white] Switch Focus      [#5f87af]║\n╚════════════════════════════════════════════════════════════╝[-]").
-		SetTextAlign(tview.AlignCenter).
-		SetDynamicColors(true)
+	tableContainer.SetBorder(true).SetTitle(" Agent Status ").SetBorderColor(theme.Border)
 
 	as.view = tview.NewFlex().
 		SetDirection(tview.FlexRow).
-		AddItem(title, 4, 0, false).
-		AddItem(tableContainer, 0, 1, true).
-		AddItem(help, 4, 0, false)
+		AddItem(title, headingHeight, 0, false).
+		AddItem(tableContainer, 0, 1, true)
+
+	as.hideWhenCompact(as.view, title, headingHeight)
 
 	as.Update()
 	return as
@@ -487,45 +1188,42 @@ func (as *AgentsScreen) Update() {
 	// Headers with enhanced styling
 	headers := []string{"Agent Name", "Installed", "Configured"}
 	for col, header := range headers {
-		cell := tview.NewTableCell(fmt.Sprintf(" [#00d7ff::b]%s[::-] ", header)).
-			SetAlign(tview.AlignCenter).
-			SetSelectable(false).
-			SetBackgroundColor(tcell.ColorDarkSlateGray)
+		cell := headerCell(header).SetAlign(tview.AlignCenter)
 		as.table.SetCell(0, col, cell)
 	}
 
 	// Data rows with icons
 	agents := GetAllAgents()
 	for row, agent := range agents {
-		installedText := "[red]  ✗ Not Installed[-]"
+		installedText := fmt.Sprintf("[bad]  %s Not Installed[-]", icon("✗", "x"))
 		if agent.Installed {
-			installedText = "[green]  ✓ Installed[-]"
+			installedText = fmt.Sprintf("[good]  %s Installed[-]", icon("✓", "+"))
 		}
 
-		configuredText := "[red]  ✗ Not Configured[-]"
+		configuredText := fmt.Sprintf("[bad]  %s Not Configured[-]", icon("✗", "x"))
 		if agent.Configured {
-			configuredText = "[green]  ✓ Configured[-]"
+			configuredText = fmt.Sprintf("[good]  %s Configured[-]", icon("✓", "+"))
 		}
 
-		// Agent icon based on name
-		agentIcon := "🔧"
+		// Agent icon based on name, or a short tag in ASCII mode
+		agentIcon, agentTag := "🔧", "CLI"
 		switch {
 		case strings.Contains(agent.Name, "Claude"):
-			agentIcon = "🤖"
+			agentIcon, agentTag = "🤖", "CLD"
 		case strings.Contains(agent.Name, "Codex"):
-			agentIcon = "⚡"
+			agentIcon, agentTag = "⚡", "CDX"
 		case strings.Contains(agent.Name, "Gemini"):
-			agentIcon = "💎"
+			agentIcon, agentTag = "💎", "GEM"
 		case strings.Contains(agent.Name, "Amp"):
-			agentIcon = "🔊"
+			agentIcon, agentTag = "🔊", "AMP"
 		case strings.Contains(agent.Name, "OpenCode"):
-			agentIcon = "📝"
+			agentIcon, agentTag = "📝", "OPC"
 		case strings.Contains(agent.Name, "Droid"):
-			agentIcon = "🤖"
+			agentIcon, agentTag = "🤖", "DRD"
 		}
 
 		cells := []string{
-			fmt.Sprintf(" %s %s", agentIcon, agent.Name),
+			" " + iconText(agentIcon, agentTag, agent.Name),
 			installedText,
 			configuredText,
 		}
@@ -541,6 +1239,8 @@ func (as *AgentsScreen) Update() {
 
 // APIKeysScreen shows API key management
 type APIKeysScreen struct {
+	collapsible
+
 	view *tview.Flex
 	list *tview.List
 	pm   *ProxyManager
@@ -551,31 +1251,92 @@ func NewAPIKeysScreen(pm *ProxyManager, cfg *Config) *APIKeysScreen {
 	aks := &APIKeysScreen{pm: pm, cfg: cfg}
 
 	title := tview.NewTextView().
-		SetText("[#00d7ff::b]━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━\n         🔑 API KEYS\n━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━[::-]").
+		SetText(screenHeading("🔑", "API KEYS")).
 		SetTextAlign(tview.AlignCenter).
 		SetDynamicColors(true)
 
-	aks.list = tview.NewList().
+	aks.list = styleList(tview.NewList()).
 		ShowSecondaryText(true).
-		SetHighlightFullLine(true).
-		SetSecondaryTextColor(tcell.ColorGray)
-	aks.list.SetBorder(true).SetTitle(" Your API Keys ").SetBorderColor(tcell.ColorDodgerBlue)
-
-	help := tview.NewTextView().
-		SetText("[#5f87af]╔════════════════════════════════════════════════════════════╗\n║  [#87d7ff]G[-][white] Generate New Key   [#87d7ff]D[-][white] Delete Selected   [#87d7ff]Tab[-][white] Switch Focus  [#5f87af]║\n╚════════════════════════════════════════════════════════════╝[-]").
-		SetTextAlign(tview.AlignCenter).
-		SetDynamicColors(true)
+		SetHighlightFullLine(true)
+	aks.list.SetBorder(true).SetTitle(" Your API Keys ").SetBorderColor(theme.Border)
 
 	aks.view = tview.NewFlex().
 		SetDirection(tview.FlexRow).
-		AddItem(title, 4, 0, false).
-		AddItem(aks.list, 0, 1, true).
-		AddItem(help, 4, 0, false)
+		AddItem(title, headingHeight, 0, false).
+		AddItem(aks.list, 0, 1, true)
+
+	aks.hideWhenCompact(aks.view, title, headingHeight)
 
 	aks.Update()
 	return aks
 }
 
+// RegisterActions registers the API keys screen's own actions
+func (aks *APIKeysScreen) RegisterActions(reg *ActionRegistry) {
+	reg.Register(Action{
+		ID:          "apikeys.generate",
+		Title:       "Generate API key",
+		Description: "Create a new random API key",
+		Screen:      "apikeys",
+		Keys:        []string{"g", "G"},
+		Hint:        "Generate",
+		Run: func() {
+			newKey, err := GenerateSecureKey()
+			if err != nil {
+				aks.pm.AddLogExternal(LogLevelError, fmt.Sprintf("Failed to generate key: %v", err))
+				return
+			}
+			aks.pm.EditConfig(func(cfg *Config) {
+				cfg.APIKeys = append(cfg.APIKeys, newKey)
+			})
+			aks.Update()
+			aks.SaveKeys()
+			aks.pm.AddLogExternal(LogLevelInfo, "New secure API key generated")
+		},
+	})
+	reg.Register(Action{
+		ID:          "apikeys.rotate",
+		Title:       "Rotate API key",
+		Description: "Replace the selected API key with a new one",
+		Screen:      "apikeys",
+		Keys:        []string{"r", "R"},
+		Hint:        "Rotate",
+		Run:         aks.RotateSelectedKey,
+	})
+}
+
+// RotateSelectedKey replaces the selected API key with a newly generated one
+func (aks *APIKeysScreen) RotateSelectedKey() {
+	idx := aks.list.GetCurrentItem()
+	if idx < 0 || idx >= len(aks.cfg.APIKeys) {
+		return
+	}
+
+	newKey, err := GenerateSecureKey()
+	if err != nil {
+		aks.pm.AddLogExternal(LogLevelError, fmt.Sprintf("Failed to generate key: %v", err))
+		return
+	}
+	aks.pm.EditConfig(func(cfg *Config) {
+		cfg.APIKeys[idx] = newKey
+	})
+	aks.Update()
+	aks.list.SetCurrentItem(idx)
+	aks.SaveKeys()
+	aks.pm.AddLogExternal(LogLevelInfo, fmt.Sprintf("API key #%d rotated", idx+1))
+}
+
+// SaveKeys saves the config and writes the keys to the proxy config, which
+// the proxy reloads while running
+func (aks *APIKeysScreen) SaveKeys() {
+	if err := SaveConfig(aks.cfg); err != nil {
+		aks.pm.AddLogExternal(LogLevelError, fmt.Sprintf("Failed to save config: %v", err))
+	}
+	if err := aks.pm.WriteProxyConfig(); err != nil {
+		aks.pm.AddLogExternal(LogLevelError, fmt.Sprintf("Failed to update proxy config: %v", err))
+	}
+}
+
 // GetSelectedIndex returns the currently selected key index
 func (aks *APIKeysScreen) GetSelectedIndex() int {
 	return aks.list.GetCurrentItem()
@@ -586,7 +1347,9 @@ func (aks *APIKeysScreen) DeleteSelectedKey() bool {
 	idx := aks.list.GetCurrentItem()
 	if idx >= 0 && idx < len(aks.cfg.APIKeys) {
 		// Remove the key at index
-		aks.cfg.APIKeys = append(aks.cfg.APIKeys[:idx], aks.cfg.APIKeys[idx+1:]...)
+		aks.pm.EditConfig(func(cfg *Config) {
+			cfg.APIKeys = append(cfg.APIKeys[:idx], cfg.APIKeys[idx+1:]...)
+		})
 		return true
 	}
 	return false
@@ -600,23 +1363,37 @@ func (aks *APIKeysScreen) Update() {
 	aks.list.Clear()
 
 	if len(aks.cfg.APIKeys) == 0 {
-		aks.list.AddItem("  [gray]No API keys generated yet[-]", "  Press 'G' to generate your first key", 0, nil)
+		aks.list.AddItem("  [dim]No API keys generated yet[-]", "  Press 'G' to generate your first key", 0, nil)
 		return
 	}
 
 	for i, key := range aks.cfg.APIKeys {
-		// Mask the key for display
-		masked := key
-		if len(key) > 16 {
-			masked = key[:8] + "••••••••" + key[len(key)-4:]
-		}
-
-		mainText := fmt.Sprintf("  🔐 Key #%d", i+1)
-		secondaryText := fmt.Sprintf("     [#5f87af]%s[-]", masked)
+		mainText := "  " + iconText("🔐", "", fmt.Sprintf("Key #%d", i+1))
+		secondaryText := fmt.Sprintf("     [muted]%s[-]", maskAPIKey(key))
 		aks.list.AddItem(mainText, secondaryText, 0, nil)
 	}
 }
 
+// formatDownloadStats formats downloaded bytes, total size and speed
+func formatDownloadStats(stats DownloadStats) string {
+	if stats.Downloaded == 0 && stats.Total <= 0 {
+		return ""
+	}
+	size := formatBytes(stats.Downloaded)
+	if stats.Total > 0 {
+		size += " / " + formatBytes(stats.Total)
+	}
+	return fmt.Sprintf("%s · %s/s", size, formatBytes(int64(stats.Speed)))
+}
+
+// maskAPIKey masks an API key for display
+func maskAPIKey(key string) string {
+	if len(key) > 16 {
+		return key[:8] + "••••••••" + key[len(key)-4:]
+	}
+	return key
+}
+
 // GenerateSecureKey generates a cryptographically secure API key
 func GenerateSecureKey() (string, error) {
 	bytes := make([]byte, 24)
@@ -628,6 +1405,8 @@ func GenerateSecureKey() (string, error) {
 
 // LogsScreen shows application logs
 type LogsScreen struct {
+	collapsible
+
 	view     *tview.Flex
 	textView *tview.TextView
 	pm       *ProxyManager
@@ -637,7 +1416,7 @@ func NewLogsScreen(pm *ProxyManager) *LogsScreen {
 	ls := &LogsScreen{pm: pm}
 
 	title := tview.NewTextView().
-		SetText("[#00d7ff::b]━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━\n         📋 APPLICATION LOGS\n━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━[::-]").
+		SetText(screenHeading("📋", "APPLICATION LOGS")).
 		SetTextAlign(tview.AlignCenter).
 		SetDynamicColors(true)
 
@@ -648,27 +1427,63 @@ func NewLogsScreen(pm *ProxyManager) *LogsScreen {
 			// Auto-scroll to bottom
 			ls.textView.ScrollToEnd()
 		})
-	ls.textView.SetBorder(true).SetTitle(" Log Output ").SetBorderColor(tcell.ColorDodgerBlue)
-
-	help := tview.NewTextView().
-		SetText("[#5f87af]╔════════════════════════════════════════════════════════════╗\n║  [#87d7ff]C[-][white] Clear Logs   [#87d7ff]↑↓[-][white] Scroll   [#87d7ff]Tab[-][white] Switch Focus            [#5f87af]║\n╚════════════════════════════════════════════════════════════╝[-]").
-		SetTextAlign(tview.AlignCenter).
-		SetDynamicColors(true)
+	ls.textView.SetBorder(true).SetTitle(" Log Output ").SetBorderColor(theme.Border)
 
 	ls.view = tview.NewFlex().
 		SetDirection(tview.FlexRow).
-		AddItem(title, 4, 0, false).
-		AddItem(ls.textView, 0, 1, true).
-		AddItem(help, 4, 0, false)
+		AddItem(title, headingHeight, 0, false).
+		AddItem(ls.textView, 0, 1, true)
+
+	ls.hideWhenCompact(ls.view, title, headingHeight)
 
 	ls.Update()
 	return ls
 }
 
+// RegisterActions registers the logs screen's own actions
+func (ls *LogsScreen) RegisterActions(reg *ActionRegistry) {
+	reg.Register(Action{
+		ID:          "logs.clear",
+		Title:       "Clear logs",
+		Description: "Remove all log entries",
+		Screen:      "logs",
+		Keys:        []string{"c", "C"},
+		Hint:        "Clear",
+		Run: func() {
+			ls.pm.ClearLogs()
+			ls.Update()
+			ls.pm.AddLogExternal(LogLevelInfo, "Logs cleared")
+		},
+	})
+	reg.Register(Action{
+		ID:          "logs.export",
+		Title:       "Export logs",
+		Description: "Write all log entries to a file in the config directory",
+		Screen:      "logs",
+		Keys:        []string{"e", "E"},
+		Hint:        "Export",
+		Run: func() {
+			path, err := ls.pm.ExportLogs()
+			if err != nil {
+				ls.pm.AddLogExternal(LogLevelError, fmt.Sprintf("Failed to export logs: %v", err))
+				return
+			}
+			ls.pm.AddLogExternal(LogLevelInfo, fmt.Sprintf("Logs exported to %s", path))
+		},
+	})
+}
+
 func (ls *LogsScreen) GetView() tview.Primitive {
 	return ls.view
 }
 
+// HandleEvent shows new log entries as they are added
+func (ls *LogsScreen) HandleEvent(event Event) {
+	if event == EventLogAppended {
+		ls.Update()
+	}
+}
+
 func (ls *LogsScreen) Update() {
 	logs := ls.pm.GetLogs()
 
@@ -677,21 +1492,21 @@ func (ls *LogsScreen) Update() {
 		var levelIcon, levelColor string
 		switch log.Level {
 		case LogLevelInfo:
-			levelIcon = "ℹ️ "
-			levelColor = "#87d7ff"
+			levelIcon = icon("ℹ️", "*")
+			levelColor = "accent"
 		case LogLevelWarn:
-			levelIcon = "⚠️ "
-			levelColor = "yellow"
+			levelIcon = icon("⚠️", "!")
+			levelColor = "warning"
 		case LogLevelError:
-			levelIcon = "❌"
-			levelColor = "red"
+			levelIcon = icon("❌", "x")
+			levelColor = "bad"
 		case LogLevelDebug:
-			levelIcon = "🔍"
-			levelColor = "#5f87af"
+			levelIcon = icon("🔍", ".")
+			levelColor = "muted"
 		}
 
 		logText.WriteString(fmt.Sprintf(
-			" [#404040]%s[-]  %s [%s]%-5s[-]  %s\n",
+			" [faint]%s[-]  %s [%s]%-5s[-]  %s\n",
 			log.Timestamp.Format("15:04:05"),
 			levelIcon,
 			levelColor,
@@ -701,7 +1516,7 @@ func (ls *LogsScreen) Update() {
 	}
 
 	if logText.Len() == 0 {
-		logText.WriteString("\n  [gray]No logs available. Logs will appear here as events occur.[-]\n")
+		logText.WriteString("\n  [dim]No logs available. Logs will appear here as events occur.[-]\n")
 	}
 
 	ls.textView.SetText(logText.String())
@@ -709,26 +1524,30 @@ func (ls *LogsScreen) Update() {
 
 // SettingsScreen shows configuration form
 type SettingsScreen struct {
-	view *tview.Flex
-	form *tview.Form
-	pm   *ProxyManager
-	cfg  *Config
-	app  *tview.Application
+	collapsible
+
+	view          *tview.Flex
+	form          *tview.Form
+	pm            *ProxyManager
+	cfg           *Config // Copy edited by the form, applied when saved
+	app           *tview.Application
+	tasks         *TaskRunner
+	onEditPricing func()
+	onEditRouting func()
+	onSaved       func()
 }
 
-func NewSettingsScreen(pm *ProxyManager, cfg *Config, app *tview.Application) *SettingsScreen {
-	ss := &SettingsScreen{pm: pm, cfg: cfg, app: app}
+func NewSettingsScreen(pm *ProxyManager, app *tview.Application, tasks *TaskRunner) *SettingsScreen {
+	ss := &SettingsScreen{pm: pm, cfg: pm.Config(), app: app, tasks: tasks}
 
 	title := tview.NewTextView().
-		SetText("[#00d7ff::b]━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━\n         ⚙️  SETTINGS\n━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━[::-]").
+		SetText(screenHeading("⚙️", "SETTINGS")).
 		SetTextAlign(tview.AlignCenter).
 		SetDynamicColors(true)
 
 	ss.form = tview.NewForm()
-	ss.form.SetBorder(true).SetTitle(" Configuration ").SetBorderColor(tcell.ColorDodgerBlue)
-	ss.form.SetFieldBackgroundColor(tcell.ColorDarkSlateGray)
-	ss.form.SetButtonBackgroundColor(tcell.ColorDodgerBlue)
-	ss.form.SetLabelColor(tcell.ColorLightCyan)
+	ss.form.SetBorder(true).SetTitle(" Configuration ")
+	styleForm(ss.form)
 	ss.buildForm()
 
 	// Capture input to prevent global shortcuts while editing
@@ -737,16 +1556,12 @@ func NewSettingsScreen(pm *ProxyManager, cfg *Config, app *tview.Application) *S
 		return event
 	})
 
-	help := tview.NewTextView().
-		SetText("[#5f87af]╔════════════════════════════════════════════════════════════╗\n║  [#87d7ff]↑↓[-][white] Navigate   [#87d7ff]Enter[-][white] Edit/Toggle   [#87d7ff]Tab[-][white] Switch Focus       [#5f87af]║\n╚════════════════════════════════════════════════════════════╝[-]").
-		SetTextAlign(tview.AlignCenter).
-		SetDynamicColors(true)
-
 	ss.view = tview.NewFlex().
 		SetDirection(tview.FlexRow).
-		AddItem(title, 4, 0, false).
-		AddItem(ss.form, 0, 1, true).
-		AddItem(help, 4, 0, false)
+		AddItem(title, headingHeight, 0, false).
+		AddItem(ss.form, 0, 1, true)
+
+	ss.hideWhenCompact(ss.view, title, headingHeight)
 
 	return ss
 }
@@ -755,6 +1570,27 @@ func (ss *SettingsScreen) GetView() tview.Primitive {
 	return ss.view
 }
 
+// SetPricingHandler sets the function that opens the price table editor
+func (ss *SettingsScreen) SetPricingHandler(handler func()) {
+	ss.onEditPricing = handler
+}
+
+// SetRoutingHandler sets the function that opens the routing editor
+func (ss *SettingsScreen) SetRoutingHandler(handler func()) {
+	ss.onEditRouting = handler
+}
+
+// EditDraft applies a change made outside the form, such as by the pricing or
+// routing editor, to the settings being edited, so saving keeps it
+func (ss *SettingsScreen) EditDraft(change func(cfg *Config)) {
+	change(ss.cfg)
+}
+
+// SetSavedHandler sets the function called after settings are saved
+func (ss *SettingsScreen) SetSavedHandler(handler func()) {
+	ss.onSaved = handler
+}
+
 func (ss *SettingsScreen) Update() {
 	// Settings don't need dynamic updates
 }
@@ -771,17 +1607,18 @@ func (ss *SettingsScreen) buildForm() {
 		}
 	})
 
-	// Routing Strategy
+	// Routing Strategy; providers can override it in the routing editor
+	strategies := RoutingStrategies()
+	strategyNames := make([]string, len(strategies))
 	strategyIndex := 0
-	if ss.cfg.RoutingStrategy == RoutingFillFirst {
-		strategyIndex = 1
-	}
-	ss.form.AddDropDown("Routing Strategy", []string{"Round Robin", "Fill First"}, strategyIndex, func(option string, optionIndex int) {
-		if optionIndex == 0 {
-			ss.cfg.RoutingStrategy = RoutingRoundRobin
-		} else {
-			ss.cfg.RoutingStrategy = RoutingFillFirst
+	for i, strategy := range strategies {
+		strategyNames[i] = RoutingStrategyName(strategy)
+		if strategy == ss.cfg.RoutingStrategy {
+			strategyIndex = i
 		}
+	}
+	ss.form.AddDropDown("Routing Strategy", strategyNames, strategyIndex, func(option string, optionIndex int) {
+		ss.cfg.RoutingStrategy = strategies[optionIndex]
 	})
 
 	// Auto-start
@@ -813,30 +1650,321 @@ func (ss *SettingsScreen) buildForm() {
 		}
 	})
 
-	// Buttons
-	ss.form.AddButton("Save", func() {
-		if err := SaveConfig(ss.cfg); err != nil {
-			ss.pm.AddLogExternal(LogLevelError, fmt.Sprintf("Failed to save config: %v", err))
+	// What happens to an account whose quota is exceeded; the proxy only
+	// switches project or model when skipping
+	behaviors := QuotaExceededBehaviors()
+	behaviorIndex := 0
+	for i, behavior := range behaviors {
+		if behavior == ss.cfg.QuotaExceededBehavior {
+			behaviorIndex = i
+		}
+	}
+	ss.form.AddDropDown("Quota Exceeded", []string{"Skip Account", "Pause Account", "Continue"}, behaviorIndex, func(option string, optionIndex int) {
+		ss.cfg.QuotaExceededBehavior = behaviors[optionIndex]
+	})
+	switchProject, switchPreview := true, true
+	if ss.cfg.QuotaSwitchProject != nil {
+		switchProject = *ss.cfg.QuotaSwitchProject
+	}
+	if ss.cfg.QuotaSwitchPreview != nil {
+		switchPreview = *ss.cfg.QuotaSwitchPreview
+	}
+	ss.form.AddCheckbox("Switch Project", switchProject, func(checked bool) {
+		ss.cfg.QuotaSwitchProject = &checked
+	})
+	ss.form.AddCheckbox("Switch Preview Model", switchPreview, func(checked bool) {
+		ss.cfg.QuotaSwitchPreview = &checked
+	})
+
+	// Binary versions kept for rollback
+	keepVersions := ss.cfg.KeepVersions
+	if keepVersions <= 0 {
+		keepVersions = defaultKeepVersions
+	}
+	ss.form.AddInputField("Keep Binary Versions", fmt.Sprintf("%d", keepVersions), 20, nil, func(text string) {
+		var count int
+		fmt.Sscanf(text, "%d", &count)
+		if count > 0 {
+			ss.cfg.KeepVersions = count
+		}
+	})
+
+	// Release source
+	repo := ss.cfg.ReleaseRepo
+	if repo == "" {
+		repo = githubRepo
+	}
+	ss.form.AddInputField("Release Repo", repo, 40, nil, func(text string) {
+		text = strings.TrimSpace(text)
+		if text == githubRepo {
+			text = ""
+		}
+		ss.cfg.ReleaseRepo = text
+	})
+
+	channelIndex := 0
+	if ss.cfg.ReleaseChannel == ReleaseChannelPrerelease {
+		channelIndex = 1
+	}
+	ss.form.AddDropDown("Release Channel", []string{"Latest", "Prerelease"}, channelIndex, func(option string, optionIndex int) {
+		if optionIndex == 0 {
+			ss.cfg.ReleaseChannel = ReleaseChannelLatest
 		} else {
-			// Update proxy manager config
-			ss.pm.UpdateConfig()
-			ss.pm.AddLogExternal(LogLevelInfo, "Configuration saved successfully")
+			ss.cfg.ReleaseChannel = ReleaseChannelPrerelease
 		}
 	})
 
+	ss.form.AddInputField("Pinned Version", ss.cfg.PinnedVersion, 20, nil, func(text string) {
+		ss.cfg.PinnedVersion = strings.TrimSpace(text)
+	})
+
+	ss.form.AddInputField("Release Mirror", ss.cfg.ReleaseMirror, 40, nil, func(text string) {
+		ss.cfg.ReleaseMirror = strings.TrimSpace(text)
+	})
+
+	// Existing binary on disk or in PATH, instead of installed releases
+	ss.form.AddInputField("Binary Path", ss.cfg.BinaryPath, 40, nil, func(text string) {
+		ss.cfg.BinaryPath = strings.TrimSpace(text)
+	})
+
+	// Keymap preset; individual keys are remapped in config.json
+	presets := KeymapPresets()
+	presetNames := make([]string, len(presets))
+	presetIndex := 0
+	for i, preset := range presets {
+		presetNames[i] = strings.ToUpper(string(preset[:1])) + string(preset[1:])
+		if preset == ss.cfg.Keymap.Preset {
+			presetIndex = i
+		}
+	}
+	ss.form.AddDropDown("Keymap Preset", presetNames, presetIndex, func(option string, optionIndex int) {
+		ss.cfg.Keymap.Preset = presets[optionIndex]
+		if ss.cfg.Keymap.Preset == KeymapPresetDefault {
+			ss.cfg.Keymap.Preset = ""
+		}
+	})
+
+	// Theme; custom themes are listed when the config names one
+	themes := BuiltinThemes()
+	if _, ok := builtinThemes[ss.cfg.Theme]; ss.cfg.Theme != "" && !ok {
+		themes = append(themes, ss.cfg.Theme)
+	}
+	themeNames := make([]string, len(themes))
+	themeIndex := 0
+	for i, name := range themes {
+		themeNames[i] = strings.ToUpper(name[:1]) + name[1:]
+		if name == ss.cfg.Theme {
+			themeIndex = i
+		}
+	}
+	ss.form.AddDropDown("Theme", themeNames, themeIndex, func(option string, optionIndex int) {
+		ss.cfg.Theme = themes[optionIndex]
+		if ss.cfg.Theme == ThemeDark {
+			ss.cfg.Theme = ""
+		}
+	})
+
+	// Emoji or ASCII icons; auto picks ASCII on terminals without emoji
+	iconModes := IconModes()
+	iconIndex := 0
+	for i, mode := range iconModes {
+		if mode == ss.cfg.Icons {
+			iconIndex = i
+		}
+	}
+	ss.form.AddDropDown("Icons", []string{"Auto", "Emoji", "ASCII"}, iconIndex, func(option string, optionIndex int) {
+		ss.cfg.Icons = iconModes[optionIndex]
+		if ss.cfg.Icons == IconModeAuto {
+			ss.cfg.Icons = ""
+		}
+	})
+
+	// Compact layout for small terminals; auto picks it by terminal size
+	layoutModes := LayoutModes()
+	layoutIndex := 0
+	for i, mode := range layoutModes {
+		if mode == ss.cfg.Layout {
+			layoutIndex = i
+		}
+	}
+	ss.form.AddDropDown("Layout", []string{"Auto", "Full", "Compact"}, layoutIndex, func(option string, optionIndex int) {
+		ss.cfg.Layout = layoutModes[optionIndex]
+		if ss.cfg.Layout == LayoutAuto {
+			ss.cfg.Layout = ""
+		}
+	})
+
+	// Alerts; rules and the cooldown are edited in config.json
+	ss.form.AddCheckbox("Alerts", ss.cfg.Alerts.Enabled, func(checked bool) {
+		ss.cfg.Alerts.Enabled = checked
+	})
+	ss.form.AddCheckbox("Alert: Terminal Bell", ss.cfg.Alerts.Bell, func(checked bool) {
+		ss.cfg.Alerts.Bell = checked
+	})
+	ss.form.AddCheckbox("Alert: Desktop", ss.cfg.Alerts.Desktop, func(checked bool) {
+		ss.cfg.Alerts.Desktop = checked
+	})
+	ss.form.AddInputField("Alert: Webhook URL", ss.cfg.Alerts.WebhookURL, 40, nil, func(text string) {
+		ss.cfg.Alerts.WebhookURL = strings.TrimSpace(text)
+	})
+	ss.form.AddInputField("Alert: Command", ss.cfg.Alerts.Command, 40, nil, func(text string) {
+		ss.cfg.Alerts.Command = strings.TrimSpace(text)
+	})
+	ss.form.AddInputField("Quiet Hours", ss.cfg.Alerts.QuietHours, 20, nil, func(text string) {
+		ss.cfg.Alerts.QuietHours = strings.TrimSpace(text)
+	})
+
+	// Buttons
+	ss.form.AddButton("Save", ss.save)
+
+	ss.form.AddButton("Pricing", func() {
+		if ss.onEditPricing != nil {
+			ss.onEditPricing()
+		}
+	})
+
+	ss.form.AddButton("Routing", func() {
+		if ss.onEditRouting != nil {
+			ss.onEditRouting()
+		}
+	})
+
+	ss.form.AddButton("Test Alert", ss.testAlert)
+
 	ss.form.AddButton("Reset", func() {
-		// Reset by copying default values to the existing config pointer
+		// Reset by copying default values to the settings being edited
 		defaultCfg := NewDefaultConfig()
 		ss.cfg.Port = defaultCfg.Port
 		ss.cfg.RoutingStrategy = defaultCfg.RoutingStrategy
+		ss.cfg.RoutingOverrides = defaultCfg.RoutingOverrides
+		ss.cfg.RoutingWeights = defaultCfg.RoutingWeights
+		ss.cfg.RoutingOrder = defaultCfg.RoutingOrder
 		ss.cfg.AutoStart = defaultCfg.AutoStart
 		ss.cfg.DebugMode = defaultCfg.DebugMode
 		ss.cfg.LogToFile = defaultCfg.LogToFile
 		ss.cfg.UsageStatsEnabled = defaultCfg.UsageStatsEnabled
 		ss.cfg.RequestRetryCount = defaultCfg.RequestRetryCount
 		ss.cfg.QuotaExceededBehavior = defaultCfg.QuotaExceededBehavior
+		ss.cfg.QuotaSwitchProject = defaultCfg.QuotaSwitchProject
+		ss.cfg.QuotaSwitchPreview = defaultCfg.QuotaSwitchPreview
+		ss.cfg.KeepVersions = defaultCfg.KeepVersions
+		ss.cfg.ReleaseRepo = defaultCfg.ReleaseRepo
+		ss.cfg.ReleaseChannel = defaultCfg.ReleaseChannel
+		ss.cfg.PinnedVersion = defaultCfg.PinnedVersion
+		ss.cfg.ReleaseMirror = defaultCfg.ReleaseMirror
+		ss.cfg.BinaryPath = defaultCfg.BinaryPath
+		ss.cfg.Keymap.Preset = defaultCfg.Keymap.Preset
+		ss.cfg.Theme = defaultCfg.Theme
+		ss.cfg.Icons = defaultCfg.Icons
+		ss.cfg.Layout = defaultCfg.Layout
+		ss.cfg.Alerts.Enabled = defaultCfg.Alerts.Enabled
+		ss.cfg.Alerts.Bell = defaultCfg.Alerts.Bell
+		ss.cfg.Alerts.Desktop = defaultCfg.Alerts.Desktop
+		ss.cfg.Alerts.WebhookURL = defaultCfg.Alerts.WebhookURL
+		ss.cfg.Alerts.Command = defaultCfg.Alerts.Command
+		ss.cfg.Alerts.QuietHours = defaultCfg.Alerts.QuietHours
 		// Keep existing API keys on reset
 		ss.buildForm()
 		ss.pm.AddLogExternal(LogLevelInfo, "Configuration reset to defaults")
 	})
 }
+
+// testAlert sends a test alert to the notifiers as currently set in the form
+func (ss *SettingsScreen) testAlert() {
+	cfg := ss.cfg.Alerts
+	if err := ValidateAlerts(cfg); err != nil {
+		ss.pm.AddLogExternal(LogLevelError, fmt.Sprintf("Invalid settings: %v", err))
+		return
+	}
+	if len(Notifiers(cfg)) == 0 {
+		ss.pm.AddLogExternal(LogLevelWarn, "No alert notifiers are enabled")
+		return
+	}
+
+	now := time.Now()
+	alert := Alert{
+		Key:     "test",
+		Kind:    "test",
+		Profile: ss.pm.GetProfile(),
+		Title:   "Test alert",
+		Message: "Alerts from LazyL2M arrive here",
+		Time:    now,
+		Since:   now,
+	}
+	go func() {
+		errs := SendAlert(cfg, alert)
+		for _, err := range errs {
+			ss.pm.AddLogExternal(LogLevelError, fmt.Sprintf("Failed to send alert by %v", err))
+		}
+		if len(errs) == 0 {
+			ss.pm.AddLogExternal(LogLevelInfo, "Test alert sent")
+		}
+	}()
+}
+
+// save checks the settings and saves them. They are checked on a copy, so
+// the profile keeps its settings until they pass. A custom binary is run to
+// check it, which can take seconds, so that happens in the background.
+func (ss *SettingsScreen) save() {
+	invalid := func(err error) {
+		ss.pm.AddLogExternal(LogLevelError, fmt.Sprintf("Invalid settings: %v", err))
+	}
+	draft := ss.cfg.Clone()
+	if err := ValidateRepo(draft.ReleaseRepo); err != nil {
+		invalid(err)
+		return
+	}
+	if err := ValidateMirror(draft.ReleaseMirror); err != nil {
+		invalid(err)
+		return
+	}
+	if err := ValidateAlerts(draft.Alerts); err != nil {
+		invalid(err)
+		return
+	}
+	if err := ValidateRouting(draft); err != nil {
+		invalid(err)
+		return
+	}
+
+	binaryPath := draft.BinaryPath
+	if binaryPath == "" {
+		ss.commit(draft)
+		return
+	}
+	var path, version string
+	ss.tasks.Run(taskKey(ss.pm, taskSettings), "Checking binary", func() error {
+		var err error
+		path, version, err = ValidateBinary(binaryPath)
+		return err
+	}, func(err error) {
+		if err != nil {
+			ss.tasks.Report(ss.pm, LogLevelError, fmt.Sprintf("Invalid binary path: %v", err))
+			return
+		}
+		ss.pm.AddLogExternal(LogLevelInfo, fmt.Sprintf("Using CLIProxyAPI %s at %s", version, path))
+		ss.commit(draft)
+	})
+}
+
+// commit applies checked settings to the profile and saves them
+func (ss *SettingsScreen) commit(draft *Config) {
+	ss.pm.EditConfig(func(cfg *Config) {
+		// API keys are managed on their own screen
+		apiKeys := cfg.APIKeys
+		*cfg = *draft
+		cfg.APIKeys = apiKeys
+	})
+	if err := SaveConfig(ss.pm.Config()); err != nil {
+		ss.pm.AddLogExternal(LogLevelError, fmt.Sprintf("Failed to save config: %v", err))
+		return
+	}
+
+	// Update proxy manager config
+	ss.pm.UpdateConfig()
+	if ss.onSaved != nil {
+		ss.onSaved()
+	}
+	ss.pm.AddLogExternal(LogLevelInfo, "Configuration saved successfully")
+	ss.tasks.Run(taskKey(ss.pm, taskAccounts), "Applying account settings", ss.pm.ApplyAccountRules, nil)
+}
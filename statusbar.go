@@ -0,0 +1,173 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/rivo/tview"
+)
+
+// StatusBar runs along the bottom of every screen. Its first row shows the
+// state of the proxy, running tasks and messages; the second row shows the
+// keys of the current screen.
+type StatusBar struct {
+	view     *tview.Flex
+	state    *tview.TextView
+	tasks    *tview.TextView
+	hints    *tview.TextView
+	globals  *tview.TextView
+	stateRow *tview.Flex
+	hintRow  *tview.Flex
+	pm       *ProxyManager
+	runner   *TaskRunner
+	seenLog  time.Time // Time of the newest log entry checked for errors
+}
+
+// NewStatusBar creates a status bar for the proxy of pm, showing the tasks of
+// runner
+func NewStatusBar(pm *ProxyManager, runner *TaskRunner) *StatusBar {
+	sb := &StatusBar{runner: runner}
+
+	sb.state = tview.NewTextView().SetDynamicColors(true).SetWrap(false)
+	sb.tasks = tview.NewTextView().SetDynamicColors(true).SetWrap(false).SetTextAlign(tview.AlignRight)
+	// Hints that don't fit wrap onto a hidden second line, so none is cut off
+	sb.hints = tview.NewTextView().SetDynamicColors(true).SetWordWrap(true)
+	sb.globals = tview.NewTextView().SetDynamicColors(true).SetWrap(false).SetTextAlign(tview.AlignRight)
+
+	sb.stateRow = tview.NewFlex().
+		AddItem(sb.state, 0, 0, false).
+		AddItem(sb.tasks, 0, 1, false)
+	sb.hintRow = tview.NewFlex().
+		AddItem(sb.hints, 0, 1, false).
+		AddItem(sb.globals, 0, 0, false)
+
+	sb.view = tview.NewFlex().
+		SetDirection(tview.FlexRow).
+		AddItem(sb.stateRow, 1, 0, false).
+		AddItem(sb.hintRow, 1, 0, false)
+
+	sb.SetProxyManager(pm)
+	return sb
+}
+
+// GetView returns the status bar's primitive
+func (sb *StatusBar) GetView() tview.Primitive {
+	return sb.view
+}
+
+// Restyle applies the current theme
+func (sb *StatusBar) Restyle() {
+	for _, text := range []*tview.TextView{sb.state, sb.tasks, sb.hints, sb.globals} {
+		text.SetBackgroundColor(theme.Background)
+	}
+	sb.view.SetBackgroundColor(theme.Background)
+}
+
+// SetProxyManager shows the proxy of another profile. Errors logged before
+// the switch are not shown.
+func (sb *StatusBar) SetProxyManager(pm *ProxyManager) {
+	sb.pm = pm
+	sb.seenLog = time.Now()
+	sb.Update()
+}
+
+// SetHints shows the keys of the actions of screen that have a hint, and
+// those of the global actions on the right
+func (sb *StatusBar) SetHints(actions *ActionRegistry, screen string) {
+	var hints, globals []string
+	for _, action := range actions.Actions(screen) {
+		if action.Hint == "" || action.KeyLabel() == "" {
+			continue
+		}
+		hint := fmt.Sprintf("[accent]%s[-]\u00a0[text]%s[-]", tview.Escape(action.KeyLabel()), strings.ReplaceAll(action.Hint, " ", "\u00a0"))
+		switch action.Screen {
+		case screen:
+			hints = append(hints, hint)
+		case "":
+			globals = append(globals, hint)
+		}
+	}
+
+	sb.hints.SetText(" " + strings.Join(hints, "  "))
+	text := strings.Join(globals, "  ") + " "
+	sb.globals.SetText(text)
+	sb.hintRow.ResizeItem(sb.globals, tview.TaggedStringWidth(text), 0)
+}
+
+// Update redraws the state of the proxy and the running tasks
+func (sb *StatusBar) Update() {
+	sb.updateState()
+	sb.UpdateTasks()
+}
+
+// UpdateTasks redraws the running tasks and the last message
+func (sb *StatusBar) UpdateTasks() {
+	status := sb.runner.Status()
+	if status != "" {
+		status += " "
+	}
+	sb.tasks.SetText(status)
+}
+
+// HandleEvent redraws the state when it changed and shows errors that were
+// logged as messages
+func (sb *StatusBar) HandleEvent(event Event) {
+	switch event {
+	case EventLogAppended:
+		sb.notifyErrors()
+	case EventStatusChanged, EventAccountsChanged, EventStatsUpdated:
+		sb.updateState()
+	}
+}
+
+// updateState shows whether the proxy runs, its port, request rate and the
+// health of its accounts
+func (sb *StatusBar) updateState() {
+	status := sb.pm.GetStatus()
+
+	state := "[bad]● STOPPED[-]"
+	switch {
+	case !sb.pm.IsBinaryInstalled():
+		state = "[bad]● NOT INSTALLED[-]"
+	case status.Starting:
+		state = "[warning]● STARTING[-]"
+	case status.Stopping:
+		state = "[warning]● STOPPING[-]"
+	case status.Running:
+		state = "[good]● RUNNING[-]"
+	}
+
+	parts := []string{state, fmt.Sprintf("[muted]port[-] [text]%d[-]", status.Port)}
+	if status.Running {
+		parts = append(parts, fmt.Sprintf("[text]%.1f[-] [muted]req/min[-]", sb.pm.GetUsageHistory().RequestRate()))
+	}
+
+	health := sb.pm.GetAccountHealth()
+	accounts := fmt.Sprintf("[muted]accounts[-] [good]%d ok[-]", health.OK)
+	if health.Warning > 0 {
+		accounts += fmt.Sprintf(" [warning]%d low[-]", health.Warning)
+	}
+	if health.Failed > 0 {
+		accounts += fmt.Sprintf(" [bad]%d failing[-]", health.Failed)
+	}
+	parts = append(parts, accounts)
+
+	text := " " + strings.Join(parts, "  [faint]│[-]  ") + "  "
+	sb.state.SetText(text)
+	sb.stateRow.ResizeItem(sb.state, tview.TaggedStringWidth(text), 0)
+}
+
+// notifyErrors shows the newest error logged since the last check
+func (sb *StatusBar) notifyErrors() {
+	logs := sb.pm.GetLogs()
+	for i := len(logs) - 1; i >= 0 && logs[i].Timestamp.After(sb.seenLog); i-- {
+		if logs[i].Level == LogLevelError {
+			sb.runner.Notify(LogLevelError, logs[i].Message)
+			break
+		}
+	}
+	if len(logs) > 0 {
+		sb.seenLog = logs[len(logs)-1].Timestamp
+	}
+}
@@ -0,0 +1,194 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/rivo/tview"
+)
+
+const (
+	// Kinds of tasks; tasks of the same kind and profile never overlap
+	taskProxy    = "proxy"    // Starting, stopping or restarting the proxy
+	taskRefresh  = "refresh"  // Fetching accounts and stats from the proxy
+	taskAccounts = "accounts" // Pausing, resuming, holding or releasing accounts
+	taskVersion  = "version"  // Running the binary to find its version
+	taskSettings = "settings" // Checking settings before they are saved
+
+	statusMessageTimeout = 5 * time.Second
+	spinnerInterval      = 100 * time.Millisecond
+)
+
+var (
+	spinnerFrames      = []string{"⠋", "⠙", "⠹", "⠸", "⠼", "⠴", "⠦", "⠧", "⠇", "⠏"}
+	asciiSpinnerFrames = []string{"|", "/", "-", `\`}
+)
+
+// taskKey returns the key of a kind of task for the profile of pm
+func taskKey(pm *ProxyManager, kind string) string {
+	return pm.GetProfile() + "/" + kind
+}
+
+// runningTask is a task that has not finished yet
+type runningTask struct {
+	key   string
+	label string
+}
+
+// TaskRunner runs slow operations, such as starting the proxy or fetching
+// from its API, off the event loop. A task is rejected while another one with
+// the same key runs. Running tasks and the last status message are shown in
+// the status line.
+type TaskRunner struct {
+	app      *tview.Application
+	onChange func() // Redraws the status line, called on the event loop
+
+	mutex     sync.Mutex
+	running   []runningTask
+	animating bool // The spinner goroutine is running
+	frame     int
+	message   string
+	level     LogLevel
+	messageAt time.Time
+}
+
+// NewTaskRunner creates a task runner that calls onChange on the event loop
+// whenever its status changes
+func NewTaskRunner(app *tview.Application, onChange func()) *TaskRunner {
+	return &TaskRunner{app: app, onChange: onChange}
+}
+
+// Run runs task in the background unless a task with the same key is
+// running. label describes the task while it runs, e.g. "Starting proxy".
+// done is called on the event loop with the task's error. Run must be called
+// on the event loop; it returns false and shows a message if the task was
+// rejected.
+func (r *TaskRunner) Run(key, label string, task func() error, done func(err error)) bool {
+	r.mutex.Lock()
+	for _, t := range r.running {
+		if t.key == key {
+			r.mutex.Unlock()
+			r.Notify(LogLevelWarn, fmt.Sprintf("%s, please wait", t.label))
+			return false
+		}
+	}
+	r.running = append(r.running, runningTask{key: key, label: label})
+	animate := !r.animating
+	r.animating = true
+	r.mutex.Unlock()
+
+	if animate {
+		go r.animate()
+	}
+
+	go func() {
+		err := task()
+
+		r.mutex.Lock()
+		for i, t := range r.running {
+			if t.key == key {
+				r.running = append(r.running[:i], r.running[i+1:]...)
+				break
+			}
+		}
+		r.mutex.Unlock()
+
+		r.app.QueueUpdateDraw(func() {
+			if done != nil {
+				done(err)
+			}
+			r.onChange()
+		})
+	}()
+
+	r.onChange()
+	return true
+}
+
+// Running reports whether a task with the given key is running. It must be
+// called on the event loop.
+func (r *TaskRunner) Running(key string) bool {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	for _, t := range r.running {
+		if t.key == key {
+			return true
+		}
+	}
+	return false
+}
+
+// Notify shows a message in the status line for a few seconds. It must be
+// called on the event loop.
+func (r *TaskRunner) Notify(level LogLevel, message string) {
+	r.mutex.Lock()
+	r.message, r.level, r.messageAt = message, level, time.Now()
+	r.mutex.Unlock()
+
+	time.AfterFunc(statusMessageTimeout, func() {
+		r.app.QueueUpdateDraw(r.onChange)
+	})
+	r.onChange()
+}
+
+// Report logs a message to pm and shows it in the status line. It must be
+// called on the event loop.
+func (r *TaskRunner) Report(pm *ProxyManager, level LogLevel, message string) {
+	pm.AddLogExternal(level, message)
+	r.Notify(level, message)
+}
+
+// animate advances the spinner while tasks are running
+func (r *TaskRunner) animate() {
+	ticker := time.NewTicker(spinnerInterval)
+	defer ticker.Stop()
+
+	for range ticker.C {
+		r.mutex.Lock()
+		r.frame++
+		idle := len(r.running) == 0
+		if idle {
+			r.animating = false
+		}
+		r.mutex.Unlock()
+		if idle {
+			return
+		}
+		r.app.QueueUpdateDraw(r.onChange)
+	}
+}
+
+// Status returns a spinner with the running tasks, followed by the last
+// message while it is recent
+func (r *TaskRunner) Status() string {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	var parts []string
+	if len(r.running) > 0 {
+		frames := spinnerFrames
+		if asciiIcons {
+			frames = asciiSpinnerFrames
+		}
+		labels := make([]string, len(r.running))
+		for i, t := range r.running {
+			labels[i] = t.label + "..."
+		}
+		parts = append(parts, fmt.Sprintf("[accent]%s[-] [text]%s[-]", frames[r.frame%len(frames)], tview.Escape(strings.Join(labels, " · "))))
+	}
+
+	if r.message != "" && time.Since(r.messageAt) < statusMessageTimeout {
+		color := "text"
+		switch r.level {
+		case LogLevelWarn:
+			color = "warning"
+		case LogLevelError:
+			color = "bad"
+		}
+		parts = append(parts, fmt.Sprintf("[%s]%s[-]", color, tview.Escape(r.message)))
+	}
+
+	return strings.Join(parts, "  [faint]│[-]  ")
+}
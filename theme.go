@@ -0,0 +1,376 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/gdamore/tcell/v2"
+	"github.com/rivo/tview"
+)
+
+const (
+	themesDir = "themes"
+
+	ThemeDark         = "dark"
+	ThemeLight        = "light"
+	ThemeHighContrast = "high-contrast"
+	ThemeNoColor      = "no-color"
+)
+
+// Theme holds the colors of the UI
+type Theme struct {
+	Name string
+
+	// Widget colors
+	Background    tcell.Color
+	Text          tcell.Color
+	Border        tcell.Color
+	Title         tcell.Color
+	Label         tcell.Color // Form labels and prompts
+	Field         tcell.Color // Input fields, dialogs and table headers
+	Selection     tcell.Color // Selected list items and buttons
+	SelectionText tcell.Color
+	Secondary     tcell.Color // Secondary text of list items
+	Danger        tcell.Color // Buttons of destructive dialogs
+
+	// Text colors, used in color tags as [heading], [accent], ...
+	Heading tcell.Color
+	Accent  tcell.Color
+	Muted   tcell.Color
+	Dim     tcell.Color
+	Faint   tcell.Color
+	Good    tcell.Color
+	Warning tcell.Color
+	Bad     tcell.Color
+	Info    tcell.Color
+
+	// Monochrome themes mark selections with reverse video instead of colors
+	Monochrome bool
+}
+
+// themeRole names a color of a theme, as used in theme files and color tags
+type themeRole struct {
+	name  string
+	tag   bool // Usable as a color tag in text
+	color func(t *Theme) *tcell.Color
+}
+
+var themeRoles = []themeRole{
+	{"background", false, func(t *Theme) *tcell.Color { return &t.Background }},
+	{"text", true, func(t *Theme) *tcell.Color { return &t.Text }},
+	{"border", false, func(t *Theme) *tcell.Color { return &t.Border }},
+	{"title", false, func(t *Theme) *tcell.Color { return &t.Title }},
+	{"label", false, func(t *Theme) *tcell.Color { return &t.Label }},
+	{"field", false, func(t *Theme) *tcell.Color { return &t.Field }},
+	{"selection", false, func(t *Theme) *tcell.Color { return &t.Selection }},
+	{"selection_text", false, func(t *Theme) *tcell.Color { return &t.SelectionText }},
+	{"secondary", false, func(t *Theme) *tcell.Color { return &t.Secondary }},
+	{"danger", false, func(t *Theme) *tcell.Color { return &t.Danger }},
+	{"heading", true, func(t *Theme) *tcell.Color { return &t.Heading }},
+	{"accent", true, func(t *Theme) *tcell.Color { return &t.Accent }},
+	{"muted", true, func(t *Theme) *tcell.Color { return &t.Muted }},
+	{"dim", true, func(t *Theme) *tcell.Color { return &t.Dim }},
+	{"faint", true, func(t *Theme) *tcell.Color { return &t.Faint }},
+	{"good", true, func(t *Theme) *tcell.Color { return &t.Good }},
+	{"warning", true, func(t *Theme) *tcell.Color { return &t.Warning }},
+	{"bad", true, func(t *Theme) *tcell.Color { return &t.Bad }},
+	{"info", true, func(t *Theme) *tcell.Color { return &t.Info }},
+}
+
+// builtinThemes are the themes shipped with LazyL2M
+var builtinThemes = map[string]Theme{
+	ThemeDark: {
+		Name:          ThemeDark,
+		Background:    tcell.ColorBlack,
+		Text:          tcell.ColorWhite,
+		Border:        tcell.ColorDodgerBlue,
+		Title:         tcell.ColorLightCyan,
+		Label:         tcell.ColorLightCyan,
+		Field:         tcell.ColorDarkSlateGray,
+		Selection:     tcell.ColorDodgerBlue,
+		SelectionText: tcell.ColorBlack,
+		Secondary:     tcell.ColorGray,
+		Danger:        tcell.ColorIndianRed,
+		Heading:       tcell.GetColor("#00d7ff"),
+		Accent:        tcell.GetColor("#87d7ff"),
+		Muted:         tcell.GetColor("#5f87af"),
+		Dim:           tcell.ColorGray,
+		Faint:         tcell.GetColor("#404040"),
+		Good:          tcell.ColorGreen,
+		Warning:       tcell.ColorYellow,
+		Bad:           tcell.ColorRed,
+		Info:          tcell.ColorAqua,
+	},
+	ThemeLight: {
+		Name:          ThemeLight,
+		Background:    tcell.ColorWhite,
+		Text:          tcell.ColorBlack,
+		Border:        tcell.GetColor("#005fd7"),
+		Title:         tcell.GetColor("#005f87"),
+		Label:         tcell.GetColor("#005f87"),
+		Field:         tcell.GetColor("#dadada"),
+		Selection:     tcell.GetColor("#005fd7"),
+		SelectionText: tcell.ColorWhite,
+		Secondary:     tcell.GetColor("#585858"),
+		Danger:        tcell.GetColor("#d70000"),
+		Heading:       tcell.GetColor("#005f87"),
+		Accent:        tcell.GetColor("#005fd7"),
+		Muted:         tcell.GetColor("#5f5f87"),
+		Dim:           tcell.GetColor("#6c6c6c"),
+		Faint:         tcell.GetColor("#bcbcbc"),
+		Good:          tcell.GetColor("#008700"),
+		Warning:       tcell.GetColor("#af5f00"),
+		Bad:           tcell.GetColor("#d70000"),
+		Info:          tcell.GetColor("#008787"),
+	},
+	ThemeHighContrast: {
+		Name:          ThemeHighContrast,
+		Background:    tcell.ColorBlack,
+		Text:          tcell.ColorWhite,
+		Border:        tcell.ColorYellow,
+		Title:         tcell.ColorWhite,
+		Label:         tcell.ColorYellow,
+		Field:         tcell.ColorNavy,
+		Selection:     tcell.ColorYellow,
+		SelectionText: tcell.ColorBlack,
+		Secondary:     tcell.ColorWhite,
+		Danger:        tcell.ColorRed,
+		Heading:       tcell.ColorYellow,
+		Accent:        tcell.ColorAqua,
+		Muted:         tcell.ColorWhite,
+		Dim:           tcell.ColorSilver,
+		Faint:         tcell.ColorGray,
+		Good:          tcell.ColorLime,
+		Warning:       tcell.ColorYellow,
+		Bad:           tcell.GetColor("#ff5f5f"),
+		Info:          tcell.ColorAqua,
+	},
+	ThemeNoColor: {
+		Name:          ThemeNoColor,
+		Background:    tcell.ColorDefault,
+		Text:          tcell.ColorDefault,
+		Border:        tcell.ColorDefault,
+		Title:         tcell.ColorDefault,
+		Label:         tcell.ColorDefault,
+		Field:         tcell.ColorDefault,
+		Selection:     tcell.ColorDefault,
+		SelectionText: tcell.ColorDefault,
+		Secondary:     tcell.ColorDefault,
+		Danger:        tcell.ColorDefault,
+		Heading:       tcell.ColorDefault,
+		Accent:        tcell.ColorDefault,
+		Muted:         tcell.ColorDefault,
+		Dim:           tcell.ColorDefault,
+		Faint:         tcell.ColorDefault,
+		Good:          tcell.ColorDefault,
+		Warning:       tcell.ColorDefault,
+		Bad:           tcell.ColorDefault,
+		Info:          tcell.ColorDefault,
+		Monochrome:    true,
+	},
+}
+
+// theme is the active theme
+var theme = builtinThemes[ThemeDark]
+
+// BuiltinThemes returns the names of the built-in themes in display order
+func BuiltinThemes() []string {
+	return []string{ThemeDark, ThemeLight, ThemeHighContrast, ThemeNoColor}
+}
+
+// themeFile is a custom theme stored as themes/<name>.json in the config
+// directory. Colors not listed are taken from the base theme.
+type themeFile struct {
+	Base       string            `json:"base"`
+	Colors     map[string]string `json:"colors"`
+	Monochrome bool              `json:"monochrome"`
+}
+
+// LoadTheme returns a built-in theme or a custom one from the config
+// directory. NO_COLOR always selects the no-color theme.
+func LoadTheme(name string) (Theme, error) {
+	if os.Getenv("NO_COLOR") != "" {
+		return builtinThemes[ThemeNoColor], nil
+	}
+	if name == "" {
+		name = ThemeDark
+	}
+	if t, ok := builtinThemes[name]; ok {
+		return t, nil
+	}
+	if name != filepath.Base(name) || strings.HasPrefix(name, ".") {
+		return builtinThemes[ThemeDark], fmt.Errorf("invalid theme name %q", name)
+	}
+
+	homeDir, err := os.UserHomeDir()
+	if err != nil {
+		return builtinThemes[ThemeDark], err
+	}
+	return loadThemeFile(name, filepath.Join(homeDir, configDir, themesDir, name+".json"))
+}
+
+// loadThemeFile reads a custom theme
+func loadThemeFile(name, path string) (Theme, error) {
+	fallback := builtinThemes[ThemeDark]
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return fallback, fmt.Errorf("theme %q: %w", name, err)
+	}
+	var file themeFile
+	if err := json.Unmarshal(data, &file); err != nil {
+		return fallback, fmt.Errorf("theme %q: %w", name, err)
+	}
+
+	base := file.Base
+	if base == "" {
+		base = ThemeDark
+	}
+	t, ok := builtinThemes[base]
+	if !ok {
+		return fallback, fmt.Errorf("theme %q: unknown base theme %q", name, base)
+	}
+	t.Name = name
+	t.Monochrome = t.Monochrome || file.Monochrome
+
+	for role, value := range file.Colors {
+		color, err := parseThemeColor(value)
+		if err != nil {
+			return fallback, fmt.Errorf("theme %q: %s: %w", name, role, err)
+		}
+		found := false
+		for _, r := range themeRoles {
+			if r.name == role {
+				*r.color(&t) = color
+				found = true
+			}
+		}
+		if !found {
+			return fallback, fmt.Errorf("theme %q: unknown color %q", name, role)
+		}
+	}
+	return t, nil
+}
+
+// parseThemeColor parses a W3C color name, "#rrggbb" or "default"
+func parseThemeColor(value string) (tcell.Color, error) {
+	value = strings.ToLower(strings.TrimSpace(value))
+	if value == "default" || value == "" {
+		return tcell.ColorDefault, nil
+	}
+	if strings.HasPrefix(value, "#") {
+		if color := tcell.GetColor(value); color != tcell.ColorDefault {
+			return color, nil
+		}
+	} else if color, ok := tcell.ColorNames[value]; ok && !isThemeTag(value) {
+		return color, nil
+	}
+	return tcell.ColorDefault, fmt.Errorf("invalid color %q", value)
+}
+
+// isThemeTag reports whether name is one of the theme's color tags
+func isThemeTag(name string) bool {
+	for _, r := range themeRoles {
+		if r.tag && r.name == name {
+			return true
+		}
+	}
+	return false
+}
+
+// ApplyTheme makes t the active theme. Primitives created afterwards use its
+// colors, and color tags such as [accent] resolve to it right away.
+func ApplyTheme(t Theme) {
+	theme = t
+
+	tview.Styles = tview.Theme{
+		PrimitiveBackgroundColor:    t.Background,
+		ContrastBackgroundColor:     t.Field,
+		MoreContrastBackgroundColor: t.Selection,
+		BorderColor:                 t.Border,
+		TitleColor:                  t.Title,
+		GraphicsColor:               t.Border,
+		PrimaryTextColor:            t.Text,
+		SecondaryTextColor:          t.Label,
+		TertiaryTextColor:           t.Good,
+		InverseTextColor:            t.SelectionText,
+		ContrastSecondaryTextColor:  t.Text,
+	}
+
+	// tview resolves tag colors through tcell's color names
+	for _, r := range themeRoles {
+		if r.tag {
+			tcell.ColorNames[r.name] = *r.color(&t)
+		}
+	}
+}
+
+// selectedStyle returns the style of selected list items and table rows
+func (t Theme) selectedStyle() tcell.Style {
+	if t.Monochrome {
+		return tcell.StyleDefault.Reverse(true)
+	}
+	return tcell.StyleDefault.Foreground(t.SelectionText).Background(t.Selection)
+}
+
+// styleList applies the theme to a list
+func styleList(list *tview.List) *tview.List {
+	style := tcell.StyleDefault.Background(theme.Background)
+	return list.
+		SetMainTextStyle(style.Foreground(theme.Text)).
+		SetSecondaryTextStyle(style.Foreground(theme.Secondary)).
+		SetShortcutStyle(style.Foreground(theme.Label)).
+		SetSelectedStyle(theme.selectedStyle())
+}
+
+// styleTable applies the theme to a table
+func styleTable(table *tview.Table) *tview.Table {
+	table.SetBorderColor(theme.Border)
+	return table.SetSelectedStyle(theme.selectedStyle())
+}
+
+// styleForm applies the theme to a form
+func styleForm(form *tview.Form) *tview.Form {
+	form.SetBorderColor(theme.Border)
+	form.SetLabelColor(theme.Label)
+	if theme.Monochrome {
+		form.SetFieldStyle(tcell.StyleDefault.Underline(true))
+		form.SetButtonStyle(tcell.StyleDefault)
+		form.SetButtonActivatedStyle(theme.selectedStyle())
+		return form
+	}
+	form.SetFieldBackgroundColor(theme.Field)
+	form.SetFieldTextColor(theme.Text)
+	form.SetButtonBackgroundColor(theme.Selection)
+	form.SetButtonTextColor(theme.SelectionText)
+	return form
+}
+
+// styleModal applies the theme to a dialog; danger marks destructive ones
+func styleModal(modal *tview.Modal, danger bool) *tview.Modal {
+	buttonColor := theme.Selection
+	if danger {
+		buttonColor = theme.Danger
+	}
+	modal.SetBackgroundColor(theme.Field)
+	modal.SetTextColor(theme.Text)
+	modal.SetBorderColor(theme.Border)
+	if theme.Monochrome {
+		return modal.
+			SetButtonStyle(tcell.StyleDefault).
+			SetButtonActivatedStyle(theme.selectedStyle())
+	}
+	return modal.
+		SetButtonBackgroundColor(buttonColor).
+		SetButtonTextColor(theme.SelectionText)
+}
+
+// headerCell returns a table header cell
+func headerCell(title string) *tview.TableCell {
+	return tview.NewTableCell(fmt.Sprintf(" [heading::b]%s[::-] ", title)).
+		SetSelectable(false).
+		SetBackgroundColor(theme.Field)
+}
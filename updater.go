@@ -0,0 +1,270 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	binaryManifestFile  = "binary_manifest.json"
+	updateCheckInterval = 6 * time.Hour
+	versionProbeTimeout = 5 * time.Second
+)
+
+// BinaryManifest records which CLIProxyAPI release is installed
+type BinaryManifest struct {
+	Version     string    `json:"version"`
+	Repo        string    `json:"repo"`
+	Asset       string    `json:"asset"`
+	SHA256      string    `json:"sha256"`
+	InstalledAt time.Time `json:"installed_at"`
+}
+
+// errNoVersion is returned when a binary doesn't print a version
+var errNoVersion = errors.New("no version in output")
+
+// versionPattern matches version numbers like v6.1.0 or 6.1.0-rc.1
+var versionPattern = regexp.MustCompile(`v?\d+\.\d+(\.\d+)?(-[0-9A-Za-z.]+)?`)
+
+// manifestPath returns the path of the manifest next to the active binary
+func (pm *ProxyManager) manifestPath() string {
+	binary := pm.binaryPath
+	if resolved, err := filepath.EvalSymlinks(binary); err == nil {
+		binary = resolved
+	}
+	return filepath.Join(filepath.Dir(binary), binaryManifestFile)
+}
+
+// loadManifest reads the manifest of the installed binary
+func (pm *ProxyManager) loadManifest() (*BinaryManifest, error) {
+	return loadManifestFile(pm.manifestPath())
+}
+
+// loadManifestFile reads a manifest file
+func loadManifestFile(path string) (*BinaryManifest, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var manifest BinaryManifest
+	if err := json.Unmarshal(data, &manifest); err != nil {
+		return nil, err
+	}
+	return &manifest, nil
+}
+
+// writeManifest records an installed release in its version directory
+func writeManifest(dir, version, repo, assetName, sha256 string) error {
+	manifest := BinaryManifest{
+		Version:     version,
+		Repo:        repo,
+		Asset:       assetName,
+		SHA256:      sha256,
+		InstalledAt: time.Now(),
+	}
+
+	content, err := json.MarshalIndent(manifest, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	return os.WriteFile(filepath.Join(dir, binaryManifestFile), content, 0644)
+}
+
+// probeBinaryVersion runs a binary with --version and extracts the version
+// it prints
+func probeBinaryVersion(path string) (string, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), versionProbeTimeout)
+	defer cancel()
+
+	output, err := exec.CommandContext(ctx, path, "--version").CombinedOutput()
+	if ctx.Err() != nil {
+		return "", fmt.Errorf("%s did not exit within %s", filepath.Base(path), versionProbeTimeout)
+	}
+
+	if version := versionPattern.FindString(string(output)); version != "" {
+		return version, nil
+	}
+	if err != nil {
+		return "", err
+	}
+	return "", fmt.Errorf("%w of %s --version", errNoVersion, filepath.Base(path))
+}
+
+// GetInstalledVersion returns the version of the installed binary, read from
+// its manifest or, for binaries installed by hand, by running it. Running the
+// binary can take seconds, so the event loop uses CachedInstalledVersion.
+func (pm *ProxyManager) GetInstalledVersion() string {
+	if version, ok := pm.CachedInstalledVersion(); ok {
+		return version
+	}
+
+	version := "unknown"
+	if probed, err := probeBinaryVersion(pm.binaryPath); err == nil {
+		version = probed
+	}
+
+	pm.mutex.Lock()
+	pm.installedVersion = version
+	pm.mutex.Unlock()
+	return version
+}
+
+// CachedInstalledVersion returns the installed version if it is known without
+// running the binary, from an earlier lookup or the manifest
+func (pm *ProxyManager) CachedInstalledVersion() (string, bool) {
+	pm.mutex.RLock()
+	version := pm.installedVersion
+	pm.mutex.RUnlock()
+	if version != "" || !pm.IsBinaryInstalled() {
+		return version, true
+	}
+
+	manifest, err := pm.loadManifest()
+	if err != nil || manifest.Version == "" {
+		return "", false
+	}
+	pm.mutex.Lock()
+	pm.installedVersion = manifest.Version
+	pm.mutex.Unlock()
+	return manifest.Version, true
+}
+
+// CheckForUpdate looks up the latest release and records whether it is
+// newer than the installed binary
+func (pm *ProxyManager) CheckForUpdate() error {
+	pm.mutex.Lock()
+	pm.lastUpdateCheck = time.Now()
+	pm.mutex.Unlock()
+
+	release, err := pm.fetchRelease()
+	if err != nil {
+		pm.AddLogExternal(LogLevelDebug, fmt.Sprintf("Update check failed: %v", err))
+		return err
+	}
+
+	installed := pm.GetInstalledVersion()
+
+	pm.mutex.Lock()
+	previous := pm.latestVersion
+	pm.latestVersion = release.TagName
+	if release.TagName != previous {
+		pm.events.Publish(EventStatusChanged)
+	}
+	pm.mutex.Unlock()
+
+	if release.TagName != previous && isNewerVersion(release.TagName, installed) {
+		pm.AddLogExternal(LogLevelInfo, fmt.Sprintf("CLIProxyAPI %s is available (installed: %s). Press 'U' on the dashboard to upgrade", release.TagName, installed))
+	}
+	return nil
+}
+
+// UpdateCheckDue reports whether the scheduled update check should run
+func (pm *ProxyManager) UpdateCheckDue() bool {
+	pm.mutex.RLock()
+	defer pm.mutex.RUnlock()
+	return !pm.isDownloading && !pm.IsCustomBinary() && time.Since(pm.lastUpdateCheck) >= updateCheckInterval
+}
+
+// GetAvailableUpdate returns the latest release version if it is newer than
+// the installed one, or the pinned version if a different one is installed
+func (pm *ProxyManager) GetAvailableUpdate() (string, bool) {
+	pm.mutex.RLock()
+	latest := pm.latestVersion
+	pm.mutex.RUnlock()
+
+	if latest == "" || pm.IsCustomBinary() || !pm.IsBinaryInstalled() {
+		return "", false
+	}
+	installed, ok := pm.CachedInstalledVersion()
+	if !ok {
+		return "", false
+	}
+	if pm.Config().PinnedVersion != "" {
+		return latest, installed != "unknown" && compareVersions(latest, installed) != 0
+	}
+	return latest, isNewerVersion(latest, installed)
+}
+
+// Upgrade installs the latest release. It is downloaded, verified and staged
+// while the proxy keeps running; a running proxy is only stopped to swap the
+// binary and restarted right after.
+func (pm *ProxyManager) Upgrade() error {
+	restart := false
+	installErr := pm.installRelease(func(dir string) error {
+		if pm.GetStatus().Running {
+			if err := pm.Stop(); err != nil {
+				return fmt.Errorf("failed to stop proxy: %w", err)
+			}
+			restart = true
+		}
+		return pm.activateVersion(dir)
+	})
+
+	// Bring the proxy back even if the swap failed and the old binary remains
+	if restart && pm.IsBinaryInstalled() {
+		if err := pm.Start(); err != nil {
+			return fmt.Errorf("failed to restart proxy: %w", err)
+		}
+	}
+
+	return installErr
+}
+
+// isNewerVersion reports whether version a is newer than b. Unknown
+// versions are never considered older.
+func isNewerVersion(a, b string) bool {
+	if b == "" || b == "unknown" {
+		return false
+	}
+	return compareVersions(a, b) > 0
+}
+
+// compareVersions compares dotted versions with an optional "v" prefix and
+// "-prerelease" suffix, returning -1, 0 or 1
+func compareVersions(a, b string) int {
+	aCore, aPre, _ := strings.Cut(strings.TrimPrefix(strings.TrimSpace(a), "v"), "-")
+	bCore, bPre, _ := strings.Cut(strings.TrimPrefix(strings.TrimSpace(b), "v"), "-")
+
+	aParts := strings.Split(aCore, ".")
+	bParts := strings.Split(bCore, ".")
+	for i := 0; i < len(aParts) || i < len(bParts); i++ {
+		var x, y int
+		if i < len(aParts) {
+			x, _ = strconv.Atoi(aParts[i])
+		}
+		if i < len(bParts) {
+			y, _ = strconv.Atoi(bParts[i])
+		}
+		if x != y {
+			if x < y {
+				return -1
+			}
+			return 1
+		}
+	}
+
+	// A release is newer than its prereleases
+	switch {
+	case aPre == bPre:
+		return 0
+	case aPre == "":
+		return 1
+	case bPre == "":
+		return -1
+	case aPre < bPre:
+		return -1
+	default:
+		return 1
+	}
+}
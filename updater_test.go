@@ -0,0 +1,63 @@
+package main
+
+import (
+	"archive/tar"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+)
+
+func TestInstallReleaseStagesBeforeActivation(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	archiveName := fmt.Sprintf("CLIProxyAPI_6.1.0_%s_%s.tar.gz", runtime.GOOS, runtime.GOARCH)
+	archive := buildTarGz(t, []tarEntry{
+		{header: tar.Header{Name: "cli-proxy-api"}, body: "#!/bin/sh\necho 6.1.0\n"},
+	})
+	release := &fakeRelease{assets: map[string][]byte{
+		archiveName:     archive,
+		"checksums.txt": []byte(sha256Hex(archive) + "  " + archiveName + "\n"),
+	}}
+	pm := NewProxyManager(&Config{Port: 8317, ReleaseRepo: "example/cli", ReleaseMirror: release.serve(t)})
+
+	if err := pm.DownloadAndInstallBinary(); err != nil {
+		t.Fatalf("install failed: %v", err)
+	}
+	active := pm.activeVersionDir()
+
+	// The new version is complete before the swap and the old one still active
+	var staged string
+	err := pm.installRelease(func(dir string) error {
+		staged = dir
+		for _, name := range []string{defaultBinaryName, binaryManifestFile} {
+			if _, err := os.Stat(filepath.Join(dir, name)); err != nil {
+				t.Errorf("%s not staged: %v", name, err)
+			}
+		}
+		if got := pm.activeVersionDir(); got != active {
+			t.Errorf("active version changed to %s before the swap", got)
+		}
+		return errors.New("swap failed")
+	})
+	if err == nil {
+		t.Fatal("install succeeded despite the failed swap")
+	}
+	if _, err := os.Stat(staged); !os.IsNotExist(err) {
+		t.Fatalf("staged version kept after the failed swap: %v", err)
+	}
+	if got := pm.activeVersionDir(); got != active {
+		t.Fatalf("active version changed to %s after the failed swap", got)
+	}
+
+	if err := pm.installRelease(pm.activateVersion); err != nil {
+		t.Fatalf("reinstall failed: %v", err)
+	}
+	if got := pm.activeVersionDir(); got == active || got == "" {
+		t.Fatalf("active version is %q, want the new version", got)
+	}
+	if version, ok := pm.CachedInstalledVersion(); !ok || version != "v6.1.0" {
+		t.Fatalf("installed version is %q, want v6.1.0", version)
+	}
+}
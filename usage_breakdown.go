@@ -0,0 +1,200 @@
+package main
+
+import (
+	"cmp"
+	"sort"
+	"strings"
+	"time"
+)
+
+// BreakdownDimension is the attribute usage is grouped by
+type BreakdownDimension string
+
+const (
+	BreakdownProvider BreakdownDimension = "provider"
+	BreakdownModel    BreakdownDimension = "model"
+	BreakdownAccount  BreakdownDimension = "account"
+	BreakdownAPIKey   BreakdownDimension = "api key"
+)
+
+// BreakdownDimensions returns all dimensions in display order
+func BreakdownDimensions() []BreakdownDimension {
+	return []BreakdownDimension{BreakdownProvider, BreakdownModel, BreakdownAccount, BreakdownAPIKey}
+}
+
+// UsageBreakdownRow aggregates usage for one provider, model, account or API key
+type UsageBreakdownRow struct {
+	Name         string
+	Provider     AIProvider
+	Requests     int
+	Failed       int
+	InputTokens  int
+	OutputTokens int
+	TotalTokens  int
+	totalLatency time.Duration
+	latencyCount int
+}
+
+// ErrorRate returns the percentage of failed requests
+func (r UsageBreakdownRow) ErrorRate() float64 {
+	if r.Requests == 0 {
+		return 0
+	}
+	return float64(r.Failed) / float64(r.Requests) * 100
+}
+
+// AvgLatency returns the average request latency, or 0 if unknown
+func (r UsageBreakdownRow) AvgLatency() time.Duration {
+	if r.latencyCount == 0 {
+		return 0
+	}
+	return r.totalLatency / time.Duration(r.latencyCount)
+}
+
+// BreakdownSortColumn is a column the breakdown table can be sorted by
+type BreakdownSortColumn string
+
+const (
+	SortByName     BreakdownSortColumn = "name"
+	SortByRequests BreakdownSortColumn = "requests"
+	SortByErrors   BreakdownSortColumn = "error rate"
+	SortByTokens   BreakdownSortColumn = "tokens"
+	SortByLatency  BreakdownSortColumn = "latency"
+)
+
+// BreakdownSortColumns returns all sort columns in display order
+func BreakdownSortColumns() []BreakdownSortColumn {
+	return []BreakdownSortColumn{SortByRequests, SortByTokens, SortByErrors, SortByLatency, SortByName}
+}
+
+// providerForModel guesses the provider serving a model from its name
+func providerForModel(model string) AIProvider {
+	name := strings.ToLower(model)
+	switch {
+	case strings.HasPrefix(name, "claude"):
+		return ProviderClaude
+	case strings.HasPrefix(name, "gemini"):
+		return ProviderGemini
+	case strings.HasPrefix(name, "gpt"), strings.HasPrefix(name, "codex"), strings.HasPrefix(name, "o1"),
+		strings.HasPrefix(name, "o3"), strings.HasPrefix(name, "o4"):
+		return ProviderCodex
+	case strings.HasPrefix(name, "qwen"):
+		return ProviderQwen
+	default:
+		return ""
+	}
+}
+
+// ComputeUsageBreakdown groups the per-request details reported by the proxy
+// by the given dimension. Models without details fall back to their totals.
+func ComputeUsageBreakdown(stats UsageStats, dim BreakdownDimension, accounts []AuthFile) []UsageBreakdownRow {
+	// Map account identifiers to their provider
+	accountProviders := map[string]AIProvider{}
+	for _, auth := range accounts {
+		accountProviders[auth.Email] = auth.Provider
+		accountProviders[auth.Name] = auth.Provider
+		accountProviders[auth.ID] = auth.Provider
+	}
+
+	rows := map[string]*UsageBreakdownRow{}
+	row := func(name string, provider AIProvider) *UsageBreakdownRow {
+		if name == "" {
+			name = "unknown"
+		}
+		r, ok := rows[name]
+		if !ok {
+			r = &UsageBreakdownRow{Name: name, Provider: provider}
+			rows[name] = r
+		}
+		return r
+	}
+
+	for apiKey, api := range stats.APIs {
+		for model, modelUsage := range api.Models {
+			modelProvider := providerForModel(model)
+
+			if len(modelUsage.Details) == 0 {
+				// Only totals are known; they can't be split by account
+				r := row(breakdownName(dim, modelProvider, model, "", apiKey), modelProvider)
+				r.Requests += modelUsage.TotalRequests
+				r.TotalTokens += modelUsage.TotalTokens
+				continue
+			}
+
+			for _, detail := range modelUsage.Details {
+				provider := modelProvider
+				if p, ok := accountProviders[detail.Source]; ok {
+					provider = p
+				}
+
+				r := row(breakdownName(dim, provider, model, detail.Source, apiKey), provider)
+				r.Requests++
+				if detail.Failed {
+					r.Failed++
+				}
+				r.InputTokens += detail.Tokens.InputTokens
+				r.OutputTokens += detail.Tokens.OutputTokens
+				r.TotalTokens += detail.Tokens.TotalTokens
+				if detail.LatencyMs > 0 {
+					r.totalLatency += time.Duration(detail.LatencyMs) * time.Millisecond
+					r.latencyCount++
+				}
+			}
+		}
+	}
+
+	result := make([]UsageBreakdownRow, 0, len(rows))
+	for _, r := range rows {
+		result = append(result, *r)
+	}
+	return result
+}
+
+// breakdownName returns the row name of a request for the given dimension
+func breakdownName(dim BreakdownDimension, provider AIProvider, model, account, apiKey string) string {
+	switch dim {
+	case BreakdownProvider:
+		if provider == "" {
+			return ""
+		}
+		return GetProviderInfo(provider).Name
+	case BreakdownModel:
+		return model
+	case BreakdownAccount:
+		return account
+	case BreakdownAPIKey:
+		return maskAPIKey(apiKey)
+	default:
+		return ""
+	}
+}
+
+// SortUsageBreakdown sorts rows by a column; descending puts the largest
+// first. Ties are ordered by name.
+func SortUsageBreakdown(rows []UsageBreakdownRow, column BreakdownSortColumn, descending bool) {
+	compare := func(a, b UsageBreakdownRow) int {
+		switch column {
+		case SortByRequests:
+			return cmp.Compare(a.Requests, b.Requests)
+		case SortByErrors:
+			return cmp.Compare(a.ErrorRate(), b.ErrorRate())
+		case SortByTokens:
+			return cmp.Compare(a.TotalTokens, b.TotalTokens)
+		case SortByLatency:
+			return cmp.Compare(a.AvgLatency(), b.AvgLatency())
+		default:
+			return cmp.Compare(strings.ToLower(a.Name), strings.ToLower(b.Name))
+		}
+	}
+
+	sort.SliceStable(rows, func(i, j int) bool {
+		c := compare(rows[i], rows[j])
+		if descending {
+			c = -c
+		}
+		if c == 0 {
+			return strings.ToLower(rows[i].Name) < strings.ToLower(rows[j].Name)
+		}
+		return c < 0
+	})
+}
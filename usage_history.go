@@ -0,0 +1,234 @@
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+)
+
+const (
+	usageHistoryFile      = "usage_history.jsonl"
+	usageHistoryRetention = 7 * 24 * time.Hour
+	usageBucketSize       = time.Minute
+	requestRateWindow     = 5 * time.Minute
+)
+
+// UsageSample holds request, error and token counts for one time bucket
+type UsageSample struct {
+	Timestamp time.Time `json:"t"`
+	Requests  int       `json:"r"`
+	Errors    int       `json:"e"`
+	Tokens    int       `json:"k"`
+}
+
+// UsageWindow describes a time range shown on the Dashboard
+type UsageWindow struct {
+	Name     string
+	Duration time.Duration
+	Buckets  int
+}
+
+// UsageWindows returns the windows available for usage charts
+func UsageWindows() []UsageWindow {
+	return []UsageWindow{
+		{Name: "Last Hour", Duration: time.Hour, Buckets: 60},
+		{Name: "Last Day", Duration: 24 * time.Hour, Buckets: 48},
+		{Name: "Last Week", Duration: 7 * 24 * time.Hour, Buckets: 42},
+	}
+}
+
+// UsageHistory keeps a time series of usage deltas computed from the
+// running totals reported by the proxy, and persists it to disk
+type UsageHistory struct {
+	path    string
+	samples []UsageSample // One sample per bucket, oldest first
+	last    *UsageStats   // Totals of the previous snapshot
+	mutex   sync.RWMutex
+}
+
+// NewUsageHistory loads the usage history stored in dir
+func NewUsageHistory(dir string) *UsageHistory {
+	h := &UsageHistory{path: filepath.Join(dir, usageHistoryFile)}
+	h.load()
+	return h
+}
+
+// load reads the history file, drops expired samples and compacts the file
+func (h *UsageHistory) load() {
+	file, err := os.Open(h.path)
+	if err != nil {
+		return
+	}
+
+	cutoff := time.Now().Add(-usageHistoryRetention)
+	var samples []UsageSample
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		var sample UsageSample
+		if err := json.Unmarshal(scanner.Bytes(), &sample); err != nil {
+			continue
+		}
+		if sample.Timestamp.Before(cutoff) {
+			continue
+		}
+		samples = append(samples, sample)
+	}
+	file.Close()
+
+	sort.Slice(samples, func(i, j int) bool { return samples[i].Timestamp.Before(samples[j].Timestamp) })
+	for _, sample := range samples {
+		h.add(sample)
+	}
+
+	h.compact()
+}
+
+// compact rewrites the history file with one line per bucket
+func (h *UsageHistory) compact() {
+	tmpPath := h.path + ".tmp"
+	file, err := os.Create(tmpPath)
+	if err != nil {
+		return
+	}
+
+	writer := bufio.NewWriter(file)
+	for _, sample := range h.samples {
+		data, _ := json.Marshal(sample)
+		writer.Write(data)
+		writer.WriteByte('\n')
+	}
+	writer.Flush()
+	file.Close()
+
+	os.Rename(tmpPath, h.path)
+}
+
+// add merges a sample into its bucket (caller holds lock or owns h)
+func (h *UsageHistory) add(sample UsageSample) {
+	sample.Timestamp = sample.Timestamp.Truncate(usageBucketSize)
+
+	if n := len(h.samples); n > 0 && h.samples[n-1].Timestamp.Equal(sample.Timestamp) {
+		h.samples[n-1].Requests += sample.Requests
+		h.samples[n-1].Errors += sample.Errors
+		h.samples[n-1].Tokens += sample.Tokens
+		return
+	}
+
+	h.samples = append(h.samples, sample)
+}
+
+// Record stores the difference between stats and the previous snapshot
+func (h *UsageHistory) Record(stats UsageStats) {
+	h.mutex.Lock()
+	defer h.mutex.Unlock()
+
+	if h.last == nil {
+		// First snapshot only establishes the baseline
+		h.last = &stats
+		return
+	}
+
+	sample := UsageSample{
+		Timestamp: stats.LastUpdated,
+		Requests:  counterDelta(h.last.TotalRequests, stats.TotalRequests),
+		Errors:    counterDelta(h.last.FailedRequests, stats.FailedRequests),
+		Tokens:    counterDelta(h.last.TotalTokens, stats.TotalTokens),
+	}
+	h.last = &stats
+
+	if sample.Requests == 0 && sample.Errors == 0 && sample.Tokens == 0 {
+		return
+	}
+
+	h.add(sample)
+	h.prune()
+	h.append(sample)
+}
+
+// ResetBaseline forgets the previous snapshot, e.g. after the proxy restarts
+func (h *UsageHistory) ResetBaseline() {
+	h.mutex.Lock()
+	defer h.mutex.Unlock()
+	h.last = nil
+}
+
+// counterDelta returns the increase of a counter; a counter that went down
+// means the proxy restarted, so the new value is the increase
+func counterDelta(prev, cur int) int {
+	if cur < prev {
+		return cur
+	}
+	return cur - prev
+}
+
+// prune drops samples older than the retention period
+func (h *UsageHistory) prune() {
+	cutoff := time.Now().Add(-usageHistoryRetention)
+	i := 0
+	for i < len(h.samples) && h.samples[i].Timestamp.Before(cutoff) {
+		i++
+	}
+	h.samples = h.samples[i:]
+}
+
+// append writes a sample to the end of the history file
+func (h *UsageHistory) append(sample UsageSample) {
+	file, err := os.OpenFile(h.path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		return
+	}
+	defer file.Close()
+
+	data, _ := json.Marshal(sample)
+	file.Write(append(data, '\n'))
+}
+
+// Series aggregates the history into evenly sized buckets covering window,
+// oldest first
+func (h *UsageHistory) Series(window UsageWindow) []UsageSample {
+	h.mutex.RLock()
+	defer h.mutex.RUnlock()
+
+	step := window.Duration / time.Duration(window.Buckets)
+	end := time.Now().Truncate(usageBucketSize).Add(usageBucketSize)
+	start := end.Add(-window.Duration)
+
+	series := make([]UsageSample, window.Buckets)
+	for i := range series {
+		series[i].Timestamp = start.Add(time.Duration(i) * step)
+	}
+
+	for _, sample := range h.samples {
+		if sample.Timestamp.Before(start) || !sample.Timestamp.Before(end) {
+			continue
+		}
+		i := int(sample.Timestamp.Sub(start) / step)
+		series[i].Requests += sample.Requests
+		series[i].Errors += sample.Errors
+		series[i].Tokens += sample.Tokens
+	}
+
+	return series
+}
+
+// RequestRate returns the average number of requests per minute over the
+// last few minutes
+func (h *UsageHistory) RequestRate() float64 {
+	window := UsageWindow{Duration: requestRateWindow, Buckets: int(requestRateWindow / usageBucketSize)}
+	return float64(SumUsage(h.Series(window)).Requests) / requestRateWindow.Minutes()
+}
+
+// SumUsage returns the totals of a series
+func SumUsage(series []UsageSample) UsageSample {
+	var total UsageSample
+	for _, sample := range series {
+		total.Requests += sample.Requests
+		total.Errors += sample.Errors
+		total.Tokens += sample.Tokens
+	}
+	return total
+}
@@ -0,0 +1,211 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/ed25519"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+)
+
+// findChecksumAsset finds the checksums file of a release, preferring a
+// per-asset "<asset>.sha256" file over a combined checksums list
+func findChecksumAsset(release *releaseInfo, assetName string) *assetInfo {
+	for i, asset := range release.Assets {
+		if asset.Name == assetName+".sha256" {
+			return &release.Assets[i]
+		}
+	}
+
+	for i, asset := range release.Assets {
+		name := strings.ToLower(asset.Name)
+		if strings.Contains(name, "checksum") && !strings.HasSuffix(name, ".sig") {
+			return &release.Assets[i]
+		}
+	}
+
+	return nil
+}
+
+// findSignatureAsset finds the signature of a checksums file
+func findSignatureAsset(release *releaseInfo, checksumName string) *assetInfo {
+	for i, asset := range release.Assets {
+		if asset.Name == checksumName+".sig" {
+			return &release.Assets[i]
+		}
+	}
+	return nil
+}
+
+// parseChecksum returns the SHA-256 listed for assetName. It understands the
+// "<hash>  <file>" format of sha256sum and goreleaser as well as files that
+// contain only a hash.
+func parseChecksum(data []byte, assetName string) (string, error) {
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	var lines [][]string
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) == 0 {
+			continue
+		}
+		lines = append(lines, fields)
+	}
+
+	for _, fields := range lines {
+		if len(fields) >= 2 && strings.TrimPrefix(fields[len(fields)-1], "*") == assetName {
+			return strings.ToLower(fields[0]), nil
+		}
+	}
+
+	// A file with a single bare hash belongs to the asset it was published for
+	if len(lines) == 1 && len(lines[0]) == 1 {
+		return strings.ToLower(lines[0][0]), nil
+	}
+
+	return "", fmt.Errorf("no checksum listed for %s", assetName)
+}
+
+// fileSHA256 returns the hex encoded SHA-256 of a file
+func fileSHA256(path string) (string, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer file.Close()
+
+	hash := sha256.New()
+	if _, err := io.Copy(hash, file); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(hash.Sum(nil)), nil
+}
+
+// verifySHA256 checks a hex encoded SHA-256 hash against the expected one
+func verifySHA256(actual, expected string) error {
+	if !strings.EqualFold(actual, strings.TrimSpace(expected)) {
+		return fmt.Errorf("checksum mismatch: expected %s, got %s", expected, actual)
+	}
+	return nil
+}
+
+// decodeKeyOrSignature accepts raw bytes or their base64/hex encoding
+func decodeKeyOrSignature(data []byte, size int) ([]byte, error) {
+	if len(data) == size {
+		return data, nil
+	}
+
+	text := strings.TrimSpace(string(data))
+	if decoded, err := base64.StdEncoding.DecodeString(text); err == nil && len(decoded) == size {
+		return decoded, nil
+	}
+	if decoded, err := hex.DecodeString(text); err == nil && len(decoded) == size {
+		return decoded, nil
+	}
+
+	return nil, fmt.Errorf("expected %d bytes, raw or base64/hex encoded", size)
+}
+
+// verifySignature checks an Ed25519 signature of message with a public key
+func verifySignature(message, signature []byte, publicKey string) error {
+	key, err := decodeKeyOrSignature([]byte(publicKey), ed25519.PublicKeySize)
+	if err != nil {
+		return fmt.Errorf("invalid public key: %w", err)
+	}
+	sig, err := decodeKeyOrSignature(signature, ed25519.SignatureSize)
+	if err != nil {
+		return fmt.Errorf("invalid signature: %w", err)
+	}
+	if !ed25519.Verify(ed25519.PublicKey(key), message, sig) {
+		return fmt.Errorf("signature verification failed")
+	}
+	return nil
+}
+
+// checksumFile is a release checksums file with its signature, if any
+type checksumFile struct {
+	name      string
+	data      []byte
+	signature []byte // nil when the file is unsigned
+}
+
+// verifyChecksums applies the verification rules shared by downloads and
+// local archives to an asset, given its SHA-256. A pinned SHA-256 must match.
+// Without a checksums file the asset is refused unless a SHA-256 is pinned
+// and no public key is configured. With one, a configured public key requires
+// a valid signature of it, and it must list a matching SHA-256.
+func (pm *ProxyManager) verifyChecksums(assetName, actual string, checksums *checksumFile) error {
+	cfg := pm.Config()
+	pinned := strings.TrimSpace(cfg.BinarySHA256)
+	publicKey := strings.TrimSpace(cfg.BinaryPublicKey)
+
+	if pinned != "" {
+		if err := verifySHA256(actual, pinned); err != nil {
+			return fmt.Errorf("pinned %w", err)
+		}
+		pm.AddLogExternal(LogLevelInfo, "Pinned SHA-256 verified")
+	}
+
+	if checksums == nil {
+		if publicKey != "" {
+			return fmt.Errorf("no signed checksums file for %s; refusing to install unverified binary", assetName)
+		}
+		if pinned == "" {
+			return fmt.Errorf("no checksums file for %s; refusing to install unverified binary", assetName)
+		}
+		return nil
+	}
+
+	if publicKey != "" {
+		if checksums.signature == nil {
+			return fmt.Errorf("no signature for %s", checksums.name)
+		}
+		if err := verifySignature(checksums.data, checksums.signature, publicKey); err != nil {
+			return fmt.Errorf("%s: %w", checksums.name, err)
+		}
+		pm.AddLogExternal(LogLevelInfo, fmt.Sprintf("Signature of %s verified", checksums.name))
+	}
+
+	expected, err := parseChecksum(checksums.data, assetName)
+	if err != nil {
+		return err
+	}
+	if err := verifySHA256(actual, expected); err != nil {
+		return err
+	}
+
+	pm.AddLogExternal(LogLevelInfo, fmt.Sprintf("SHA-256 of %s verified with %s", assetName, checksums.name))
+	return nil
+}
+
+// verifyAsset verifies a downloaded asset, given its SHA-256, against the
+// checksums file of its release before it is installed
+func (pm *ProxyManager) verifyAsset(release *releaseInfo, asset *assetInfo, actual string) error {
+	checksumAsset := findChecksumAsset(release, asset.Name)
+	if checksumAsset == nil {
+		return pm.verifyChecksums(asset.Name, actual, nil)
+	}
+
+	data, err := pm.downloadAsset(checksumAsset.DownloadURL)
+	if err != nil {
+		return fmt.Errorf("failed to download %s: %w", checksumAsset.Name, err)
+	}
+	checksums := &checksumFile{name: checksumAsset.Name, data: data}
+
+	// The signature is only fetched when it is checked
+	if pm.Config().BinaryPublicKey != "" {
+		if sigAsset := findSignatureAsset(release, checksumAsset.Name); sigAsset != nil {
+			signature, err := pm.downloadAsset(sigAsset.DownloadURL)
+			if err != nil {
+				return fmt.Errorf("failed to download %s: %w", sigAsset.Name, err)
+			}
+			checksums.signature = signature
+		}
+	}
+
+	return pm.verifyChecksums(asset.Name, actual, checksums)
+}
@@ -0,0 +1,231 @@
+package main
+
+import (
+	"archive/tar"
+	"crypto/ed25519"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"runtime"
+	"strings"
+	"testing"
+)
+
+// fakeRelease is a release served by a local fake GitHub API
+type fakeRelease struct {
+	assets map[string][]byte
+}
+
+// serve starts the fake release server and returns its URL
+func (r *fakeRelease) serve(t *testing.T) string {
+	t.Helper()
+	mux := http.NewServeMux()
+	mux.HandleFunc("/repos/example/cli/releases/latest", func(w http.ResponseWriter, _ *http.Request) {
+		release := releaseInfo{TagName: "v6.1.0"}
+		for name := range r.assets {
+			release.Assets = append(release.Assets, assetInfo{Name: name, DownloadURL: "download/" + name})
+		}
+		json.NewEncoder(w).Encode(release)
+	})
+	mux.HandleFunc("/repos/example/cli/releases/download/", func(w http.ResponseWriter, req *http.Request) {
+		data, ok := r.assets[strings.TrimPrefix(req.URL.Path, "/repos/example/cli/releases/download/")]
+		if !ok {
+			http.NotFound(w, req)
+			return
+		}
+		w.Write(data)
+	})
+	server := httptest.NewServer(mux)
+	t.Cleanup(server.Close)
+	return server.URL
+}
+
+func sha256Hex(data []byte) string {
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+func TestDownloadVerification(t *testing.T) {
+	archiveName := fmt.Sprintf("CLIProxyAPI_6.1.0_%s_%s.tar.gz", runtime.GOOS, runtime.GOARCH)
+	archive := buildTarGz(t, []tarEntry{
+		{header: tar.Header{Name: "cli-proxy-api"}, body: "#!/bin/sh\necho 6.1.0\n"},
+		{header: tar.Header{Name: "README.md", Mode: 0644}, body: "readme"},
+	})
+	goodChecksums := []byte(sha256Hex(archive) + "  " + archiveName + "\n" + sha256Hex([]byte("other")) + "  other.zip\n")
+	badChecksums := []byte(sha256Hex([]byte("tampered")) + "  " + archiveName + "\n")
+
+	publicKey, privateKey, err := ed25519.GenerateKey(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	encodedKey := base64.StdEncoding.EncodeToString(publicKey)
+	validSig := []byte(base64.StdEncoding.EncodeToString(ed25519.Sign(privateKey, goodChecksums)))
+	tamperedSig := []byte(base64.StdEncoding.EncodeToString(ed25519.Sign(privateKey, append([]byte("#"), goodChecksums...))))
+
+	tests := []struct {
+		name      string
+		assets    map[string][]byte
+		pinned    string
+		publicKey string
+		wantErr   string
+	}{
+		{
+			name:   "good checksum",
+			assets: map[string][]byte{archiveName: archive, "checksums.txt": goodChecksums},
+		},
+		{
+			name:   "per-asset checksum file",
+			assets: map[string][]byte{archiveName: archive, archiveName + ".sha256": []byte(sha256Hex(archive)), "checksums.txt": badChecksums},
+		},
+		{
+			name:    "checksum mismatch",
+			assets:  map[string][]byte{archiveName: archive, "checksums.txt": badChecksums},
+			wantErr: "checksum mismatch",
+		},
+		{
+			name:    "asset not listed",
+			assets:  map[string][]byte{archiveName: archive, "checksums.txt": []byte(sha256Hex(archive) + "  other.tar.gz\n")},
+			wantErr: "no checksum listed",
+		},
+		{
+			name:    "missing checksums file",
+			assets:  map[string][]byte{archiveName: archive},
+			wantErr: "refusing to install unverified binary",
+		},
+		{
+			name:   "missing checksums file with pinned hash",
+			assets: map[string][]byte{archiveName: archive},
+			pinned: sha256Hex(archive),
+		},
+		{
+			name:    "pinned hash mismatch",
+			assets:  map[string][]byte{archiveName: archive, "checksums.txt": goodChecksums},
+			pinned:  sha256Hex([]byte("other")),
+			wantErr: "pinned checksum mismatch",
+		},
+		{
+			name:      "pinned hash does not skip the public key",
+			assets:    map[string][]byte{archiveName: archive},
+			pinned:    sha256Hex(archive),
+			publicKey: encodedKey,
+			wantErr:   "no signed checksums file",
+		},
+		{
+			name:      "valid signature",
+			assets:    map[string][]byte{archiveName: archive, "checksums.txt": goodChecksums, "checksums.txt.sig": validSig},
+			publicKey: encodedKey,
+		},
+		{
+			name:      "tampered signature",
+			assets:    map[string][]byte{archiveName: archive, "checksums.txt": goodChecksums, "checksums.txt.sig": tamperedSig},
+			publicKey: encodedKey,
+			wantErr:   "signature verification failed",
+		},
+		{
+			name:      "missing signature",
+			assets:    map[string][]byte{archiveName: archive, "checksums.txt": goodChecksums},
+			publicKey: encodedKey,
+			wantErr:   "no signature for checksums.txt",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv("HOME", t.TempDir())
+			release := &fakeRelease{assets: tt.assets}
+			pm := NewProxyManager(&Config{
+				Port:            8317,
+				ReleaseRepo:     "example/cli",
+				ReleaseMirror:   release.serve(t),
+				BinarySHA256:    tt.pinned,
+				BinaryPublicKey: tt.publicKey,
+			})
+
+			err := pm.DownloadAndInstallBinary()
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Fatalf("install failed: %v", err)
+				}
+				if data, err := os.ReadFile(pm.GetBinaryPath()); err != nil || !strings.Contains(string(data), "6.1.0") {
+					t.Fatalf("binary not installed: %v", err)
+				}
+				return
+			}
+
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Fatalf("got error %v, want %q", err, tt.wantErr)
+			}
+			if pm.IsBinaryInstalled() {
+				t.Fatal("binary installed despite failed verification")
+			}
+			if _, err := os.Stat(pm.partialDownloadPath(archiveName)); err == nil {
+				t.Fatal("rejected download kept for resuming")
+			}
+		})
+	}
+}
+
+func TestLocalArchiveVerification(t *testing.T) {
+	archive := []byte("archive")
+	checksums := []byte(sha256Hex(archive) + "  cli.tar.gz\n")
+	publicKey, privateKey, err := ed25519.GenerateKey(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	encodedKey := hex.EncodeToString(publicKey)
+
+	tests := []struct {
+		name      string
+		files     map[string][]byte
+		pinned    string
+		publicKey string
+		wantErr   string
+	}{
+		{name: "checksums file", files: map[string][]byte{"checksums.txt": checksums}},
+		{name: "per-archive file", files: map[string][]byte{"cli.tar.gz.sha256": []byte(sha256Hex(archive))}},
+		{name: "no checksums", wantErr: "refusing to install unverified binary"},
+		{name: "no checksums with pinned hash", pinned: sha256Hex(archive)},
+		{name: "pinned hash with public key", pinned: sha256Hex(archive), publicKey: encodedKey, wantErr: "no signed checksums file"},
+		{
+			name:      "valid signature",
+			files:     map[string][]byte{"checksums.txt": checksums, "checksums.txt.sig": ed25519.Sign(privateKey, checksums)},
+			publicKey: encodedKey,
+		},
+		{
+			name:      "tampered signature",
+			files:     map[string][]byte{"checksums.txt": checksums, "checksums.txt.sig": ed25519.Sign(privateKey, []byte("other"))},
+			publicKey: encodedKey,
+			wantErr:   "signature verification failed",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv("HOME", t.TempDir())
+			dir := t.TempDir()
+			path := dir + "/cli.tar.gz"
+			if err := os.WriteFile(path, archive, 0644); err != nil {
+				t.Fatal(err)
+			}
+			for name, data := range tt.files {
+				if err := os.WriteFile(dir+"/"+name, data, 0644); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			pm := NewProxyManager(&Config{Port: 8317, BinarySHA256: tt.pinned, BinaryPublicKey: tt.publicKey})
+			err := pm.verifyLocalArchive(path, sha256Hex(archive))
+			if tt.wantErr == "" && err != nil {
+				t.Fatalf("verification failed: %v", err)
+			}
+			if tt.wantErr != "" && (err == nil || !strings.Contains(err.Error(), tt.wantErr)) {
+				t.Fatalf("got error %v, want %q", err, tt.wantErr)
+			}
+		})
+	}
+}
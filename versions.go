@@ -0,0 +1,276 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"time"
+)
+
+const (
+	versionsDir         = "versions"
+	defaultKeepVersions = 3
+)
+
+// InstalledVersion is a binary kept in its own directory under versions/
+type InstalledVersion struct {
+	Name        string // Directory name
+	Version     string
+	InstalledAt time.Time
+	Active      bool
+}
+
+// unsafeVersionChars matches characters not allowed in version directory names
+var unsafeVersionChars = regexp.MustCompile(`[^A-Za-z0-9._-]`)
+
+// versionsPath returns the directory holding all installed versions
+func (pm *ProxyManager) versionsPath() string {
+	return filepath.Join(filepath.Dir(pm.managedBinaryPath), versionsDir)
+}
+
+// activeVersionDir returns the version directory the binary link points to,
+// or "" if the binary is not a link into versions/
+func (pm *ProxyManager) activeVersionDir() string {
+	resolved, err := filepath.EvalSymlinks(pm.managedBinaryPath)
+	if err != nil {
+		return ""
+	}
+	versions, err := filepath.EvalSymlinks(pm.versionsPath())
+	if err != nil || filepath.Dir(filepath.Dir(resolved)) != versions {
+		return ""
+	}
+	return filepath.Dir(resolved)
+}
+
+// readManifest reads the manifest stored in a version directory
+func readManifest(dir string) (*BinaryManifest, error) {
+	return loadManifestFile(filepath.Join(dir, binaryManifestFile))
+}
+
+// InstalledVersions lists the kept versions, newest first
+func (pm *ProxyManager) InstalledVersions() []InstalledVersion {
+	entries, err := os.ReadDir(pm.versionsPath())
+	if err != nil {
+		return nil
+	}
+
+	activeName := filepath.Base(pm.activeVersionDir())
+	var versions []InstalledVersion
+	for _, entry := range entries {
+		if !entry.IsDir() {
+			continue
+		}
+		dir := filepath.Join(pm.versionsPath(), entry.Name())
+		if _, err := os.Stat(filepath.Join(dir, defaultBinaryName)); err != nil {
+			continue
+		}
+
+		version := InstalledVersion{Name: entry.Name(), Version: entry.Name(), Active: entry.Name() == activeName}
+		if manifest, err := readManifest(dir); err == nil {
+			version.Version = manifest.Version
+			version.InstalledAt = manifest.InstalledAt
+		} else if info, err := entry.Info(); err == nil {
+			version.InstalledAt = info.ModTime()
+		}
+		versions = append(versions, version)
+	}
+
+	sort.Slice(versions, func(i, j int) bool {
+		return versions[i].InstalledAt.After(versions[j].InstalledAt)
+	})
+	return versions
+}
+
+// newVersionDir creates an empty directory for a version
+func (pm *ProxyManager) newVersionDir(version string) (string, error) {
+	name := unsafeVersionChars.ReplaceAllString(version, "_")
+	if name == "" || name == "." || name == ".." {
+		name = "unknown"
+	}
+
+	dir := filepath.Join(pm.versionsPath(), name)
+	if _, err := os.Stat(dir); err == nil {
+		// Reinstalling a version never touches the copy that may be running
+		dir = fmt.Sprintf("%s-%s", dir, time.Now().Format("20060102150405"))
+	}
+
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return "", err
+	}
+	return dir, nil
+}
+
+// activateVersion atomically points the binary link at a version directory
+func (pm *ProxyManager) activateVersion(dir string) error {
+	target, err := filepath.Rel(filepath.Dir(pm.managedBinaryPath), filepath.Join(dir, defaultBinaryName))
+	if err != nil {
+		return err
+	}
+
+	tmpLink := pm.managedBinaryPath + ".new"
+	os.Remove(tmpLink)
+	if err := os.Symlink(target, tmpLink); err != nil {
+		return err
+	}
+	if err := os.Rename(tmpLink, pm.managedBinaryPath); err != nil {
+		os.Remove(tmpLink)
+		return err
+	}
+
+	pm.mutex.Lock()
+	pm.installedVersion = ""
+	pm.events.Publish(EventStatusChanged)
+	pm.mutex.Unlock()
+	return nil
+}
+
+// adoptLegacyBinary moves a binary installed before versioned directories
+// existed into versions/, so it can be rolled back to
+func (pm *ProxyManager) adoptLegacyBinary() error {
+	info, err := os.Lstat(pm.managedBinaryPath)
+	if err != nil || !info.Mode().IsRegular() {
+		return nil
+	}
+
+	legacyManifest := filepath.Join(filepath.Dir(pm.managedBinaryPath), binaryManifestFile)
+	version := "unknown"
+	if manifest, err := loadManifestFile(legacyManifest); err == nil && manifest.Version != "" {
+		version = manifest.Version
+	} else if probed, err := probeBinaryVersion(pm.managedBinaryPath); err == nil {
+		version = probed
+	}
+
+	dir, err := pm.newVersionDir(version)
+	if err != nil {
+		return err
+	}
+
+	if err := os.Rename(pm.managedBinaryPath, filepath.Join(dir, defaultBinaryName)); err != nil {
+		return err
+	}
+	os.Rename(legacyManifest, filepath.Join(dir, binaryManifestFile))
+
+	pm.AddLogExternal(LogLevelInfo, fmt.Sprintf("Moved installed binary %s to %s", version, dir))
+	return pm.activateVersion(dir)
+}
+
+// stageVersion copies a binary into a new version directory without making
+// it the active one, so the running binary stays in place until the link is
+// swapped
+func (pm *ProxyManager) stageVersion(binaryPath, version string) (string, error) {
+	if err := pm.adoptLegacyBinary(); err != nil {
+		pm.AddLogExternal(LogLevelWarn, fmt.Sprintf("Failed to keep previous binary: %v", err))
+	}
+
+	dir, err := pm.newVersionDir(version)
+	if err != nil {
+		return "", err
+	}
+
+	if err := copyFile(binaryPath, filepath.Join(dir, defaultBinaryName), 0755); err != nil {
+		os.RemoveAll(dir)
+		return "", err
+	}
+	return dir, nil
+}
+
+// copyFile streams src into a new file at dst
+func copyFile(src, dst string, perm os.FileMode) error {
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+
+	out, err := os.OpenFile(dst, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, perm)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(out, in); err != nil {
+		out.Close()
+		return err
+	}
+	return out.Close()
+}
+
+// PruneVersions removes old versions beyond the configured number to keep.
+// The active version is always kept.
+func (pm *ProxyManager) PruneVersions() {
+	keep := pm.Config().KeepVersions
+	if keep <= 0 {
+		keep = defaultKeepVersions
+	}
+
+	versions := pm.InstalledVersions()
+	kept := 0
+	for _, v := range versions {
+		if v.Active {
+			kept++
+		}
+	}
+
+	for _, v := range versions {
+		if v.Active {
+			continue
+		}
+		if kept < keep {
+			kept++
+			continue
+		}
+		if err := os.RemoveAll(filepath.Join(pm.versionsPath(), v.Name)); err != nil {
+			pm.AddLogExternal(LogLevelWarn, fmt.Sprintf("Failed to remove old version %s: %v", v.Version, err))
+			continue
+		}
+		pm.AddLogExternal(LogLevelInfo, fmt.Sprintf("Removed old version %s", v.Version))
+	}
+}
+
+// PreviousVersion returns the newest kept version installed before the active one
+func (pm *ProxyManager) PreviousVersion() (InstalledVersion, bool) {
+	versions := pm.InstalledVersions()
+	for i, v := range versions {
+		if v.Active && i+1 < len(versions) {
+			return versions[i+1], true
+		}
+	}
+	return InstalledVersion{}, false
+}
+
+// Rollback switches back to the previous version, restarting the proxy
+// around the swap if it was running
+func (pm *ProxyManager) Rollback() (string, error) {
+	if pm.IsCustomBinary() {
+		return "", fmt.Errorf("a custom binary is configured (%s); rollback only applies to installed releases", pm.Config().BinaryPath)
+	}
+	if err := pm.adoptLegacyBinary(); err != nil {
+		return "", err
+	}
+
+	previous, ok := pm.PreviousVersion()
+	if !ok {
+		return "", fmt.Errorf("no previous version to roll back to")
+	}
+
+	wasRunning := pm.GetStatus().Running
+	if wasRunning {
+		if err := pm.Stop(); err != nil {
+			return "", fmt.Errorf("failed to stop proxy: %w", err)
+		}
+	}
+
+	swapErr := pm.activateVersion(filepath.Join(pm.versionsPath(), previous.Name))
+	if swapErr == nil {
+		pm.AddLogExternal(LogLevelInfo, fmt.Sprintf("Rolled back to CLIProxyAPI %s", previous.Version))
+	}
+
+	if wasRunning {
+		if err := pm.Start(); err != nil {
+			return previous.Version, fmt.Errorf("failed to restart proxy: %w", err)
+		}
+	}
+
+	return previous.Version, swapErr
+}